@@ -11,10 +11,17 @@ import (
 	"time"
 
 	"agentgo/internal/aggregator"
+	"agentgo/internal/alert"
 	"agentgo/internal/config"
+	"agentgo/internal/digest"
+	"agentgo/internal/history"
 	"agentgo/internal/httpserver"
+	"agentgo/internal/monitor"
 	"agentgo/internal/provider"
 	"agentgo/internal/provider/mock"
+	"agentgo/internal/saved"
+	"agentgo/internal/summary/entity"
+	"agentgo/internal/summary/sentiment"
 	simplesummary "agentgo/internal/summary/simple"
 )
 
@@ -35,14 +42,160 @@ func main() {
 		providers[mockProvider.Name()] = mockProvider
 	}
 
-	summ := simplesummary.New()
-	agg := aggregator.New(providers, summ, aggregator.Config{
-		CacheTTL:       cfg.CacheTTL,
-		RequestTimeout: cfg.RequestTimeout,
-		HistorySize:    cfg.HistorySize,
+	lexicon := sentiment.DefaultLexicon()
+	if cfg.SentimentLexicon != "" {
+		custom, err := sentiment.LoadLexiconFile(cfg.SentimentLexicon)
+		if err != nil {
+			log.Fatalf("load sentiment lexicon: %v", err)
+		}
+		lexicon.Merge(custom)
+	}
+
+	aspects, err := sentiment.ParseAspects(cfg.SentimentAspects)
+	if err != nil {
+		log.Fatalf("parse sentiment aspects: %v", err)
+	}
+
+	gazetteer := entity.DefaultGazetteer()
+	if cfg.EntityGazetteer != "" {
+		entries, err := entity.LoadGazetteerFile(cfg.EntityGazetteer)
+		if err != nil {
+			log.Fatalf("load entity gazetteer: %v", err)
+		}
+		gazetteer.Add(entries...)
+	}
+
+	templates := simplesummary.DefaultTemplates()
+	if cfg.TemplateDir != "" {
+		templates, err = simplesummary.LoadTemplates(cfg.TemplateDir)
+		if err != nil {
+			log.Fatalf("load summary templates: %v", err)
+		}
+	}
+
+	summ := simplesummary.NewWithConfig(simplesummary.Config{
+		Lexicon:   lexicon,
+		Aspects:   aspects,
+		Gazetteer: gazetteer,
+		Templates: templates,
+		Locale:    cfg.SummaryLocale,
+	})
+	aggCfg := aggregator.Config{
+		CacheTTL:         cfg.CacheTTL,
+		RequestTimeout:   cfg.RequestTimeout,
+		HistorySize:      cfg.HistorySize,
+		SummaryCacheTTL:  cfg.SummaryCacheTTL,
+		CacheMaxEntries:  cfg.CacheMaxEntries,
+		CacheMaxBytes:    cfg.CacheMaxBytes,
+		StaleTTL:         cfg.CacheStaleTTL,
+		ProviderCacheTTL: cfg.ProviderCacheTTL,
+	}
+	var historyBackend history.Backend
+	if cfg.HistoryDir != "" {
+		historyBackend, err = history.NewFileBackend(cfg.HistoryDir, history.FileOptions{MaxFileBytes: cfg.HistoryFileBytes})
+		if err != nil {
+			log.Fatalf("open history: %v", err)
+		}
+	}
+	aggCfg.History, err = history.NewStoreWithConfig(history.Config{
+		MaxRecords:     cfg.HistorySize,
+		MaxDiskRecords: cfg.HistoryDiskSize,
+		MaxAge:         cfg.HistoryMaxAge,
+		Backend:        historyBackend,
+		MaxSnapshots:   cfg.HistorySnapshots,
 	})
+	if err != nil {
+		log.Fatalf("load history: %v", err)
+	}
+	alertRules, err := alert.NewStore(cfg.AlertRulesFile)
+	if err != nil {
+		log.Fatalf("load alert rules: %v", err)
+	}
+	notifier := alert.NewNotifier(alert.NotifierConfig{AllowTargets: cfg.AlertAllowHosts})
+	defer notifier.Close()
+	alerts := alert.NewEngine(alertRules, notifier)
+	aggCfg.OnSearch = alerts.ObserveSearch
 
-	server := httpserver.New(agg)
+	aggCfg.ResponseCache, aggCfg.ProviderCache, err = aggregator.OpenCaches(aggregator.CacheConfig{
+		Backend:         cfg.CacheBackend,
+		Dir:             cfg.CacheDir,
+		RedisAddr:       cfg.RedisAddr,
+		RedisPassword:   cfg.RedisPassword,
+		RedisDB:         cfg.RedisDB,
+		RedisPrefix:     cfg.RedisPrefix,
+		RedisSerializer: cfg.RedisSerializer,
+	}, aggCfg)
+	if err != nil {
+		log.Fatalf("open cache: %v", err)
+	}
+	agg := aggregator.New(providers, summ, aggCfg)
+	defer agg.Close()
+
+	savedSearches, err := saved.NewStore(cfg.SavedSearchFile)
+	if err != nil {
+		log.Fatalf("load saved searches: %v", err)
+	}
+
+	location := time.Local
+	if cfg.MonitorTimezone != "" {
+		if location, err = time.LoadLocation(cfg.MonitorTimezone); err != nil {
+			log.Fatalf("load monitor timezone: %v", err)
+		}
+	}
+	jobs, err := monitor.NewStore(monitor.StoreConfig{Dir: cfg.MonitorDir, MaxRuns: cfg.MonitorMaxRuns})
+	if err != nil {
+		log.Fatalf("load monitor jobs: %v", err)
+	}
+	defer jobs.Close()
+	scheduler := monitor.New(jobs, monitor.SavedSearchRunner(agg, savedSearches), monitor.Config{Location: location})
+	scheduler.Start()
+	defer scheduler.Close()
+
+	digestTemplates := digest.DefaultTemplates(location)
+	if cfg.DigestTemplates != "" {
+		if digestTemplates, err = digest.LoadTemplates(cfg.DigestTemplates, location); err != nil {
+			log.Fatalf("load digest templates: %v", err)
+		}
+	}
+	var mailer *digest.Mailer
+	if cfg.SMTPAddr != "" {
+		mailer, err = digest.NewMailer(digest.SMTPConfig{
+			Addr:           cfg.SMTPAddr,
+			Username:       cfg.SMTPUsername,
+			Password:       cfg.SMTPPassword,
+			From:           cfg.SMTPFrom,
+			AllowPlaintext: cfg.SMTPPlaintext,
+		})
+		if err != nil {
+			log.Fatalf("configure smtp: %v", err)
+		}
+	}
+	if cfg.DigestSchedule != "" {
+		if mailer == nil {
+			log.Fatalf("DIGEST_SCHEDULE requires SMTP_ADDR")
+		}
+		digests, err := digest.StartSchedule(digest.NewGenerator(agg, digest.Config{Runs: jobs}), digestTemplates, mailer, savedSearches, digest.ScheduleConfig{
+			Spec:     cfg.DigestSchedule,
+			Location: location,
+			Owner:    cfg.DigestOwner,
+			To:       cfg.DigestTo,
+			Period:   cfg.DigestPeriod,
+		})
+		if err != nil {
+			log.Fatalf("start digest schedule: %v", err)
+		}
+		defer digests.Close()
+	}
+
+	server := httpserver.NewWithConfig(agg, httpserver.Config{
+		AdminToken:      cfg.AdminToken,
+		APIKeys:         cfg.APIKeys,
+		SavedSearches:   savedSearches,
+		Scheduler:       scheduler,
+		Alerts:          alerts,
+		DigestTemplates: digestTemplates,
+		Mailer:          mailer,
+	})
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
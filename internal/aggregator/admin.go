@@ -0,0 +1,171 @@
+package aggregator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"agentgo/internal/cache"
+)
+
+// 缓存名称，与 CacheStats 的键一致。
+const (
+	CacheResponses = "responses"
+	CacheProviders = "providers"
+	CacheSummaries = "summaries"
+)
+
+// CacheEntry 管理接口展示的缓存条目。
+type CacheEntry struct {
+	Cache string `json:"cache"`
+	Key   string `json:"key"`
+	Query string `json:"query"`
+	// Provider 仅 providers 缓存有值。
+	Provider string        `json:"provider,omitempty"`
+	Age      time.Duration `json:"age"`
+	// TTL 剩余有效期，负数表示已过期但仍在宽限期内。
+	TTL  time.Duration `json:"ttl"`
+	Size int64         `json:"size"`
+}
+
+// WarmResult 单个查询的预热结果。
+type WarmResult struct {
+	Query   string `json:"query"`
+	Results int    `json:"results"`
+	Error   string `json:"error,omitempty"`
+}
+
+// warmConcurrency 预热时同时执行的查询数。
+const warmConcurrency = 4
+
+// CacheEntries 返回指定缓存的条目，name 为空时返回全部缓存。
+func (a *Aggregator) CacheEntries(name string) []CacheEntry {
+	now := time.Now()
+	var out []CacheEntry
+	if name == "" || name == CacheResponses {
+		out = appendEntries(out, CacheResponses, a.cache.Entries(), now)
+	}
+	if name == "" || name == CacheProviders {
+		out = appendEntries(out, CacheProviders, a.providerCache.Entries(), now)
+	}
+	if name == "" || name == CacheSummaries {
+		out = appendEntries(out, CacheSummaries, a.summaries.Entries(), now)
+	}
+	return out
+}
+
+// InvalidateKey 按完整缓存键删除，三类缓存都会尝试，返回删除数量。
+func (a *Aggregator) InvalidateKey(key string) int {
+	match := func(k string) bool { return k == key }
+	return a.cache.DeleteFunc(match) + a.providerCache.DeleteFunc(match) + a.summaries.DeleteFunc(match)
+}
+
+// InvalidateQuery 删除查询词以 prefix 开头（不区分大小写）的响应、平台结果与摘要缓存。
+func (a *Aggregator) InvalidateQuery(prefix string) int {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	return a.cache.DeleteFunc(func(k string) bool {
+		query, _ := parseResponseKey(k)
+		return strings.HasPrefix(query, prefix)
+	}) + a.providerCache.DeleteFunc(func(k string) bool {
+		_, query := parseProviderKey(k)
+		return strings.HasPrefix(query, prefix)
+	}) + a.summaries.DeleteFunc(func(k string) bool {
+		return strings.HasPrefix(parseSummaryKey(k), prefix)
+	})
+}
+
+// InvalidateProvider 删除该平台的结果缓存以及包含该平台的响应缓存。
+// 摘要以结果集指纹为键，平台数据变化后自然不会命中，因此保留。
+func (a *Aggregator) InvalidateProvider(name string) int {
+	return a.cache.DeleteFunc(func(k string) bool {
+		_, providers := parseResponseKey(k)
+		return slices.Contains(providers, name)
+	}) + a.providerCache.DeleteFunc(func(k string) bool {
+		provider, _ := parseProviderKey(k)
+		return provider == name
+	})
+}
+
+// PurgeCache 清空响应、平台结果与摘要缓存。
+func (a *Aggregator) PurgeCache() {
+	a.cache.Purge()
+	a.providerCache.Purge()
+	a.summaries.Purge()
+}
+
+// Warm 以有限并发依次执行查询，把结果与摘要写入缓存，返回顺序与 queries 一致。
+func (a *Aggregator) Warm(ctx context.Context, queries []string, opts Options) []WarmResult {
+	results := make([]WarmResult, len(queries))
+	sem := make(chan struct{}, warmConcurrency)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, query string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Query = query
+			resp, err := a.Search(ctx, query, opts)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Results = len(resp.Results)
+		}(i, query)
+	}
+	wg.Wait()
+	return results
+}
+
+func appendEntries(out []CacheEntry, name string, entries []cache.EntryInfo[string], now time.Time) []CacheEntry {
+	for _, e := range entries {
+		entry := CacheEntry{
+			Cache: name,
+			Key:   e.Key,
+			Age:   now.Sub(e.Created),
+			TTL:   e.Expiration.Sub(now),
+			Size:  e.Size,
+		}
+		switch name {
+		case CacheResponses:
+			entry.Query, _ = parseResponseKey(e.Key)
+		case CacheProviders:
+			entry.Provider, entry.Query = parseProviderKey(e.Key)
+		case CacheSummaries:
+			entry.Query = parseSummaryKey(e.Key)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// parseResponseKey 解析 buildCacheKey 生成的键。查询词本身可能包含“|”，
+// 因此从末尾取固定字段。
+func parseResponseKey(key string) (string, []string) {
+	parts := strings.Split(key, "|")
+	if len(parts) < 5 {
+		return key, nil
+	}
+	n := len(parts)
+	return strings.Join(parts[:n-4], "|"), strings.Split(parts[n-4], ",")
+}
+
+// parseProviderKey 解析 buildProviderCacheKey 生成的键。
+func parseProviderKey(key string) (string, string) {
+	parts := strings.Split(key, "|")
+	if len(parts) < 5 {
+		return "", key
+	}
+	return parts[0], strings.Join(parts[1:len(parts)-3], "|")
+}
+
+// parseSummaryKey 从 buildSummaryKey 生成的键中取出查询词。
+func parseSummaryKey(key string) string {
+	parts := strings.Split(key, "|")
+	if len(parts) < 5 {
+		return key
+	}
+	return strings.Join(parts[1:len(parts)-3], "|")
+}
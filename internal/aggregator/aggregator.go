@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,13 +23,29 @@ type Options struct {
 	Providers    []string
 	Limit        int
 	ForceRefresh bool
+	// Locale 摘要语言，如 zh-CN、zh-TW、en，为空时使用摘要器默认语言。
+	Locale string
+	// StartTime、EndTime 限定结果的发布时间窗口，零值表示不限。
+	StartTime time.Time
+	EndTime   time.Time
+	// Mode 取值见 ModeFull、ModeResultsOnly、ModeSummaryOnly，为空时等同 ModeFull。
+	Mode string
+	// Sort 结果排序方式，取值见 ParseSort，为空时按发布时间排序。
+	// 按情绪排序依赖摘要器的标注，ModeResultsOnly 下保持时间顺序。
+	Sort string
 }
 
 // Metadata 描述一次聚合的额外信息。
 type Metadata struct {
+	RequestID        string           `json:"request_id,omitempty"`
 	Cached           bool             `json:"cached"`
+	Stale            bool             `json:"stale"`
+	Coalesced        bool             `json:"coalesced"`
+	Mode             string           `json:"mode"`
 	GeneratedAt      time.Time        `json:"generated_at"`
 	Took             time.Duration    `json:"took"`
+	SummaryCached    bool             `json:"summary_cached"`
+	SummaryTook      time.Duration    `json:"summary_took"`
 	ProviderStatuses []ProviderStatus `json:"provider_statuses"`
 }
 
@@ -43,7 +61,7 @@ type ProviderStatus struct {
 type Response struct {
 	Query    string         `json:"query"`
 	Results  []model.Result `json:"results"`
-	Summary  model.Summary  `json:"summary"`
+	Summary  *model.Summary `json:"summary,omitempty"`
 	Metadata Metadata       `json:"metadata"`
 }
 
@@ -52,39 +70,97 @@ type Config struct {
 	CacheTTL       time.Duration
 	RequestTimeout time.Duration
 	HistorySize    int
+	// SummaryCacheTTL 摘要缓存有效期，为空时与 CacheTTL 相同。
+	SummaryCacheTTL time.Duration
+	// CacheMaxEntries、CacheMaxBytes 分别限制响应缓存与摘要缓存的容量，0 表示不限。
+	CacheMaxEntries int
+	CacheMaxBytes   int64
+	// ProviderCacheTTL 按 provider 名称设置单个平台结果的缓存有效期，未设置的使用 CacheTTL。
+	ProviderCacheTTL map[string]time.Duration
+	// StaleTTL 响应过期后仍直接返回旧结果并在后台刷新的宽限期，0 表示不启用。
+	StaleTTL time.Duration
+	// ResponseCache、ProviderCache 自定义响应缓存与单平台结果缓存的后端，为空时使用内存缓存。
+	// 传入的缓存由聚合器接管，Close 时一并关闭。
+	ResponseCache cache.Store[string, Response]
+	ProviderCache cache.Store[string, []model.Result]
+	// History 自定义历史记录仓库（如持久化到文件），为空时按 HistorySize 创建内存仓库。
+	// 传入的仓库同样由聚合器接管。
+	History *history.Store
+	// OnSearch 每次搜索完成后同步调用（包括命中缓存的搜索），resp 含完整结果，
+	// 用于告警等旁路处理，不能修改 resp，耗时操作需自行异步执行。
+	OnSearch func(ctx context.Context, query string, resp Response)
 }
 
 // Aggregator 负责并发调度多个 provider 并汇总结果。
 type Aggregator struct {
-	providers  map[string]provider.Provider
-	cache      *cache.Cache[string, Response]
-	summarizer summary.Summarizer
-	history    *history.Store
-	timeout    time.Duration
-	mu         sync.RWMutex
+	providers     map[string]provider.Provider
+	cache         cache.Store[string, Response]
+	providerCache cache.Store[string, []model.Result]
+	providerTTL   map[string]time.Duration
+	summaries     *cache.Cache[string, summaryEntry]
+	summarizer    summary.Summarizer
+	history       *history.Store
+	timeout       time.Duration
+	flight        *flightGroup
+	onSearch      func(ctx context.Context, query string, resp Response)
+	mu            sync.RWMutex
 }
 
 // New 创建聚合器。
 func New(providers map[string]provider.Provider, summarizer summary.Summarizer, cfg Config) *Aggregator {
-	ttl := cfg.CacheTTL
-	if ttl <= 0 {
-		ttl = 3 * time.Minute
-	}
+	ttl := cacheTTL(cfg)
 	timeout := cfg.RequestTimeout
 	if timeout <= 0 {
 		timeout = 8 * time.Second
 	}
-	historySize := cfg.HistorySize
-	if historySize <= 0 {
-		historySize = 50
+	historyStore := cfg.History
+	if historyStore == nil {
+		historyStore = history.NewStore(cfg.HistorySize)
+	}
+	summaryTTL := cfg.SummaryCacheTTL
+	if summaryTTL <= 0 {
+		summaryTTL = ttl
+	}
+
+	responses := cfg.ResponseCache
+	if responses == nil {
+		responses = newResponseMemoryCache(cfg, true)
+	}
+	providerResults := cfg.ProviderCache
+	if providerResults == nil {
+		providerResults = newProviderMemoryCache(cfg)
 	}
 
 	return &Aggregator{
-		providers:  providers,
-		cache:      cache.New[string, Response](ttl),
+		providers:     providers,
+		cache:         responses,
+		providerCache: providerResults,
+		providerTTL:   cfg.ProviderCacheTTL,
+		summaries: cache.NewWithOptions(summaryTTL, cache.Options[string, summaryEntry]{
+			MaxEntries:      cfg.CacheMaxEntries,
+			MaxBytes:        cfg.CacheMaxBytes,
+			Sizer:           summaryEntrySize,
+			JanitorInterval: janitorInterval(summaryTTL),
+		}),
 		summarizer: summarizer,
-		history:    history.NewStore(historySize),
+		history:    historyStore,
 		timeout:    timeout,
+		flight:     newFlightGroup(),
+		onSearch:   cfg.OnSearch,
+	}
+}
+
+// Close 停止缓存的后台清理协程并关闭持久化缓存与历史记录。
+func (a *Aggregator) Close() error {
+	return errors.Join(a.cache.Close(), a.providerCache.Close(), a.summaries.Close(), a.history.Close())
+}
+
+// CacheStats 返回响应缓存与摘要缓存的统计信息。
+func (a *Aggregator) CacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		CacheResponses: a.cache.Stats(),
+		CacheProviders: a.providerCache.Stats(),
+		CacheSummaries: a.summaries.Stats(),
 	}
 }
 
@@ -111,27 +187,161 @@ func (a *Aggregator) RegisterProvider(p provider.Provider) {
 }
 
 // Search 执行聚合查询。
+//
+// 结果与摘要分别缓存：结果按查询条件缓存，摘要按结果集指纹缓存，
+// 因此 results_only 请求不会触发摘要器，摘要也不会因结果重新排序而重复计算。
 func (a *Aggregator) Search(ctx context.Context, query string, opts Options) (Response, error) {
 	query = strings.TrimSpace(query)
-	if query == "" {
-		return Response{}, errors.New("query is required")
+	providers, err := a.prepare(query, opts)
+	if err != nil {
+		return Response{}, err
 	}
-
 	start := time.Now()
 
+	cacheKey := a.buildCacheKey(query, providers, opts)
+	resp, stale, cached := Response{}, false, false
+	if !opts.ForceRefresh {
+		resp, stale, cached = a.cache.GetStale(cacheKey)
+	}
+	if stale {
+		a.refreshInBackground(cacheKey, query, providers, opts)
+	}
+	coalesced := false
+	if !cached {
+		resp, coalesced, err = a.flight.do(ctx, cacheKey, func() Response {
+			return a.fetchAndStore(context.WithoutCancel(ctx), cacheKey, query, providers, opts)
+		})
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	resp.Metadata.Cached = cached
+	resp.Metadata.Stale = stale
+	resp.Metadata.Coalesced = coalesced
+	return a.finish(ctx, query, providers, opts, start, resp), nil
+}
+
+// finish 在取得结果后生成摘要、排序并写入历史记录，opts 已经过校验。
+func (a *Aggregator) finish(ctx context.Context, query string, providers []string, opts Options, start time.Time, resp Response) Response {
+	mode, _ := ParseMode(opts.Mode)
+	sortBy, _ := ParseSort(opts.Sort)
+
+	// 缓存中的响应被多个请求共享，标注与追加状态前先复制。
+	results := make([]model.Result, len(resp.Results))
+	copy(results, resp.Results)
+	resp.Results = results
+	resp.Metadata.ProviderStatuses = append([]ProviderStatus(nil), resp.Metadata.ProviderStatuses...)
+	resp.Metadata.Mode = mode
+	resp.Metadata.RequestID = RequestInfoFrom(ctx).ID
+
+	if mode != ModeResultsOnly {
+		summaryResult, summaryCached, summaryTook, err := a.summarize(ctx, query, resp.Results, opts)
+		if err != nil {
+			resp.Metadata.ProviderStatuses = append(resp.Metadata.ProviderStatuses, ProviderStatus{Name: "summary", Error: err.Error()})
+		}
+		resp.Summary = &summaryResult
+		resp.Metadata.SummaryCached = summaryCached
+		resp.Metadata.SummaryTook = summaryTook
+	}
+	sortResults(resp.Results, sortBy)
+	resp.Metadata.Took = time.Since(start)
+	a.record(ctx, query, providers, opts, resp)
+	if a.onSearch != nil {
+		a.onSearch(ctx, query, resp)
+	}
+	if mode == ModeSummaryOnly {
+		resp.Results = nil
+	}
+	return resp
+}
+
+// prepare 校验查询参数并返回参与搜索的平台。
+func (a *Aggregator) prepare(query string, opts Options) ([]string, error) {
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	if _, ok := ParseMode(opts.Mode); !ok {
+		return nil, fmt.Errorf("unknown mode %q", opts.Mode)
+	}
+	if _, ok := ParseSort(opts.Sort); !ok {
+		return nil, fmt.Errorf("unknown sort %q", opts.Sort)
+	}
 	providers := a.selectProviders(opts.Providers)
 	if len(providers) == 0 {
-		return Response{}, errors.New("no providers configured")
+		return nil, errors.New("no providers configured")
 	}
+	return providers, nil
+}
 
-	cacheKey := a.buildCacheKey(query, providers, opts.Limit)
-	if !opts.ForceRefresh {
-		if resp, ok := a.cache.Get(cacheKey); ok {
-			resp.Metadata.Cached = true
-			resp.Metadata.Took = time.Since(start)
-			return resp, nil
+// record 把一次搜索（包括命中缓存的搜索）连同请求上下文写入历史记录。
+func (a *Aggregator) record(ctx context.Context, query string, providers []string, opts Options, resp Response) {
+	info := RequestInfoFrom(ctx)
+	statuses := make([]history.ProviderStatus, 0, len(resp.Metadata.ProviderStatuses))
+	for _, st := range resp.Metadata.ProviderStatuses {
+		statuses = append(statuses, history.ProviderStatus{Name: st.Name, Count: st.Count, Error: st.Error, Cached: st.Cached})
+	}
+	fingerprints := make([]string, len(resp.Results))
+	snapshot := make([]history.ResultSnapshot, len(resp.Results))
+	for i, r := range resp.Results {
+		fingerprints[i] = ResultFingerprint(r)
+		snapshot[i] = history.ResultSnapshot{
+			URL:         r.URL,
+			Title:       r.Title,
+			Source:      r.Source,
+			PublishedAt: r.PublishedAt,
+			Metrics:     r.Metrics,
 		}
 	}
+	err := a.history.Add(history.Record{
+		RequestID: info.ID,
+		Caller:    info.Caller,
+		Query:     query,
+		Providers: providers,
+		Options: history.SearchOptions{
+			Providers:    opts.Providers,
+			Limit:        opts.Limit,
+			ForceRefresh: opts.ForceRefresh,
+			Locale:       opts.Locale,
+			StartTime:    opts.StartTime,
+			EndTime:      opts.EndTime,
+			Mode:         resp.Metadata.Mode,
+			Sort:         opts.Sort,
+		},
+		Results:      len(resp.Results),
+		Took:         resp.Metadata.Took,
+		Time:         time.Now(),
+		Cached:       resp.Metadata.Cached,
+		Stale:        resp.Metadata.Stale,
+		Coalesced:    resp.Metadata.Coalesced,
+		Statuses:     statuses,
+		Fingerprints: fingerprints,
+		Snapshot:     snapshot,
+	})
+	// 记录本身已保存在内存中，持久化失败不影响本次搜索，但需要留下痕迹便于排查磁盘或权限问题。
+	if err != nil {
+		log.Printf("history: persist record for %q (request %s): %v", query, info.ID, err)
+	}
+}
+
+// refreshInBackground 在后台重新拉取过期的响应，与进行中的同 key 请求合并。
+func (a *Aggregator) refreshInBackground(cacheKey, query string, providers []string, opts Options) {
+	a.flight.start(cacheKey, func() Response {
+		return a.fetchAndStore(context.Background(), cacheKey, query, providers, opts)
+	})
+}
+
+// fetchAndStore 执行 fan-out 并写入缓存，由 flightGroup 保证同一 key 只执行一次。
+func (a *Aggregator) fetchAndStore(ctx context.Context, cacheKey, query string, providers []string, opts Options) Response {
+	resp := a.fetch(ctx, query, providers, opts, nil)
+	a.cache.Set(cacheKey, resp)
+	return resp
+}
+
+// fetch 并发调用各 provider，返回按发布时间排序、尚未生成摘要的响应。
+// progress 不为空时在每个平台返回后按到达顺序依次调用。
+func (a *Aggregator) fetch(ctx context.Context, query string, providers []string, opts Options, progress func(ProviderStatus, []model.Result)) Response {
+	start := time.Now()
 
 	limit := opts.Limit
 	if limit <= 0 {
@@ -142,6 +352,7 @@ func (a *Aggregator) Search(ctx context.Context, query string, opts Options) (Re
 		provider string
 		results  []model.Result
 		err      error
+		cached   bool
 	}
 
 	resultCh := make(chan resultEnvelope, len(providers))
@@ -156,12 +367,26 @@ func (a *Aggregator) Search(ctx context.Context, query string, opts Options) (Re
 			resultCh <- resultEnvelope{provider: name, err: fmt.Errorf("provider %s not found", name)}
 			continue
 		}
+		providerKey := a.buildProviderCacheKey(name, query, limit, opts)
+		if !opts.ForceRefresh {
+			if res, ok := a.providerCache.Get(providerKey); ok {
+				resultCh <- resultEnvelope{provider: name, results: res, cached: true}
+				continue
+			}
+		}
 		wg.Add(1)
-		go func(p provider.Provider) {
+		go func(name string, p provider.Provider) {
 			defer wg.Done()
-			res, err := p.Search(ctx, query, provider.SearchOptions{Limit: limit})
-			resultCh <- resultEnvelope{provider: p.Name(), results: res, err: err}
-		}(prov)
+			res, err := p.Search(ctx, query, provider.SearchOptions{
+				Limit:     limit,
+				StartTime: opts.StartTime,
+				EndTime:   opts.EndTime,
+			})
+			if err == nil {
+				a.providerCache.SetWithTTL(providerKey, res, a.providerTTL[name])
+			}
+			resultCh <- resultEnvelope{provider: name, results: res, err: err}
+		}(name, prov)
 	}
 
 	go func() {
@@ -171,48 +396,33 @@ func (a *Aggregator) Search(ctx context.Context, query string, opts Options) (Re
 
 	aggregated := make([]model.Result, 0)
 	statuses := make([]ProviderStatus, 0, len(providers))
-	providerNames := make([]string, 0, len(providers))
 
 	for envelope := range resultCh {
-		providerNames = append(providerNames, envelope.provider)
+		status := ProviderStatus{Name: envelope.provider, Count: len(envelope.results), Cached: envelope.cached}
 		if envelope.err != nil {
-			statuses = append(statuses, ProviderStatus{Name: envelope.provider, Error: envelope.err.Error()})
-			continue
+			status = ProviderStatus{Name: envelope.provider, Error: envelope.err.Error()}
+			envelope.results = nil
 		}
 		aggregated = append(aggregated, envelope.results...)
-		statuses = append(statuses, ProviderStatus{Name: envelope.provider, Count: len(envelope.results)})
+		statuses = append(statuses, status)
+		if progress != nil {
+			progress(status, envelope.results)
+		}
 	}
 
 	sort.Slice(aggregated, func(i, j int) bool {
 		return aggregated[i].PublishedAt.After(aggregated[j].PublishedAt)
 	})
 
-	summaryResult, err := a.summarizer.Summarize(ctx, query, aggregated)
-	if err != nil {
-		statuses = append(statuses, ProviderStatus{Name: "summary", Error: err.Error()})
-	}
-
-	resp := Response{
+	return Response{
 		Query:   query,
 		Results: aggregated,
-		Summary: summaryResult,
 		Metadata: Metadata{
 			GeneratedAt:      time.Now(),
 			Took:             time.Since(start),
 			ProviderStatuses: statuses,
 		},
 	}
-
-	a.cache.Set(cacheKey, resp)
-	a.history.Add(history.Record{
-		Query:     query,
-		Providers: providerNames,
-		Results:   len(aggregated),
-		Took:      resp.Metadata.Took,
-		Time:      resp.Metadata.GeneratedAt,
-	})
-
-	return resp, nil
 }
 
 // History 返回最近的查询记录。
@@ -220,10 +430,49 @@ func (a *Aggregator) History(limit int) []history.Record {
 	return a.history.List(limit)
 }
 
-func (a *Aggregator) buildCacheKey(query string, providers []string, limit int) string {
+// HistoryRange 按时间先后返回 [since, until) 内的查询记录，供统计分析使用。
+func (a *Aggregator) HistoryRange(since, until time.Time) []history.Record {
+	return a.history.Range(since, until)
+}
+
+// QueryHistory 按条件筛选并分页返回查询记录。
+func (a *Aggregator) QueryHistory(f history.Filter) (history.Page, error) {
+	return a.history.Query(f)
+}
+
+func (a *Aggregator) buildCacheKey(query string, providers []string, opts Options) string {
 	cloned := append([]string(nil), providers...)
 	sort.Strings(cloned)
-	return fmt.Sprintf("%s|%s|%d", strings.ToLower(query), strings.Join(cloned, ","), limit)
+	return fmt.Sprintf("%s|%s|%d|%s|%s", strings.ToLower(query), strings.Join(cloned, ","), opts.Limit,
+		formatWindow(opts.StartTime), formatWindow(opts.EndTime))
+}
+
+func (a *Aggregator) buildProviderCacheKey(name, query string, limit int, opts Options) string {
+	return fmt.Sprintf("%s|%s|%d|%s|%s", name, strings.ToLower(query), limit,
+		formatWindow(opts.StartTime), formatWindow(opts.EndTime))
+}
+
+// cacheTTL 返回响应缓存有效期，未配置时为三分钟。
+func cacheTTL(cfg Config) time.Duration {
+	if cfg.CacheTTL <= 0 {
+		return 3 * time.Minute
+	}
+	return cfg.CacheTTL
+}
+
+// janitorInterval 过期清理间隔取 TTL，但不超过一分钟。
+func janitorInterval(ttl time.Duration) time.Duration {
+	if ttl > time.Minute {
+		return time.Minute
+	}
+	return ttl
+}
+
+func formatWindow(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
 }
 
 func (a *Aggregator) selectProviders(requested []string) []string {
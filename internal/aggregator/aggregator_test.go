@@ -1,10 +1,19 @@
 package aggregator
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"agentgo/internal/history"
+	"agentgo/internal/model"
 	"agentgo/internal/provider"
 	"agentgo/internal/provider/mock"
 	simplesummary "agentgo/internal/summary/simple"
@@ -52,3 +61,631 @@ func TestAggregatorSearchAndCache(t *testing.T) {
 		t.Fatalf("expected provider not called again, got %d", mockProvider.CallCount())
 	}
 }
+
+type countingSummarizer struct {
+	calls int
+}
+
+func (c *countingSummarizer) Summarize(_ context.Context, query string, results []model.Result) (model.Summary, error) {
+	c.calls++
+	for i := range results {
+		results[i].Sentiment = &model.SentimentScore{Label: "neutral"}
+	}
+	return model.Summary{Query: query, Keywords: []string{fmt.Sprint(len(results))}}, nil
+}
+
+func TestAggregatorSummaryModes(t *testing.T) {
+	mockProvider := mock.New()
+	summ := &countingSummarizer{}
+	agg := New(map[string]provider.Provider{mockProvider.Name(): mockProvider}, summ, Config{CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	resp, err := agg.Search(ctx, "运营", Options{Mode: ModeResultsOnly})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Summary != nil || len(resp.Results) == 0 || summ.calls != 0 {
+		t.Fatalf("results_only should skip summarizer, got summary=%v calls=%d", resp.Summary, summ.calls)
+	}
+
+	resp, err = agg.Search(ctx, "运营", Options{Mode: ModeSummaryOnly})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Summary == nil || resp.Results != nil || summ.calls != 1 {
+		t.Fatalf("summary_only should return only summary, got results=%d calls=%d", len(resp.Results), summ.calls)
+	}
+	if !resp.Metadata.Cached || resp.Metadata.SummaryCached {
+		t.Fatalf("expected cached results and fresh summary, got %+v", resp.Metadata)
+	}
+
+	resp, err = agg.Search(ctx, "运营", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summ.calls != 1 || !resp.Metadata.SummaryCached {
+		t.Fatalf("expected summary cache hit, got calls=%d meta=%+v", summ.calls, resp.Metadata)
+	}
+	for _, r := range resp.Results {
+		if r.Sentiment == nil {
+			t.Fatalf("expected cached annotations to be restored")
+		}
+	}
+	if mockProvider.CallCount() != 1 {
+		t.Fatalf("expected a single provider fan-out, got %d", mockProvider.CallCount())
+	}
+
+	if _, err := agg.Search(ctx, "运营", Options{Mode: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func TestAggregatorStaleWhileRevalidate(t *testing.T) {
+	mockProvider := mock.New()
+	agg := New(map[string]provider.Provider{mockProvider.Name(): mockProvider}, simplesummary.New(), Config{
+		CacheTTL: 30 * time.Millisecond,
+		StaleTTL: time.Minute,
+	})
+	defer agg.Close()
+	ctx := context.Background()
+
+	if _, err := agg.Search(ctx, "运营", Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	resp, err := agg.Search(ctx, "运营", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Metadata.Cached || !resp.Metadata.Stale {
+		t.Fatalf("expected stale cached response, got %+v", resp.Metadata)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for mockProvider.CallCount() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background refresh")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for {
+		resp, err = agg.Search(ctx, "运营", Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Metadata.Stale {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected refreshed response to replace stale entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if mockProvider.CallCount() != 2 {
+		t.Fatalf("expected exactly one background refresh, got %d calls", mockProvider.CallCount())
+	}
+}
+
+type blockingProvider struct {
+	release chan struct{}
+	mu      sync.Mutex
+	calls   int
+}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+
+func (p *blockingProvider) Search(ctx context.Context, query string, _ provider.SearchOptions) ([]model.Result, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []model.Result{{Title: query, URL: "https://example.com/" + query, Source: "blocking", PublishedAt: time.Now()}}, nil
+}
+
+func TestAggregatorCoalescesConcurrentSearches(t *testing.T) {
+	prov := &blockingProvider{release: make(chan struct{})}
+	agg := New(map[string]provider.Provider{prov.Name(): prov}, simplesummary.New(), Config{CacheTTL: time.Minute})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancelErr := make(chan error, 1)
+	go func() {
+		_, err := agg.Search(cancelled, "品牌", Options{})
+		cancelErr <- err
+	}()
+
+	const callers = 8
+	var wg sync.WaitGroup
+	responses := make([]Response, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = agg.Search(context.Background(), "品牌", Options{})
+		}(i)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		prov.mu.Lock()
+		calls := prov.calls
+		prov.mu.Unlock()
+		if calls > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("provider was never called")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-cancelErr; err != context.Canceled {
+		t.Fatalf("expected cancelled caller to return context.Canceled, got %v", err)
+	}
+
+	close(prov.release)
+	wg.Wait()
+
+	coalesced := 0
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("caller %d failed: %v", i, errs[i])
+		}
+		if len(responses[i].Results) != 1 {
+			t.Fatalf("caller %d expected 1 result, got %d", i, len(responses[i].Results))
+		}
+		if responses[i].Metadata.Coalesced {
+			coalesced++
+		}
+	}
+	if prov.calls != 1 {
+		t.Fatalf("expected a single fan-out, got %d", prov.calls)
+	}
+	if coalesced == 0 {
+		t.Fatalf("expected coalesced responses")
+	}
+}
+
+type staticProvider struct {
+	name  string
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *staticProvider) Name() string { return p.name }
+
+func (p *staticProvider) Search(_ context.Context, query string, _ provider.SearchOptions) ([]model.Result, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return []model.Result{{Title: query + "@" + p.name, URL: "https://" + p.name + ".example.com", Source: p.name, PublishedAt: time.Now()}}, nil
+}
+
+func TestAggregatorPerProviderCache(t *testing.T) {
+	zhihu := &staticProvider{name: "zhihu"}
+	wechat := &staticProvider{name: "wechat"}
+	agg := New(map[string]provider.Provider{"zhihu": zhihu, "wechat": wechat}, simplesummary.New(), Config{
+		CacheTTL:         time.Minute,
+		ProviderCacheTTL: map[string]time.Duration{"wechat": 20 * time.Millisecond},
+	})
+	defer agg.Close()
+	ctx := context.Background()
+
+	if _, err := agg.Search(ctx, "品牌", Options{Providers: []string{"zhihu"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := agg.Search(ctx, "品牌", Options{Providers: []string{"zhihu", "wechat"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata.Cached {
+		t.Fatalf("different provider set should miss the response cache")
+	}
+	statuses := map[string]ProviderStatus{}
+	for _, st := range resp.Metadata.ProviderStatuses {
+		statuses[st.Name] = st
+	}
+	if !statuses["zhihu"].Cached || statuses["wechat"].Cached {
+		t.Fatalf("expected zhihu from provider cache and wechat fresh, got %+v", statuses)
+	}
+	if zhihu.calls != 1 || wechat.calls != 1 {
+		t.Fatalf("unexpected call counts zhihu=%d wechat=%d", zhihu.calls, wechat.calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := agg.Search(ctx, "品牌", Options{Providers: []string{"wechat"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wechat.calls != 2 {
+		t.Fatalf("expected wechat entry expired by its own ttl, got %d calls", wechat.calls)
+	}
+}
+
+func TestAggregatorDiskCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	zhihu := &staticProvider{name: "zhihu"}
+	open := func() *Aggregator {
+		cfg := Config{CacheTTL: time.Minute}
+		var err error
+		cfg.ResponseCache, cfg.ProviderCache, err = OpenCaches(CacheConfig{Backend: CacheTiered, Dir: dir}, cfg)
+		if err != nil {
+			t.Fatalf("open caches: %v", err)
+		}
+		return New(map[string]provider.Provider{"zhihu": zhihu}, simplesummary.New(), cfg)
+	}
+
+	agg := open()
+	first, err := agg.Search(context.Background(), "品牌", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := agg.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	agg = open()
+	defer agg.Close()
+	resp, err := agg.Search(context.Background(), "品牌", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Metadata.Cached || zhihu.calls != 1 {
+		t.Fatalf("expected response served from disk after restart, cached=%v calls=%d", resp.Metadata.Cached, zhihu.calls)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Title != first.Results[0].Title {
+		t.Fatalf("expected persisted results, got %+v", resp.Results)
+	}
+}
+
+func TestAggregatorCacheAdmin(t *testing.T) {
+	zhihu := &staticProvider{name: "zhihu"}
+	wechat := &staticProvider{name: "wechat"}
+	agg := New(map[string]provider.Provider{"zhihu": zhihu, "wechat": wechat}, simplesummary.New(), Config{CacheTTL: time.Minute})
+	defer agg.Close()
+	ctx := context.Background()
+
+	warmed := agg.Warm(ctx, []string{"品牌A", "品牌B", "其他|带竖线"}, Options{})
+	for _, w := range warmed {
+		if w.Error != "" || w.Results != 2 {
+			t.Fatalf("unexpected warm result %+v", w)
+		}
+	}
+	entries := agg.CacheEntries(CacheResponses)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 response entries, got %+v", entries)
+	}
+	for _, e := range entries {
+		if e.TTL <= 0 || e.TTL > time.Minute || e.Size <= 0 {
+			t.Fatalf("unexpected entry metadata %+v", e)
+		}
+	}
+	providerEntries := agg.CacheEntries(CacheProviders)
+	if len(providerEntries) != 6 || providerEntries[0].Provider == "" {
+		t.Fatalf("expected 6 provider entries with provider names, got %+v", providerEntries)
+	}
+
+	if removed := agg.InvalidateQuery("品牌"); removed != 2+4+2 {
+		t.Fatalf("expected responses, provider results and summaries for 品牌* removed, got %d", removed)
+	}
+	if _, err := agg.Search(ctx, "其他|带竖线", Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zhihu.calls != 3 {
+		t.Fatalf("expected untouched query served from cache, got %d calls", zhihu.calls)
+	}
+
+	if removed := agg.InvalidateProvider("wechat"); removed != 2 {
+		t.Fatalf("expected wechat results and the response containing it removed, got %d", removed)
+	}
+	resp, err := agg.Search(ctx, "其他|带竖线", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata.Cached || zhihu.calls != 3 || wechat.calls != 4 {
+		t.Fatalf("expected only wechat refetched, cached=%v zhihu=%d wechat=%d", resp.Metadata.Cached, zhihu.calls, wechat.calls)
+	}
+
+	key := agg.CacheEntries(CacheResponses)[0].Key
+	if removed := agg.InvalidateKey(key); removed != 1 {
+		t.Fatalf("expected exact key removed, got %d", removed)
+	}
+	agg.PurgeCache()
+	if entries := agg.CacheEntries(""); len(entries) != 0 {
+		t.Fatalf("expected purge to empty all caches, got %+v", entries)
+	}
+}
+
+func TestAggregatorRecordsRequestContext(t *testing.T) {
+	zhihu := &staticProvider{name: "zhihu"}
+	agg := New(map[string]provider.Provider{"zhihu": zhihu}, simplesummary.New(), Config{CacheTTL: time.Minute})
+	defer agg.Close()
+	ctx := WithRequestInfo(context.Background(), RequestInfo{ID: "req-1", Caller: "user:alice"})
+	opts := Options{Providers: []string{"zhihu"}, Limit: 5, Locale: "en", Mode: ModeSummaryOnly}
+
+	if _, err := agg.Search(ctx, "品牌", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := agg.Search(WithRequestInfo(context.Background(), RequestInfo{ID: "req-2"}), "品牌", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata.RequestID != "req-2" {
+		t.Fatalf("expected request id in metadata, got %q", resp.Metadata.RequestID)
+	}
+
+	records := agg.History(10)
+	if len(records) != 2 {
+		t.Fatalf("expected cache hit recorded too, got %d records", len(records))
+	}
+	hit, miss := records[0], records[1]
+	if miss.RequestID != "req-1" || miss.Caller != "user:alice" || miss.Cached {
+		t.Fatalf("unexpected first record %+v", miss)
+	}
+	if hit.RequestID != "req-2" || !hit.Cached {
+		t.Fatalf("expected second record marked as cache hit, got %+v", hit)
+	}
+	if miss.Options.Limit != 5 || miss.Options.Locale != "en" || miss.Options.Mode != ModeSummaryOnly {
+		t.Fatalf("expected options recorded, got %+v", miss.Options)
+	}
+	if miss.Results != 1 || len(miss.Fingerprints) != 1 || len(miss.Statuses) != 1 || miss.Statuses[0].Name != "zhihu" {
+		t.Fatalf("expected results, fingerprints and statuses recorded even in summary_only mode, got %+v", miss)
+	}
+	if hit.Fingerprints[0] != miss.Fingerprints[0] {
+		t.Fatalf("expected same fingerprints for cached replay")
+	}
+}
+
+// failingBackend 写入总是失败的历史后端。
+type failingBackend struct{}
+
+func (failingBackend) Append(history.Record) error              { return errors.New("disk full") }
+func (failingBackend) Load(time.Time) ([]history.Record, error) { return nil, nil }
+func (failingBackend) Prune(time.Time, int) error               { return nil }
+func (failingBackend) Close() error                             { return nil }
+
+func TestAggregatorLogsHistoryBackendFailure(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	store, err := history.NewStoreWithConfig(history.Config{Backend: failingBackend{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg := New(map[string]provider.Provider{"zhihu": &staticProvider{name: "zhihu"}}, simplesummary.New(), Config{History: store})
+	defer agg.Close()
+
+	if _, err := agg.Search(WithRequestInfo(context.Background(), RequestInfo{ID: "req-9"}), "品牌", Options{}); err != nil {
+		t.Fatalf("history failure must not fail the search: %v", err)
+	}
+	if !strings.Contains(buf.String(), "disk full") || !strings.Contains(buf.String(), "req-9") {
+		t.Fatalf("expected backend failure logged, got %q", buf.String())
+	}
+	if len(agg.History(1)) != 1 {
+		t.Fatalf("expected record kept in memory")
+	}
+}
+
+// changingProvider 每次调用返回不同的结果，用于验证重放差异。
+type changingProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *changingProvider) Name() string { return "zhihu" }
+
+func (p *changingProvider) Search(_ context.Context, _ string, _ provider.SearchOptions) ([]model.Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	published := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if p.calls == 1 {
+		return []model.Result{
+			{Title: "A", URL: "https://a", Source: "zhihu", PublishedAt: published, Metrics: map[string]int64{"likes": 10, "reads": 100}},
+			{Title: "B", URL: "https://b", Source: "zhihu", PublishedAt: published, Metrics: map[string]int64{"likes": 5}},
+			{Title: "C", URL: "https://c", Source: "zhihu", PublishedAt: published},
+		}, nil
+	}
+	return []model.Result{
+		{Title: "A", URL: "https://a", Source: "zhihu", PublishedAt: published, Metrics: map[string]int64{"likes": 30, "reads": 100}},
+		{Title: "C", URL: "https://c", Source: "zhihu", PublishedAt: published},
+		{Title: "D", URL: "https://d", Source: "zhihu", PublishedAt: published},
+	}, nil
+}
+
+func TestAggregatorReplayDiff(t *testing.T) {
+	p := &changingProvider{}
+	agg := New(map[string]provider.Provider{"zhihu": p}, simplesummary.New(), Config{CacheTTL: time.Minute})
+	defer agg.Close()
+	ctx := context.Background()
+
+	if _, err := agg.Search(ctx, "品牌", Options{Limit: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	record := agg.History(1)[0]
+	if len(record.Snapshot) != 3 {
+		t.Fatalf("expected result snapshot stored with history, got %+v", record.Snapshot)
+	}
+
+	replay, err := agg.Replay(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	if p.calls != 2 || replay.Response.Metadata.Cached {
+		t.Fatalf("expected replay to bypass cache, calls=%d", p.calls)
+	}
+	diff := replay.Diff
+	if len(diff.Added) != 1 || diff.Added[0].URL != "https://d" {
+		t.Fatalf("expected D added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].URL != "https://b" {
+		t.Fatalf("expected B removed, got %+v", diff.Removed)
+	}
+	if diff.Unchanged != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("expected C unchanged and A changed, got %+v", diff)
+	}
+	likes := diff.Changed[0].Metrics["likes"]
+	if likes.Before != 10 || likes.After != 30 || likes.Delta != 20 || likes.Growth != 2 {
+		t.Fatalf("unexpected likes delta %+v", likes)
+	}
+	if _, ok := diff.Changed[0].Metrics["reads"]; ok {
+		t.Fatalf("expected unchanged metrics omitted")
+	}
+
+	if _, err := agg.Replay(ctx, 999); err != ErrRecordNotFound {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestAggregatorReplayWithoutSnapshot(t *testing.T) {
+	store, _ := history.NewStoreWithConfig(history.Config{MaxSnapshots: 1})
+	agg := New(map[string]provider.Provider{"zhihu": &changingProvider{}}, simplesummary.New(), Config{History: store})
+	defer agg.Close()
+	ctx := context.Background()
+	agg.Search(ctx, "品牌", Options{})
+	agg.Search(ctx, "品牌", Options{})
+
+	old := agg.History(2)[1]
+	if _, err := agg.Replay(ctx, old.ID); err != ErrSnapshotUnavailable {
+		t.Fatalf("expected ErrSnapshotUnavailable for an evicted snapshot, got %v", err)
+	}
+}
+
+// likesProvider 越新的结果点赞越少，用于区分时间排序与指标排序。
+type likesProvider struct{}
+
+func (likesProvider) Name() string { return "zhihu" }
+
+func (likesProvider) Search(_ context.Context, _ string, _ provider.SearchOptions) ([]model.Result, error) {
+	now := time.Now()
+	return []model.Result{
+		{Title: "old", URL: "https://old", Source: "zhihu", PublishedAt: now.Add(-2 * time.Hour), Metrics: map[string]int64{"likes": 50}},
+		{Title: "new", URL: "https://new", Source: "zhihu", PublishedAt: now, Metrics: map[string]int64{"likes": 1}},
+		{Title: "mid", URL: "https://mid", Source: "zhihu", PublishedAt: now.Add(-time.Hour)},
+	}, nil
+}
+
+func TestAggregatorSortOption(t *testing.T) {
+	agg := New(map[string]provider.Provider{"zhihu": likesProvider{}}, simplesummary.New(), Config{CacheTTL: time.Minute})
+	defer agg.Close()
+	ctx := context.Background()
+
+	titles := func(resp Response) string {
+		out := make([]string, len(resp.Results))
+		for i, r := range resp.Results {
+			out[i] = r.Title
+		}
+		return strings.Join(out, ",")
+	}
+
+	resp, err := agg.Search(ctx, "品牌", Options{Mode: ModeResultsOnly})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := titles(resp); got != "new,mid,old" {
+		t.Fatalf("expected time order by default, got %s", got)
+	}
+	resp, err = agg.Search(ctx, "品牌", Options{Mode: ModeResultsOnly, Sort: "metrics.likes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := titles(resp); got != "old,new,mid" || !resp.Metadata.Cached {
+		t.Fatalf("expected cached results sorted by likes, got %s cached=%v", got, resp.Metadata.Cached)
+	}
+	if sortBy, ok := ParseSort("Metrics.likes"); !ok || sortBy != "metrics.likes" {
+		t.Fatalf("expected metric prefix to be case-insensitive, got %q %v", sortBy, ok)
+	}
+	if _, err := agg.Search(ctx, "品牌", Options{Sort: "popularity"}); err == nil {
+		t.Fatalf("expected unknown sort to be rejected")
+	}
+	if record := agg.History(1)[0]; record.Options.Sort != "metrics.likes" {
+		t.Fatalf("expected sort recorded in history, got %+v", record.Options)
+	}
+}
+
+func TestAggregatorOnSearchSeesResults(t *testing.T) {
+	var calls int
+	var seen int
+	agg := New(map[string]provider.Provider{"zhihu": likesProvider{}}, simplesummary.New(), Config{
+		CacheTTL: time.Minute,
+		OnSearch: func(_ context.Context, query string, resp Response) {
+			calls++
+			seen = len(resp.Results)
+		},
+	})
+	defer agg.Close()
+
+	resp, err := agg.Search(context.Background(), "品牌", Options{Mode: ModeSummaryOnly})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 || seen != 3 || resp.Results != nil {
+		t.Fatalf("expected hook to see full results before summary_only trimming, calls=%d seen=%d", calls, seen)
+	}
+	agg.Search(context.Background(), "品牌", Options{})
+	if calls != 2 {
+		t.Fatalf("expected hook on cached search too, calls=%d", calls)
+	}
+}
+
+func TestAggregatorSearchStreamReportsProvidersAsTheyFinish(t *testing.T) {
+	slow := &blockingProvider{release: make(chan struct{})}
+	agg := New(map[string]provider.Provider{"zhihu": likesProvider{}, slow.Name(): slow}, simplesummary.New(), Config{CacheTTL: time.Minute})
+	defer agg.Close()
+
+	var updates []ProviderUpdate
+	resp, err := agg.SearchStream(context.Background(), "品牌", Options{Sort: "metrics.likes"}, func(u ProviderUpdate) {
+		updates = append(updates, u)
+		if len(updates) == 1 {
+			// 慢的平台在快的平台推送之后才返回。
+			close(slow.release)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected one update per provider, got %d", len(updates))
+	}
+	first, last := updates[0], updates[1]
+	if first.Status.Name != "zhihu" || len(first.Results) != 3 || first.Completed != 1 || first.Total != 2 {
+		t.Fatalf("unexpected first update %+v", first.Status)
+	}
+	if first.Results[0].Title != "old" || len(first.Positions) != 3 || first.Positions[2] != 2 {
+		t.Fatalf("expected first results in likes order at positions 0..2, got %+v", first.Positions)
+	}
+	if last.Status.Name != "blocking" || len(last.Results) != 1 || len(last.Positions) != 1 || last.Completed != 2 {
+		t.Fatalf("expected only the new provider's result in the last update, got %+v %+v", last.Status, last.Positions)
+	}
+	if len(resp.Results) != 4 || resp.Summary == nil || resp.Results[0].Title != "old" {
+		t.Fatalf("expected sorted final response with summary, got %d results", len(resp.Results))
+	}
+	// 按位置依次插入后应与最终排名一致。
+	var ranking []model.Result
+	for _, u := range updates {
+		for i, pos := range u.Positions {
+			ranking = append(ranking, model.Result{})
+			copy(ranking[pos+1:], ranking[pos:])
+			ranking[pos] = u.Results[i]
+		}
+	}
+	for i := range ranking {
+		if ranking[i].Key() != resp.Results[i].Key() {
+			t.Fatalf("rebuilt ranking differs at %d: %s vs %s", i, ranking[i].Title, resp.Results[i].Title)
+		}
+	}
+
+	cached, err := agg.Search(context.Background(), "品牌", Options{})
+	if err != nil || !cached.Metadata.Cached || len(cached.Results) != 4 {
+		t.Fatalf("expected streamed response to populate the cache, cached=%v err=%v", cached.Metadata.Cached, err)
+	}
+	if _, err := agg.SearchStream(context.Background(), "品牌", Options{Mode: "verbose"}, func(ProviderUpdate) {}); err == nil {
+		t.Fatalf("expected invalid mode to be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package aggregator
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strconv"
+
+	"agentgo/internal/model"
+)
+
+// ResultFingerprint 计算单条结果的内容指纹，只包含会影响摘要的字段。
+func ResultFingerprint(r model.Result) string {
+	h := sha1.New()
+	for _, part := range []string{r.URL, r.Title, r.Summary, r.Source, strconv.FormatInt(r.PublishedAt.Unix(), 10)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SetFingerprint 计算结果集的指纹，与结果顺序无关，重新排序或分页不会改变指纹。
+func SetFingerprint(results []model.Result) string {
+	prints := make([]string, len(results))
+	for i, r := range results {
+		prints[i] = ResultFingerprint(r)
+	}
+	sort.Strings(prints)
+	h := sha1.New()
+	for _, p := range prints {
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
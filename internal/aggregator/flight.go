@@ -0,0 +1,56 @@
+package aggregator
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall 一次正在进行的 provider fan-out。
+type flightCall struct {
+	done chan struct{}
+	resp Response
+}
+
+// flightGroup 合并相同 key 的并发请求，使其共享一次 fan-out。
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: map[string]*flightCall{}}
+}
+
+// start 若 key 已有进行中的调用则返回它，否则在新协程中执行 fn。
+// fn 独立于任何调用方运行，单个调用方取消不会中断其他等待者。
+func (g *flightGroup) start(key string, fn func() Response) (*flightCall, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	go func() {
+		defer func() {
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+		c.resp = fn()
+	}()
+	return c, true
+}
+
+// do 执行或加入 key 对应的调用并等待结果，ctx 取消时立即返回而不影响调用本身。
+// shared 表示结果来自其他调用方发起的 fan-out。
+func (g *flightGroup) do(ctx context.Context, key string, fn func() Response) (resp Response, shared bool, err error) {
+	c, leader := g.start(key, fn)
+	select {
+	case <-c.done:
+		return c.resp, !leader, nil
+	case <-ctx.Done():
+		return Response{}, !leader, ctx.Err()
+	}
+}
@@ -0,0 +1,154 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"agentgo/internal/history"
+	"agentgo/internal/model"
+)
+
+// ErrRecordNotFound 历史记录不存在或已超过保留期。
+var ErrRecordNotFound = errors.New("history record not found")
+
+// ErrSnapshotUnavailable 历史记录的结果快照已不在内存中，且无法从持久化后端读取。
+var ErrSnapshotUnavailable = errors.New("history record has no result snapshot to compare against")
+
+// ReplayResponse 重放结果。
+type ReplayResponse struct {
+	Record   history.Record `json:"record"`
+	Response Response       `json:"response"`
+	Diff     ResultDiff     `json:"diff"`
+}
+
+// ResultDiff 重放结果与历史快照的差异，结果按 URL 对应。
+type ResultDiff struct {
+	Added     []model.Result           `json:"added"`
+	Removed   []history.ResultSnapshot `json:"removed"`
+	Changed   []MetricChange           `json:"changed"`
+	Unchanged int                      `json:"unchanged"`
+}
+
+// MetricChange 同一 URL 的指标变化。
+type MetricChange struct {
+	URL     string                 `json:"url"`
+	Title   string                 `json:"title"`
+	Source  string                 `json:"source"`
+	Metrics map[string]MetricDelta `json:"metrics"`
+}
+
+// MetricDelta 单个指标（如点赞、阅读）的前后对比，Growth 为相对增长率，原值为 0 时为 0。
+type MetricDelta struct {
+	Before int64   `json:"before"`
+	After  int64   `json:"after"`
+	Delta  int64   `json:"delta"`
+	Growth float64 `json:"growth"`
+}
+
+// HistoryRecord 按 ID 返回历史记录。
+func (a *Aggregator) HistoryRecord(id uint64) (history.Record, bool) {
+	return a.history.Get(id)
+}
+
+// Replay 以历史记录中的平台与参数重新执行搜索，并与当时返回的结果做差异对比。
+// 重放总是绕过响应缓存，且只取结果不生成摘要。
+func (a *Aggregator) Replay(ctx context.Context, id uint64) (ReplayResponse, error) {
+	record, ok := a.history.Get(id)
+	if !ok {
+		return ReplayResponse{}, ErrRecordNotFound
+	}
+	if record.Results > 0 && len(record.Snapshot) == 0 {
+		return ReplayResponse{}, ErrSnapshotUnavailable
+	}
+	resp, err := a.Search(ctx, record.Query, Options{
+		Providers:    record.Providers,
+		Limit:        record.Options.Limit,
+		ForceRefresh: true,
+		Locale:       record.Options.Locale,
+		StartTime:    record.Options.StartTime,
+		EndTime:      record.Options.EndTime,
+		Mode:         ModeResultsOnly,
+		Sort:         record.Options.Sort,
+	})
+	if err != nil {
+		return ReplayResponse{}, err
+	}
+	return ReplayResponse{
+		Record:   record,
+		Response: resp,
+		Diff:     DiffResults(record.Snapshot, resp.Results),
+	}, nil
+}
+
+// DiffResults 对比历史快照与当前结果：新出现的 URL 计入 Added，消失的计入 Removed，
+// 两边都有且指标发生变化的计入 Changed。没有 URL 的结果以来源加标题对应。
+func DiffResults(before []history.ResultSnapshot, after []model.Result) ResultDiff {
+	diff := ResultDiff{Added: []model.Result{}, Removed: []history.ResultSnapshot{}, Changed: []MetricChange{}}
+	previous := make(map[string]history.ResultSnapshot, len(before))
+	for _, snap := range before {
+		previous[model.ResultKey(snap.URL, snap.Source, snap.Title)] = snap
+	}
+
+	seen := make(map[string]bool, len(after))
+	for _, r := range after {
+		key := model.ResultKey(r.URL, r.Source, r.Title)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		snap, ok := previous[key]
+		if !ok {
+			diff.Added = append(diff.Added, r)
+			continue
+		}
+		if metrics := diffMetrics(snap.Metrics, r.Metrics); len(metrics) > 0 {
+			diff.Changed = append(diff.Changed, MetricChange{URL: r.URL, Title: r.Title, Source: r.Source, Metrics: metrics})
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for _, snap := range before {
+		key := model.ResultKey(snap.URL, snap.Source, snap.Title)
+		if !seen[key] {
+			seen[key] = true
+			diff.Removed = append(diff.Removed, snap)
+		}
+	}
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return maxDelta(diff.Changed[i].Metrics) > maxDelta(diff.Changed[j].Metrics)
+	})
+	return diff
+}
+
+func diffMetrics(before, after map[string]int64) map[string]MetricDelta {
+	out := map[string]MetricDelta{}
+	for name, value := range after {
+		if old := before[name]; old != value {
+			out[name] = newMetricDelta(old, value)
+		}
+	}
+	for name, old := range before {
+		if _, ok := after[name]; !ok {
+			out[name] = newMetricDelta(old, 0)
+		}
+	}
+	return out
+}
+
+func newMetricDelta(before, after int64) MetricDelta {
+	d := MetricDelta{Before: before, After: after, Delta: after - before}
+	if before != 0 {
+		d.Growth = float64(d.Delta) / float64(before)
+	}
+	return d
+}
+
+// maxDelta 返回变化最大的指标增量，用于把增长最快的结果排在前面。
+func maxDelta(metrics map[string]MetricDelta) int64 {
+	var best int64
+	for _, m := range metrics {
+		best = max(best, m.Delta)
+	}
+	return best
+}
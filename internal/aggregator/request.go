@@ -0,0 +1,22 @@
+package aggregator
+
+import "context"
+
+// RequestInfo 描述发起搜索的请求，写入历史记录用于审计。
+type RequestInfo struct {
+	ID     string
+	Caller string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo 把请求信息附加到 ctx。
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom 读取 ctx 中的请求信息，未设置时返回零值。
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,81 @@
+package aggregator
+
+import "agentgo/internal/model"
+
+// 以下估算只统计主要字符串与集合元素，用于缓存的近似字节上限，不追求精确。
+const (
+	structOverhead = 64
+	mapEntrySize   = 48
+)
+
+func responseSize(key string, resp Response) int64 {
+	size := int64(len(key)+len(resp.Query)) + structOverhead
+	for _, r := range resp.Results {
+		size += resultSize(r)
+	}
+	for _, st := range resp.Metadata.ProviderStatuses {
+		size += int64(len(st.Name)+len(st.Error)) + structOverhead
+	}
+	if resp.Summary != nil {
+		size += summarySize(*resp.Summary)
+	}
+	return size
+}
+
+func resultsSize(key string, results []model.Result) int64 {
+	size := int64(len(key)) + structOverhead
+	for _, r := range results {
+		size += resultSize(r)
+	}
+	return size
+}
+
+func summaryEntrySize(key string, entry summaryEntry) int64 {
+	size := int64(len(key)) + summarySize(entry.summary)
+	for fp, ann := range entry.annotations {
+		size += int64(len(fp)) + mapEntrySize
+		if ann.sentiment != nil {
+			size += structOverhead
+		}
+		for _, e := range ann.entities {
+			size += int64(len(e.Name)+len(e.Type)) + structOverhead
+		}
+	}
+	return size
+}
+
+func resultSize(r model.Result) int64 {
+	size := int64(len(r.Title)+len(r.URL)+len(r.Summary)+len(r.Author)+len(r.Source)) + structOverhead
+	size += int64(len(r.Metrics)) * mapEntrySize
+	for _, tag := range r.Tags {
+		size += int64(len(tag))
+	}
+	for k, v := range r.Extras {
+		size += int64(len(k)+len(v)) + mapEntrySize
+	}
+	for _, e := range r.Entities {
+		size += int64(len(e.Name)+len(e.Type)) + structOverhead
+	}
+	return size
+}
+
+func summarySize(s model.Summary) int64 {
+	size := int64(len(s.Query)+len(s.Overview)+len(s.Sentiment)) + structOverhead
+	for _, h := range s.Highlights {
+		size += int64(len(h))
+	}
+	for _, k := range s.Keywords {
+		size += int64(len(k))
+	}
+	for _, t := range s.Topics {
+		size += int64(len(t.Label)+8*len(t.Members)) + int64(len(t.SourceBreakdown))*mapEntrySize + structOverhead
+	}
+	size += int64(len(s.Entities)+len(s.Aspects)) * structOverhead
+	size += int64(len(s.SourceBreakdown)) * mapEntrySize
+	if s.Timeline != nil {
+		for _, b := range s.Timeline.Buckets {
+			size += int64(len(b.BySource))*mapEntrySize + structOverhead
+		}
+	}
+	return size
+}
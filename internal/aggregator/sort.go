@@ -0,0 +1,53 @@
+package aggregator
+
+import (
+	"sort"
+	"strings"
+
+	"agentgo/internal/model"
+)
+
+// 结果排序方式。
+const (
+	// SortTime 按发布时间从新到旧，为默认排序。
+	SortTime = "time"
+	// SortSentiment 按情绪得分从高到低，未打分的结果排在最后。
+	SortSentiment = "sentiment"
+	// SortMetricPrefix 后接指标名，如 metrics.likes，按该指标从高到低排序。
+	SortMetricPrefix = "metrics."
+)
+
+// ParseSort 校验并归一排序方式，空字符串视为 SortTime。
+func ParseSort(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "", SortTime:
+		return SortTime, true
+	case SortSentiment:
+		return SortSentiment, true
+	}
+	// 前缀与 time、sentiment 一样不区分大小写，指标名保持原样。
+	if len(raw) > len(SortMetricPrefix) && strings.EqualFold(raw[:len(SortMetricPrefix)], SortMetricPrefix) {
+		return SortMetricPrefix + raw[len(SortMetricPrefix):], true
+	}
+	return "", false
+}
+
+// sortResults 对已按发布时间排序的结果稳定排序，同分时保持时间顺序。
+func sortResults(results []model.Result, by string) {
+	switch {
+	case by == SortSentiment:
+		sort.SliceStable(results, func(i, j int) bool {
+			a, b := results[i].Sentiment, results[j].Sentiment
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return a.Score > b.Score
+		})
+	case strings.HasPrefix(by, SortMetricPrefix):
+		name := strings.TrimPrefix(by, SortMetricPrefix)
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Metrics[name] > results[j].Metrics[name]
+		})
+	}
+}
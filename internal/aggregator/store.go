@@ -0,0 +1,145 @@
+package aggregator
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"agentgo/internal/cache"
+	"agentgo/internal/model"
+)
+
+// 缓存后端类型。
+const (
+	// CacheMemory 进程内存缓存，重启后清空。
+	CacheMemory = "memory"
+	// CacheDisk 磁盘缓存，重启后仍然有效。
+	CacheDisk = "disk"
+	// CacheTiered 内存 + 磁盘两级缓存。
+	CacheTiered = "tiered"
+	// CacheRedis 多副本共享的 Redis 缓存，不可用时降级到内存缓存。
+	CacheRedis = "redis"
+)
+
+// CacheConfig 选择缓存后端。
+type CacheConfig struct {
+	// Backend 取值见 CacheMemory、CacheDisk、CacheTiered、CacheRedis，为空时等同 CacheMemory。
+	Backend string
+	// Dir disk 与 tiered 模式的数据目录。
+	Dir string
+	// RedisAddr、RedisPassword、RedisDB、RedisPrefix 为 redis 模式的连接参数。
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisPrefix   string
+	// RedisSerializer 取值 json 或 gob，为空时使用 json。
+	RedisSerializer string
+}
+
+// OpenCaches 按后端类型创建响应缓存与单平台结果缓存，结果可直接填入 Config。
+// disk 与 tiered 模式分别在 Dir 下的 responses、providers 子目录中保存数据，
+// redis 模式分别使用 RedisPrefix 后接 resp:、prov: 的键前缀。
+func OpenCaches(cc CacheConfig, cfg Config) (cache.Store[string, Response], cache.Store[string, []model.Result], error) {
+	switch cc.Backend {
+	case "", CacheMemory:
+		return newResponseMemoryCache(cfg, true), newProviderMemoryCache(cfg), nil
+	case CacheRedis:
+		return openRedisCaches(cc, cfg)
+	case CacheDisk, CacheTiered:
+	default:
+		return nil, nil, fmt.Errorf("unknown cache backend %q", cc.Backend)
+	}
+	backend, dir := cc.Backend, cc.Dir
+	if dir == "" {
+		return nil, nil, fmt.Errorf("cache backend %s requires a directory", backend)
+	}
+
+	ttl := cacheTTL(cfg)
+	responseDisk, err := cache.OpenDisk[Response](filepath.Join(dir, "responses"), ttl, cache.DiskOptions{
+		StaleTTL:        cfg.StaleTTL,
+		JanitorInterval: janitorInterval(ttl),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open response cache: %w", err)
+	}
+	providerDisk, err := cache.OpenDisk[[]model.Result](filepath.Join(dir, "providers"), ttl, cache.DiskOptions{
+		JanitorInterval: janitorInterval(ttl),
+	})
+	if err != nil {
+		responseDisk.Close()
+		return nil, nil, fmt.Errorf("open provider cache: %w", err)
+	}
+
+	if backend == CacheDisk {
+		return responseDisk, providerDisk, nil
+	}
+	// 宽限期内的旧响应由磁盘层提供，内存层只保存未过期的条目。
+	return cache.NewTiered(newResponseMemoryCache(cfg, false), responseDisk),
+		cache.NewTiered(newProviderMemoryCache(cfg), providerDisk), nil
+}
+
+func openRedisCaches(cc CacheConfig, cfg Config) (cache.Store[string, Response], cache.Store[string, []model.Result], error) {
+	var (
+		responseSerializer cache.Serializer[Response]
+		providerSerializer cache.Serializer[[]model.Result]
+	)
+	switch cc.RedisSerializer {
+	case "", "json":
+		responseSerializer, providerSerializer = cache.JSONSerializer[Response]{}, cache.JSONSerializer[[]model.Result]{}
+	case "gob":
+		responseSerializer, providerSerializer = cache.GobSerializer[Response]{}, cache.GobSerializer[[]model.Result]{}
+	default:
+		return nil, nil, fmt.Errorf("unknown redis serializer %q", cc.RedisSerializer)
+	}
+
+	ttl := cacheTTL(cfg)
+	responses, err := cache.NewRedis(ttl, cache.RedisOptions[Response]{
+		Addr:       cc.RedisAddr,
+		Password:   cc.RedisPassword,
+		DB:         cc.RedisDB,
+		Prefix:     cc.RedisPrefix + "resp:",
+		Serializer: responseSerializer,
+		StaleTTL:   cfg.StaleTTL,
+		Fallback:   newResponseMemoryCache(cfg, true),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open response cache: %w", err)
+	}
+	providerResults, err := cache.NewRedis(ttl, cache.RedisOptions[[]model.Result]{
+		Addr:       cc.RedisAddr,
+		Password:   cc.RedisPassword,
+		DB:         cc.RedisDB,
+		Prefix:     cc.RedisPrefix + "prov:",
+		Serializer: providerSerializer,
+		Fallback:   newProviderMemoryCache(cfg),
+	})
+	if err != nil {
+		responses.Close()
+		return nil, nil, fmt.Errorf("open provider cache: %w", err)
+	}
+	return responses, providerResults, nil
+}
+
+// newResponseMemoryCache 创建响应内存缓存，withStale 为 false 时不保留过期条目。
+func newResponseMemoryCache(cfg Config, withStale bool) *cache.Cache[string, Response] {
+	ttl := cacheTTL(cfg)
+	opts := cache.Options[string, Response]{
+		MaxEntries:      cfg.CacheMaxEntries,
+		MaxBytes:        cfg.CacheMaxBytes,
+		Sizer:           responseSize,
+		JanitorInterval: janitorInterval(ttl),
+	}
+	if withStale {
+		opts.StaleTTL = cfg.StaleTTL
+	}
+	return cache.NewWithOptions(ttl, opts)
+}
+
+func newProviderMemoryCache(cfg Config) *cache.Cache[string, []model.Result] {
+	ttl := cacheTTL(cfg)
+	return cache.NewWithOptions(ttl, cache.Options[string, []model.Result]{
+		MaxEntries:      cfg.CacheMaxEntries,
+		MaxBytes:        cfg.CacheMaxBytes,
+		Sizer:           resultsSize,
+		JanitorInterval: janitorInterval(ttl),
+	})
+}
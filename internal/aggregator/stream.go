@@ -0,0 +1,73 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// ProviderUpdate 流式搜索中单个平台返回后的进度。
+//
+// 已返回平台的结果合并后按请求的排序方式排列（按情绪排序时摘要生成前保持时间顺序），
+// 先前结果之间的相对顺序不会改变，因此只推送新结果及其位置：
+// 按 Positions 升序依次把 Results[i] 插入到已有排名的 Positions[i] 处即得到最新排名。
+type ProviderUpdate struct {
+	Status ProviderStatus `json:"status"`
+	// Results 该平台返回的结果，按在合并排名中的位置排列，出错时为空。
+	Results []model.Result `json:"results"`
+	// Positions 与 Results 一一对应，为各结果在合并排名中的下标。
+	Positions []int `json:"positions"`
+	// Completed、Total 分别为已返回与参与搜索的平台数。
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// SearchStream 与 Search 相同，但在每个平台返回时调用 onProvider，便于先展示已到达的结果。
+//
+// 为了逐个平台推送，流式搜索不读取整体响应缓存，也不与进行中的同一请求合并，
+// 单平台结果缓存照常生效；完整取得结果后写入响应缓存供后续 Search 使用。
+// onProvider 在同一个 goroutine 中按到达顺序调用，返回前不会再次调用。
+func (a *Aggregator) SearchStream(ctx context.Context, query string, opts Options, onProvider func(ProviderUpdate)) (Response, error) {
+	query = strings.TrimSpace(query)
+	providers, err := a.prepare(query, opts)
+	if err != nil {
+		return Response{}, err
+	}
+	sortBy, _ := ParseSort(opts.Sort)
+	start := time.Now()
+
+	var merged, ranking []model.Result
+	completed := 0
+	resp := a.fetch(ctx, query, providers, opts, func(status ProviderStatus, results []model.Result) {
+		completed++
+		merged = append(merged, results...)
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].PublishedAt.After(merged[j].PublishedAt)
+		})
+		next := make([]model.Result, len(merged))
+		copy(next, merged)
+		sortResults(next, sortBy)
+
+		// 两次排序都是稳定的，原有结果按原顺序出现，不与其对上的就是本次新增的结果。
+		update := ProviderUpdate{Status: status, Results: []model.Result{}, Positions: []int{}, Completed: completed, Total: len(providers)}
+		j := 0
+		for i, r := range next {
+			if j < len(ranking) && r.Key() == ranking[j].Key() {
+				j++
+				continue
+			}
+			update.Results = append(update.Results, r)
+			update.Positions = append(update.Positions, i)
+		}
+		ranking = next
+		onProvider(update)
+	})
+	// 客户端中途断开时各平台多半以取消告终，这样的结果不写入缓存。
+	if ctx.Err() == nil {
+		a.cache.Set(a.buildCacheKey(query, providers, opts), resp)
+	}
+	return a.finish(ctx, query, providers, opts, start, resp), nil
+}
@@ -0,0 +1,89 @@
+package aggregator
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"agentgo/internal/model"
+	"agentgo/internal/summary"
+)
+
+// 摘要模式。
+const (
+	// ModeFull 同时返回结果与摘要。
+	ModeFull = "full"
+	// ModeResultsOnly 只返回结果，不调用摘要器。
+	ModeResultsOnly = "results_only"
+	// ModeSummaryOnly 只返回摘要，适合看板类调用。
+	ModeSummaryOnly = "summary_only"
+)
+
+// ParseMode 校验并归一摘要模式，空字符串视为 ModeFull。
+func ParseMode(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", ModeFull:
+		return ModeFull, true
+	case ModeResultsOnly:
+		return ModeResultsOnly, true
+	case ModeSummaryOnly:
+		return ModeSummaryOnly, true
+	default:
+		return "", false
+	}
+}
+
+// summaryEntry 摘要缓存项，同时保存摘要器写回结果的逐条标注。
+type summaryEntry struct {
+	summary     model.Summary
+	annotations map[string]annotation
+}
+
+// annotation 摘要器为单条结果补充的字段。
+type annotation struct {
+	sentiment *model.SentimentScore
+	entities  []model.Entity
+}
+
+// summarize 以结果集指纹为键缓存摘要，命中时把缓存的逐条标注写回 results。
+func (a *Aggregator) summarize(ctx context.Context, query string, results []model.Result, opts Options) (model.Summary, bool, time.Duration, error) {
+	start := time.Now()
+	key := a.buildSummaryKey(query, results, opts)
+	if entry, ok := a.summaries.Get(key); ok {
+		for i := range results {
+			if ann, ok := entry.annotations[ResultFingerprint(results[i])]; ok {
+				results[i].Sentiment = ann.sentiment
+				results[i].Entities = ann.entities
+			}
+		}
+		return entry.summary, true, time.Since(start), nil
+	}
+
+	ctx = summary.WithOptions(ctx, summary.Options{
+		Locale: opts.Locale,
+		Start:  opts.StartTime,
+		End:    opts.EndTime,
+	})
+	result, err := a.summarizer.Summarize(ctx, query, results)
+	took := time.Since(start)
+	if err != nil {
+		return result, false, took, err
+	}
+
+	entry := summaryEntry{summary: result, annotations: make(map[string]annotation, len(results))}
+	for _, r := range results {
+		entry.annotations[ResultFingerprint(r)] = annotation{sentiment: r.Sentiment, entities: r.Entities}
+	}
+	a.summaries.Set(key, entry)
+	return result, false, took, nil
+}
+
+func (a *Aggregator) buildSummaryKey(query string, results []model.Result, opts Options) string {
+	return strings.Join([]string{
+		SetFingerprint(results),
+		strings.ToLower(query),
+		opts.Locale,
+		formatWindow(opts.StartTime),
+		formatWindow(opts.EndTime),
+	}, "|")
+}
@@ -0,0 +1,321 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+)
+
+// maxSeen 每条 new_results 规则记住的结果数，超出后淘汰最早的。
+const maxSeen = 10000
+
+// Engine 在每次搜索后对匹配的规则求值，触发的告警交给 Notifier 异步投递。
+//
+// new_results 规则在内存中记录已见过的结果，规则第一次求值只建立基线不触发；
+// volume 规则在内存中累计窗口内的结果。服务重启后两者都重新开始。
+type Engine struct {
+	store    *Store
+	notifier *Notifier
+	seen     map[uint64]*seenSet
+	volume   map[uint64]*volumeWindow
+	mu       sync.Mutex
+}
+
+// NewEngine 创建告警引擎。
+func NewEngine(store *Store, notifier *Notifier) *Engine {
+	return &Engine{store: store, notifier: notifier, seen: map[uint64]*seenSet{}, volume: map[uint64]*volumeWindow{}}
+}
+
+// Store 返回规则仓库。
+func (e *Engine) Store() *Store {
+	return e.store
+}
+
+// ObserveSearch 可直接作为 aggregator.Config.OnSearch 使用。
+func (e *Engine) ObserveSearch(ctx context.Context, query string, resp aggregator.Response) {
+	e.Evaluate(ctx, query, resp.Results, time.Now())
+}
+
+// Evaluate 对查询词匹配的规则求值，返回本次触发（已过冷却期）的告警。
+// 规则只对其创建者发起的搜索求值（包括创建者的监控任务），调用方取自 ctx 中的请求信息，
+// 以免把其他用户的搜索结果发送到规则的 Webhook。
+func (e *Engine) Evaluate(ctx context.Context, query string, results []model.Result, now time.Time) []Alert {
+	var fired []Alert
+	info := aggregator.RequestInfoFrom(ctx)
+	for _, rule := range e.store.matching(info.Caller, query) {
+		alert, ok := e.check(rule, results, now)
+		if !ok {
+			continue
+		}
+		eventID, ok := e.store.fire(rule.ID, now, alert.Message, alert.Value)
+		if !ok {
+			continue
+		}
+		alert.RuleID = rule.ID
+		alert.RuleName = rule.Name
+		alert.Type = rule.Condition.Type
+		alert.Query = query
+		alert.Threshold = rule.Condition.Threshold
+		alert.RequestID = info.ID
+		alert.FiredAt = now
+		if len(alert.Results) > maxAlertResults {
+			alert.Results = alert.Results[:maxAlertResults]
+		}
+		fired = append(fired, alert)
+
+		ruleID := rule.ID
+		done := func(attempts int, err error) {
+			e.store.finishEvent(ruleID, eventID, attempts, err)
+		}
+		if !e.notifier.Enqueue(rule.Webhook, alert, done) {
+			log.Printf("alert rule %d: notification queue full, dropped", rule.ID)
+			done(0, ErrQueueFull)
+		}
+	}
+	return fired
+}
+
+// CheckWebhook 检查规则的 Webhook 地址能否投递，创建或修改规则前调用。
+func (e *Engine) CheckWebhook(ctx context.Context, rule Rule) error {
+	return e.notifier.CheckURL(ctx, rule.Webhook.URL)
+}
+
+// Test 把一条示例告警加入投递队列，用于检查配置；投递结果与普通告警一样记入规则的触发记录。
+// 返回的记录状态为 pending，规则已删除时返回 ErrRuleNotFound，队列已满时返回 ErrQueueFull。
+func (e *Engine) Test(rule Rule) (Event, error) {
+	const message = "这是一条测试告警。"
+	now := time.Now()
+	event, ok := e.store.record(rule.ID, now, message)
+	if !ok {
+		return Event{}, ErrRuleNotFound
+	}
+	alert := Alert{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Type:     rule.Condition.Type,
+		Query:    rule.Query,
+		Message:  message,
+		FiredAt:  now,
+		Results:  []model.Result{},
+	}
+	done := func(attempts int, err error) {
+		e.store.finishEvent(rule.ID, event.ID, attempts, err)
+	}
+	if !e.notifier.Enqueue(rule.Webhook, alert, done) {
+		done(0, ErrQueueFull)
+		return Event{}, ErrQueueFull
+	}
+	return event, nil
+}
+
+// check 判断规则是否满足，满足时返回填好 Message、Value 与相关结果的告警。
+func (e *Engine) check(rule Rule, results []model.Result, now time.Time) (Alert, bool) {
+	c := rule.Condition
+	switch c.Type {
+	case CondNewResults:
+		fresh := e.observe(rule.ID, results)
+		var matched []model.Result
+		for _, r := range fresh {
+			if c.Match == "" || containsFold(r.Title, c.Match) || containsFold(r.Summary, c.Match) {
+				matched = append(matched, r)
+			}
+		}
+		if len(matched) == 0 {
+			return Alert{}, false
+		}
+		msg := fmt.Sprintf("出现 %d 条新结果", len(matched))
+		if c.Match != "" {
+			msg = fmt.Sprintf("出现 %d 条包含“%s”的新结果", len(matched), c.Match)
+		}
+		return Alert{Message: msg, Value: float64(len(matched)), Results: matched}, true
+
+	case CondVolume:
+		window, _ := time.ParseDuration(c.Window)
+		recent := e.accumulate(rule.ID, results, now, window)
+		if float64(len(recent)) < c.Threshold {
+			return Alert{}, false
+		}
+		return Alert{
+			Message: fmt.Sprintf("最近 %s 内累计出现 %d 条结果，达到阈值 %g", c.Window, len(recent), c.Threshold),
+			Value:   float64(len(recent)),
+			Results: recent,
+		}, true
+
+	case CondNegativeShare:
+		minResults := c.MinResults
+		if minResults <= 0 {
+			minResults = 5
+		}
+		var scored int
+		var negative []model.Result
+		for _, r := range results {
+			if r.Sentiment == nil {
+				continue
+			}
+			scored++
+			if r.Sentiment.Label == "negative" {
+				negative = append(negative, r)
+			}
+		}
+		if scored < minResults {
+			return Alert{}, false
+		}
+		share := float64(len(negative)) * 100 / float64(scored)
+		if share < c.Threshold {
+			return Alert{}, false
+		}
+		return Alert{
+			Message: fmt.Sprintf("负面结果占比 %.1f%%（%d/%d），达到阈值 %g%%", share, len(negative), scored, c.Threshold),
+			Value:   share,
+			Results: negative,
+		}, true
+
+	case CondEngagement:
+		type scoredResult struct {
+			result model.Result
+			value  int64
+		}
+		var hot []scoredResult
+		for _, r := range results {
+			if v := engagement(r, c.Metric); float64(v) >= c.Threshold {
+				hot = append(hot, scoredResult{r, v})
+			}
+		}
+		if len(hot) == 0 {
+			return Alert{}, false
+		}
+		sort.SliceStable(hot, func(i, j int) bool { return hot[i].value > hot[j].value })
+		out := make([]model.Result, len(hot))
+		for i, h := range hot {
+			out[i] = h.result
+		}
+		metric := c.Metric
+		if metric == "" {
+			metric = "互动量"
+		}
+		return Alert{
+			Message: fmt.Sprintf("%d 条结果的%s超过 %g，最高 %d：%s", len(hot), metric, c.Threshold, hot[0].value, hot[0].result.Title),
+			Value:   float64(hot[0].value),
+			Results: out,
+		}, true
+	}
+	return Alert{}, false
+}
+
+// observe 记录结果并返回此前未见过的部分，规则第一次求值时只建立基线。
+func (e *Engine) observe(ruleID uint64, results []model.Result) []model.Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	set, ok := e.seen[ruleID]
+	if !ok {
+		set = &seenSet{keys: map[string]struct{}{}}
+		e.seen[ruleID] = set
+	}
+	var fresh []model.Result
+	for _, r := range results {
+		if set.add(r.Key()) && ok {
+			fresh = append(fresh, r)
+		}
+	}
+	return fresh
+}
+
+// accumulate 把本次搜索的结果计入规则的时间窗口，返回窗口内的全部结果，最新的在前。
+func (e *Engine) accumulate(ruleID uint64, results []model.Result, now time.Time, window time.Duration) []model.Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	w, ok := e.volume[ruleID]
+	if !ok {
+		w = &volumeWindow{entries: map[string]volumeEntry{}}
+		e.volume[ruleID] = w
+	}
+	return w.add(results, now, now.Add(-window))
+}
+
+// volumeWindow 规则见过的结果及其计入窗口的时间，按结果键去重。
+type volumeWindow struct {
+	entries map[string]volumeEntry
+}
+
+type volumeEntry struct {
+	at     time.Time
+	result model.Result
+}
+
+// add 加入结果并淘汰 since 之前的记录，超出 maxSeen 时淘汰最早的。
+func (w *volumeWindow) add(results []model.Result, now, since time.Time) []model.Result {
+	for _, r := range results {
+		key := r.Key()
+		at := r.PublishedAt
+		if at.IsZero() {
+			at = now
+			if prev, ok := w.entries[key]; ok {
+				at = prev.at
+			}
+		}
+		w.entries[key] = volumeEntry{at: at, result: r}
+	}
+	recent := make([]volumeEntry, 0, len(w.entries))
+	for key, entry := range w.entries {
+		if entry.at.Before(since) {
+			delete(w.entries, key)
+			continue
+		}
+		recent = append(recent, entry)
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i].at.After(recent[j].at) })
+	if len(recent) > maxSeen {
+		for _, entry := range recent[maxSeen:] {
+			delete(w.entries, entry.result.Key())
+		}
+		recent = recent[:maxSeen]
+	}
+	out := make([]model.Result, len(recent))
+	for i, entry := range recent {
+		out[i] = entry.result
+	}
+	return out
+}
+
+// seenSet 按插入顺序淘汰的结果集合。
+type seenSet struct {
+	keys  map[string]struct{}
+	order []string
+}
+
+// add 加入 key，已存在时返回 false。
+func (s *seenSet) add(key string) bool {
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+	if len(s.order) > maxSeen {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	return true
+}
+
+// engagement 返回指定指标的值，metric 为空时返回全部指标之和。
+func engagement(r model.Result, metric string) int64 {
+	if metric != "" {
+		return r.Metrics[metric]
+	}
+	var total int64
+	for _, v := range r.Metrics {
+		total += v
+	}
+	return total
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
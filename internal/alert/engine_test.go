@@ -0,0 +1,232 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+)
+
+func newTestEngine(t *testing.T) (*Engine, *httptest.Server, *sync.WaitGroup) {
+	t.Helper()
+	var wg sync.WaitGroup
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		wg.Done()
+	}))
+	t.Cleanup(srv.Close)
+	store, _ := NewStore("")
+	n := NewNotifier(NotifierConfig{Backoff: time.Millisecond, AllowTargets: []string{"127.0.0.1"}})
+	t.Cleanup(func() { n.Close() })
+	return NewEngine(store, n), srv, &wg
+}
+
+func addRule(t *testing.T, e *Engine, url string, cond Condition, cooldown string) Rule {
+	t.Helper()
+	rule, err := e.Store().Create(Rule{
+		Owner:     "user:alice",
+		Name:      cond.Type,
+		Query:     "品牌A",
+		Condition: cond,
+		Webhook:   Webhook{URL: url},
+		Cooldown:  cooldown,
+		Enabled:   true,
+	})
+	if err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	return rule
+}
+
+// aliceSearch 模拟规则创建者发起的搜索。
+func aliceSearch() context.Context {
+	return aggregator.WithRequestInfo(context.Background(), aggregator.RequestInfo{ID: "req-1", Caller: "user:alice"})
+}
+
+func TestEngineNewResultsUsesBaseline(t *testing.T) {
+	e, srv, wg := newTestEngine(t)
+	rule := addRule(t, e, srv.URL, Condition{Type: CondNewResults, Match: "召回"}, "0s")
+	ctx := aliceSearch()
+	now := time.Now()
+
+	first := []model.Result{{Title: "品牌A 新品", URL: "https://a"}}
+	if fired := e.Evaluate(ctx, "品牌A 新品", first, now); len(fired) != 0 {
+		t.Fatalf("expected first evaluation to only build a baseline, got %+v", fired)
+	}
+	second := append(first, model.Result{Title: "品牌A 宣布召回", URL: "https://b"}, model.Result{Title: "品牌A 促销", URL: "https://c"})
+	wg.Add(1)
+	fired := e.Evaluate(ctx, "品牌A 新品", second, now)
+	if len(fired) != 1 || fired[0].Value != 1 || fired[0].Results[0].URL != "https://b" {
+		t.Fatalf("expected one matching new result, got %+v", fired)
+	}
+	if fired := e.Evaluate(ctx, "品牌A", second, now); len(fired) != 0 {
+		t.Fatalf("expected seen results not to fire again, got %+v", fired)
+	}
+	if fired := e.Evaluate(ctx, "品牌B", []model.Result{{Title: "品牌A 召回", URL: "https://d"}}, now); len(fired) != 0 {
+		t.Fatalf("expected non-matching query to be ignored")
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		events, _ := e.Store().Events("user:alice", rule.ID)
+		if len(events) == 1 && events[0].Status == StatusDelivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected delivered event, got %+v", events)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEngineThresholdConditions(t *testing.T) {
+	e, srv, wg := newTestEngine(t)
+	now := time.Now()
+	addRule(t, e, srv.URL, Condition{Type: CondVolume, Window: "1h", Threshold: 2}, "")
+	addRule(t, e, srv.URL, Condition{Type: CondNegativeShare, Threshold: 50, MinResults: 2}, "")
+	addRule(t, e, srv.URL, Condition{Type: CondEngagement, Metric: "likes", Threshold: 1000}, "")
+
+	negative := &model.SentimentScore{Score: -0.8, Label: "negative"}
+	positive := &model.SentimentScore{Score: 0.6, Label: "positive"}
+	results := []model.Result{
+		{Title: "a", URL: "https://a", PublishedAt: now.Add(-10 * time.Minute), Sentiment: negative, Metrics: map[string]int64{"likes": 1500}},
+		{Title: "b", URL: "https://b", PublishedAt: now.Add(-30 * time.Minute), Sentiment: positive, Metrics: map[string]int64{"likes": 20}},
+		{Title: "c", URL: "https://c", PublishedAt: now.Add(-3 * time.Hour)},
+	}
+	wg.Add(3)
+	fired := e.Evaluate(aliceSearch(), "品牌A", results, now)
+	wg.Wait()
+	got := map[string]Alert{}
+	for _, a := range fired {
+		got[a.Type] = a
+	}
+	if a := got[CondVolume]; a.Value != 2 || len(a.Results) != 2 {
+		t.Fatalf("unexpected volume alert: %+v", a)
+	}
+	if a := got[CondNegativeShare]; a.Value != 50 || a.Results[0].URL != "https://a" {
+		t.Fatalf("unexpected negative share alert: %+v", a)
+	}
+	if a := got[CondEngagement]; a.Value != 1500 || len(a.Results) != 1 {
+		t.Fatalf("unexpected engagement alert: %+v", a)
+	}
+
+	// 默认 1 小时冷却期内不重复触发。
+	if fired := e.Evaluate(aliceSearch(), "品牌A", results, now.Add(time.Minute)); len(fired) != 0 {
+		t.Fatalf("expected cooldown to suppress alerts, got %d", len(fired))
+	}
+}
+
+func TestEngineVolumeAccumulatesAcrossSearches(t *testing.T) {
+	e, srv, wg := newTestEngine(t)
+	addRule(t, e, srv.URL, Condition{Type: CondVolume, Window: "1h", Threshold: 2}, "0s")
+	now := time.Now()
+	a := model.Result{Title: "a", URL: "https://a", PublishedAt: now.Add(-10 * time.Minute)}
+	b := model.Result{Title: "b", URL: "https://b"}
+
+	if fired := e.Evaluate(aliceSearch(), "品牌A", []model.Result{a}, now); len(fired) != 0 {
+		t.Fatalf("one result must not reach the threshold, got %+v", fired)
+	}
+	// 重复运行返回同一结果，不重复计数。
+	if fired := e.Evaluate(aliceSearch(), "品牌A", []model.Result{a}, now.Add(time.Minute)); len(fired) != 0 {
+		t.Fatalf("re-running the same search must not double count, got %+v", fired)
+	}
+	wg.Add(1)
+	fired := e.Evaluate(aliceSearch(), "品牌A", []model.Result{b}, now.Add(2*time.Minute))
+	wg.Wait()
+	if len(fired) != 1 || fired[0].Value != 2 || len(fired[0].Results) != 2 {
+		t.Fatalf("expected results of separate searches to add up, got %+v", fired)
+	}
+
+	// 窗口过后早先的结果不再计入。
+	if fired := e.Evaluate(aliceSearch(), "品牌A", []model.Result{b}, now.Add(90*time.Minute)); len(fired) != 0 {
+		t.Fatalf("expected results outside the window to expire, got %+v", fired)
+	}
+}
+
+func TestEngineOnlyEvaluatesOwnersSearches(t *testing.T) {
+	e, srv, wg := newTestEngine(t)
+	addRule(t, e, srv.URL, Condition{Type: CondEngagement, Metric: "likes", Threshold: 10}, "0s")
+	results := []model.Result{{Title: "b 的私有结果", URL: "https://private", Metrics: map[string]int64{"likes": 100}}}
+	now := time.Now()
+
+	bob := aggregator.WithRequestInfo(context.Background(), aggregator.RequestInfo{Caller: "user:bob"})
+	if fired := e.Evaluate(bob, "品牌A", results, now); len(fired) != 0 {
+		t.Fatalf("another user's search must not trigger alice's rule, got %+v", fired)
+	}
+	if fired := e.Evaluate(context.Background(), "品牌A", results, now); len(fired) != 0 {
+		t.Fatalf("anonymous searches must not trigger rules, got %+v", fired)
+	}
+	wg.Add(1)
+	if fired := e.Evaluate(aliceSearch(), "品牌A", results, now); len(fired) != 1 {
+		t.Fatalf("expected alice's own search to fire, got %d", len(fired))
+	}
+	wg.Wait()
+}
+
+func TestEngineTestIsQueued(t *testing.T) {
+	e, srv, wg := newTestEngine(t)
+	rule := addRule(t, e, srv.URL, Condition{Type: CondNewResults}, "1h")
+
+	wg.Add(1)
+	event, err := e.Test(rule)
+	if err != nil || event.Status != StatusPending {
+		t.Fatalf("expected a pending test event, got %+v err=%v", event, err)
+	}
+	wg.Wait()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		events, _ := e.Store().Events("user:alice", rule.ID)
+		if len(events) == 1 && events[0].Status == StatusDelivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected test event to be delivered, got %+v", events)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 测试告警不占用冷却期。
+	if got, _ := e.Store().Get("user:alice", rule.ID); !got.LastFired.IsZero() {
+		t.Fatalf("test alert must not update LastFired, got %v", got.LastFired)
+	}
+	if _, err := e.Test(Rule{ID: 999}); !errors.Is(err, ErrRuleNotFound) {
+		t.Fatalf("expected ErrRuleNotFound for a deleted rule, got %v", err)
+	}
+}
+
+func TestRuleValidation(t *testing.T) {
+	store, _ := NewStore("")
+	cases := []Rule{
+		{Name: "x", Condition: Condition{Type: CondNewResults}, Webhook: Webhook{URL: "https://h"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: "unknown"}, Webhook: Webhook{URL: "https://h"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: CondVolume, Threshold: 5}, Webhook: Webhook{URL: "https://h"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: CondNegativeShare, Threshold: 120}, Webhook: Webhook{URL: "https://h"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: CondNewResults}, Webhook: Webhook{URL: "ftp://h"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: CondNewResults}, Webhook: Webhook{URL: "https://h", Format: "slack"}},
+		{Name: "x", Query: "q", Condition: Condition{Type: CondNewResults}, Webhook: Webhook{URL: "https://h"}, Cooldown: "soon"},
+	}
+	for _, c := range cases {
+		if _, err := store.Create(c); err == nil {
+			t.Fatalf("expected %+v to be rejected", c)
+		}
+	}
+
+	rule, err := store.Create(Rule{Owner: "user:alice", Name: "x", Query: "q", Condition: Condition{Type: CondNewResults}, Webhook: Webhook{URL: "https://h", Secret: "k"}})
+	if err != nil || rule.Webhook.Format != FormatGeneric {
+		t.Fatalf("unexpected rule %+v err=%v", rule, err)
+	}
+	if rule.Redacted().Webhook.Secret != redactedSecret {
+		t.Fatalf("expected secret redacted")
+	}
+	updated, err := store.Update("user:alice", rule.ID, rule.Redacted())
+	if err != nil || updated.Webhook.Secret != "k" {
+		t.Fatalf("expected redacted secret to keep the original, got %+v err=%v", updated, err)
+	}
+}
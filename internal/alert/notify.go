@@ -0,0 +1,342 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// 通用 Webhook 的请求头。签名为 HMAC-SHA256(secret, 时间戳 + "." + 请求体) 的十六进制，
+// 接收方应校验时间戳在合理范围内以防重放。
+const (
+	HeaderTimestamp = "X-Agentgo-Timestamp"
+	HeaderSignature = "X-Agentgo-Signature"
+)
+
+// maxAlertResults 通知中附带的结果条数上限。
+const maxAlertResults = 10
+
+// Alert 一次告警的通用 JSON 载荷。
+type Alert struct {
+	RuleID    uint64         `json:"rule_id"`
+	RuleName  string         `json:"rule_name"`
+	Type      string         `json:"type"`
+	Query     string         `json:"query"`
+	Message   string         `json:"message"`
+	Value     float64        `json:"value"`
+	Threshold float64        `json:"threshold,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	FiredAt   time.Time      `json:"fired_at"`
+	Results   []model.Result `json:"results"`
+}
+
+// NotifierConfig 投递配置。
+type NotifierConfig struct {
+	// Client 发送请求的 HTTP 客户端，默认超时 5 秒、不走代理，并在连接时检查目标地址。
+	Client *http.Client
+	// AllowTargets 允许投递的内网目标，每项为主机名、IP 或 CIDR。
+	// 默认拒绝回环、链路本地、私有等内网地址，防止借 Webhook 访问内网服务。
+	AllowTargets []string
+	// MaxAttempts 每条通知的最大尝试次数，默认 3。
+	MaxAttempts int
+	// Backoff 首次重试前的等待时间，之后每次翻倍，默认 1 秒。
+	Backoff time.Duration
+	// Workers 并发投递的协程数，默认 2。
+	Workers int
+	// QueueSize 待投递队列长度，队列满时丢弃新通知，默认 256。
+	QueueSize int
+}
+
+// ErrQueueFull 投递队列已满或 Notifier 已关闭，通知未能入队。
+var ErrQueueFull = errors.New("notification queue full")
+
+type delivery struct {
+	hook  Webhook
+	alert Alert
+	done  func(attempts int, err error)
+}
+
+// Notifier 异步投递告警，失败时按指数退避重试。网络错误、429 与 5xx 会重试，其余 4xx 不重试。
+type Notifier struct {
+	cfg    NotifierConfig
+	policy targetPolicy
+	queue  chan delivery
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewNotifier 创建并启动投递协程。
+func NewNotifier(cfg NotifierConfig) *Notifier {
+	policy := newTargetPolicy(cfg.AllowTargets)
+	if cfg.Client == nil {
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		cfg.Client = &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{DialContext: policy.dialContext(dialer), TLSHandshakeTimeout: 5 * time.Second},
+		}
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 256
+	}
+	n := &Notifier{cfg: cfg, policy: policy, queue: make(chan delivery, cfg.QueueSize), stop: make(chan struct{})}
+	for i := 0; i < cfg.Workers; i++ {
+		n.wg.Add(1)
+		go n.worker()
+	}
+	return n
+}
+
+// CheckURL 检查 Webhook 地址是否为 http(s) 且不指向未允许的内网地址，目标不允许时错误包装 ErrForbiddenTarget。
+func (n *Notifier) CheckURL(ctx context.Context, raw string) error {
+	return n.policy.checkURL(ctx, raw)
+}
+
+// Enqueue 把通知加入队列，done 在投递结束后调用；队列已满或已关闭时返回 false。
+func (n *Notifier) Enqueue(hook Webhook, alert Alert, done func(attempts int, err error)) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return false
+	}
+	select {
+	case n.queue <- delivery{hook: hook, alert: alert, done: done}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 停止接收新通知，投递完队列中的通知后返回；关闭后不再等待重试。
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+		close(n.stop)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+	return nil
+}
+
+func (n *Notifier) worker() {
+	defer n.wg.Done()
+	for d := range n.queue {
+		attempts, err := n.Send(context.Background(), d.hook, d.alert)
+		if d.done != nil {
+			d.done(attempts, err)
+		}
+	}
+}
+
+// Send 同步投递一条通知，返回尝试次数与最后一次错误。Notifier 关闭后不再重试。
+func (n *Notifier) Send(ctx context.Context, hook Webhook, alert Alert) (int, error) {
+	body, err := encodePayload(hook.Format, alert)
+	if err != nil {
+		return 0, err
+	}
+	backoff := n.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, hook, body)
+		if err == nil || !retry || attempt >= n.cfg.MaxAttempts {
+			return attempt, err
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return attempt, err
+		case <-n.stop:
+			return attempt, err
+		}
+	}
+}
+
+// post 发送一次请求，返回是否值得重试。
+func (n *Notifier) post(ctx context.Context, hook Webhook, body []byte) (bool, error) {
+	target := hook.URL
+	now := time.Now()
+	if hook.Format == FormatDingTalk && hook.Secret != "" {
+		ts := strconv.FormatInt(now.UnixMilli(), 10)
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + "timestamp=" + ts + "&sign=" + url.QueryEscape(robotSign(ts, hook.Secret))
+	}
+	if hook.Format == FormatFeishu && hook.Secret != "" {
+		var err error
+		if body, err = signFeishu(body, now, hook.Secret); err != nil {
+			return false, err
+		}
+	}
+
+	// 自定义 Client 时连接阶段未必检查地址，发送前先检查一次。
+	if err := n.policy.checkURL(ctx, target); err != nil {
+		return !errors.Is(err, ErrForbiddenTarget), err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "agentgo-alert")
+	if hook.Format == FormatGeneric && hook.Secret != "" {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, "sha256="+Signature(hook.Secret, ts, body))
+	}
+
+	resp, err := n.cfg.Client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrForbiddenTarget), err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	if hook.Format != FormatGeneric {
+		// 机器人接口出错时仍返回 200，需检查返回体中的错误码（多为限流或签名错误）。
+		if err := robotError(data); err != nil {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// Signature 计算通用 Webhook 的签名，供接收方校验。
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// robotSign 钉钉机器人加签：HMAC-SHA256(secret, 时间戳 + "\n" + secret) 的 Base64。
+func robotSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signFeishu 飞书机器人加签：以 时间戳 + "\n" + secret 为密钥对空串做 HMAC-SHA256，写入请求体。
+func signFeishu(body []byte, now time.Time, secret string) ([]byte, error) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(ts+"\n"+secret))
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	payload["timestamp"] = ts
+	payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return json.Marshal(payload)
+}
+
+func robotError(data []byte) error {
+	var reply struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(data, &reply) != nil {
+		return nil
+	}
+	switch {
+	case reply.ErrCode != nil && *reply.ErrCode != 0:
+		return fmt.Errorf("robot error %d: %s", *reply.ErrCode, reply.ErrMsg)
+	case reply.Code != nil && *reply.Code != 0:
+		return fmt.Errorf("robot error %d: %s", *reply.Code, reply.Msg)
+	}
+	return nil
+}
+
+// encodePayload 按 Webhook 格式编码通知。
+func encodePayload(format string, alert Alert) ([]byte, error) {
+	var payload any
+	switch format {
+	case "", FormatGeneric:
+		payload = alert
+	case FormatWeCom:
+		payload = map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": markdownText(alert)},
+		}
+	case FormatDingTalk:
+		payload = map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": alert.RuleName, "text": markdownText(alert)},
+		}
+	case FormatFeishu:
+		payload = map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": plainText(alert)},
+		}
+	default:
+		return nil, errors.New("unknown webhook format " + format)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// robotResults 机器人消息中列出的结果条数。
+const robotResults = 5
+
+func markdownText(alert Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### 告警：%s\n%s\n", alert.RuleName, alert.Message)
+	for i, r := range alert.Results {
+		if i == robotResults {
+			break
+		}
+		fmt.Fprintf(&b, "- [%s](%s)（%s）\n", r.Title, r.URL, r.Source)
+	}
+	return b.String()
+}
+
+func plainText(alert Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "告警：%s\n%s", alert.RuleName, alert.Message)
+	for i, r := range alert.Results {
+		if i == robotResults {
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s %s", r.Title, r.URL)
+	}
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// receiver 记录收到的请求，并按顺序返回预设的状态码。
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	reply    string
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, rc.reply)
+}
+
+func sampleAlert() Alert {
+	return Alert{
+		RuleID:   1,
+		RuleName: "品牌负面",
+		Type:     CondNegativeShare,
+		Query:    "品牌A",
+		Message:  "负面结果占比 60.0%",
+		Value:    60,
+		FiredAt:  time.Now(),
+		Results:  []model.Result{{Title: "吐槽 <品牌A>", URL: "https://example.com/1", Source: "zhihu"}},
+	}
+}
+
+func testNotifier() *Notifier {
+	return NewNotifier(NotifierConfig{Backoff: time.Millisecond, MaxAttempts: 3, AllowTargets: []string{"127.0.0.1"}})
+}
+
+func TestNotifierGenericSignatureAndRetry(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := testNotifier()
+	defer n.Close()
+
+	attempts, err := n.Send(context.Background(), Webhook{URL: srv.URL, Format: FormatGeneric, Secret: "s3cret"}, sampleAlert())
+	if err != nil || attempts != 3 {
+		t.Fatalf("expected delivery on third attempt, got attempts=%d err=%v", attempts, err)
+	}
+	req, body := rc.requests[2], rc.bodies[2]
+	want := "sha256=" + Signature("s3cret", req.Header.Get(HeaderTimestamp), body)
+	if req.Header.Get(HeaderSignature) != want {
+		t.Fatalf("signature mismatch: %s vs %s", req.Header.Get(HeaderSignature), want)
+	}
+	var payload Alert
+	if err := json.Unmarshal(body, &payload); err != nil || payload.RuleName != "品牌负面" || len(payload.Results) != 1 {
+		t.Fatalf("unexpected payload %s err=%v", body, err)
+	}
+	if !strings.Contains(string(body), "<品牌A>") {
+		t.Fatalf("expected HTML characters left unescaped: %s", body)
+	}
+}
+
+func TestNotifierDoesNotRetryClientErrors(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusBadRequest}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := testNotifier()
+	defer n.Close()
+
+	attempts, err := n.Send(context.Background(), Webhook{URL: srv.URL}, sampleAlert())
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected single failed attempt, got attempts=%d err=%v", attempts, err)
+	}
+}
+
+func TestNotifierRobotFormats(t *testing.T) {
+	rc := &receiver{reply: `{"errcode":0,"errmsg":"ok"}`}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := testNotifier()
+	defer n.Close()
+	ctx := context.Background()
+
+	if _, err := n.Send(ctx, Webhook{URL: srv.URL + "/cgi-bin/webhook/send?key=abc", Format: FormatWeCom}, sampleAlert()); err != nil {
+		t.Fatalf("wecom: %v", err)
+	}
+	var wecom struct {
+		MsgType  string `json:"msgtype"`
+		Markdown struct {
+			Content string `json:"content"`
+		} `json:"markdown"`
+	}
+	json.Unmarshal(rc.bodies[0], &wecom)
+	if wecom.MsgType != "markdown" || !strings.Contains(wecom.Markdown.Content, "[吐槽 <品牌A>](https://example.com/1)") {
+		t.Fatalf("unexpected wecom payload: %s", rc.bodies[0])
+	}
+
+	if _, err := n.Send(ctx, Webhook{URL: srv.URL + "/robot/send?access_token=t", Format: FormatDingTalk, Secret: "SEC1"}, sampleAlert()); err != nil {
+		t.Fatalf("dingtalk: %v", err)
+	}
+	q := rc.requests[1].URL.Query()
+	if q.Get("access_token") != "t" || q.Get("sign") != robotSign(q.Get("timestamp"), "SEC1") {
+		t.Fatalf("unexpected dingtalk query: %v", q)
+	}
+
+	rc.reply = `{"code":0,"msg":"success"}`
+	if _, err := n.Send(ctx, Webhook{URL: srv.URL + "/open-apis/bot/v2/hook/x", Format: FormatFeishu, Secret: "fs"}, sampleAlert()); err != nil {
+		t.Fatalf("feishu: %v", err)
+	}
+	var feishu map[string]any
+	json.Unmarshal(rc.bodies[2], &feishu)
+	if feishu["msg_type"] != "text" || feishu["timestamp"] == nil || feishu["sign"] == "" {
+		t.Fatalf("unexpected feishu payload: %s", rc.bodies[2])
+	}
+
+	// 机器人接口返回 200 但带错误码时视为失败并重试。
+	rc.reply = `{"errcode":310000,"errmsg":"sign not match"}`
+	attempts, err := n.Send(ctx, Webhook{URL: srv.URL, Format: FormatDingTalk}, sampleAlert())
+	if err == nil || attempts != 3 {
+		t.Fatalf("expected robot error to be retried, got attempts=%d err=%v", attempts, err)
+	}
+}
+
+func TestNotifierEnqueueCallsDone(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := testNotifier()
+
+	done := make(chan error, 1)
+	if !n.Enqueue(Webhook{URL: srv.URL}, sampleAlert(), func(_ int, err error) { done <- err }) {
+		t.Fatalf("expected enqueue to succeed")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected delivery error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected delivery callback")
+	}
+	n.Close()
+	if n.Enqueue(Webhook{URL: srv.URL}, sampleAlert(), nil) {
+		t.Fatalf("expected enqueue after close to fail")
+	}
+}
+
+func TestNotifierRejectsPrivateTargets(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := NewNotifier(NotifierConfig{Backoff: time.Millisecond, AllowTargets: []string{"10.1.0.0/16", "hooks.internal"}})
+	defer n.Close()
+	ctx := context.Background()
+
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://192.168.1.10/hook",
+		"http://10.2.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://0.1.2.3/hook",
+		"http://[::ffff:0.1.2.3]/hook",
+		"http://100.64.0.1/hook",
+		"http://100.127.255.254/hook",
+	} {
+		if err := n.CheckURL(ctx, raw); !errors.Is(err, ErrForbiddenTarget) {
+			t.Fatalf("expected %s to be rejected, got %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"https://93.184.216.34/hook", "http://100.128.0.1/hook", "http://10.1.2.3/hook", "http://hooks.internal/x"} {
+		if err := n.CheckURL(ctx, raw); err != nil {
+			t.Fatalf("expected %s to be allowed, got %v", raw, err)
+		}
+	}
+	if err := n.CheckURL(ctx, "ftp://93.184.216.34/"); err == nil || errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("expected scheme error, got %v", err)
+	}
+
+	// 投递时同样检查，且不重试、不发出请求。
+	attempts, err := n.Send(ctx, Webhook{URL: srv.URL}, sampleAlert())
+	if !errors.Is(err, ErrForbiddenTarget) || attempts != 1 || len(rc.requests) != 0 {
+		t.Fatalf("expected loopback delivery to be refused, got attempts=%d err=%v requests=%d", attempts, err, len(rc.requests))
+	}
+}
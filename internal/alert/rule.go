@@ -0,0 +1,392 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agentgo/internal/jsonfile"
+)
+
+// 告警条件类型。
+const (
+	// CondNewResults 出现此前未见过的结果，可用 Match 限定标题或摘要包含的关键词。
+	CondNewResults = "new_results"
+	// CondVolume Window 内的结果数达到 Threshold。结果在规则参与求值的各次搜索（包括监控任务的每次运行）间
+	// 按结果键去重累计，按发布时间计入窗口，没有发布时间的按首次出现时间。
+	CondVolume = "volume"
+	// CondNegativeShare 已打分结果中负面占比达到 Threshold（百分比）。
+	CondNegativeShare = "negative_share"
+	// CondEngagement 单条结果的互动量（Metric 指定的指标，为空时为全部指标之和）达到 Threshold。
+	CondEngagement = "engagement"
+)
+
+// Webhook 格式。
+const (
+	FormatGeneric  = "generic"
+	FormatWeCom    = "wecom"
+	FormatDingTalk = "dingtalk"
+	FormatFeishu   = "feishu"
+)
+
+// redactedSecret 接口返回规则时替代密钥，修改规则时传回该值表示沿用原密钥。
+const redactedSecret = "******"
+
+// ErrRuleNotFound 规则不存在或不属于调用方。
+var ErrRuleNotFound = errors.New("alert rule not found")
+
+// Rule 告警规则，对查询词包含 Query 的每次搜索（包括监控任务的定期运行）求值。
+type Rule struct {
+	ID        uint64    `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Condition Condition `json:"condition"`
+	Webhook   Webhook   `json:"webhook"`
+	// Cooldown 两次触发的最小间隔，如 30m，默认 1h，0s 表示不限制。
+	Cooldown  string    `json:"cooldown,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	LastFired time.Time `json:"last_fired,omitzero"`
+}
+
+// Condition 触发条件。
+type Condition struct {
+	Type      string  `json:"type"`
+	Match     string  `json:"match,omitempty"`
+	Window    string  `json:"window,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Metric    string  `json:"metric,omitempty"`
+	// MinResults 负面占比至少基于多少条已打分结果，默认 5。
+	MinResults int `json:"min_results,omitempty"`
+}
+
+// Webhook 通知地址，Secret 用于通用格式的 HMAC 签名及钉钉、飞书机器人的加签。
+type Webhook struct {
+	URL    string `json:"url"`
+	Format string `json:"format,omitempty"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// Redacted 返回隐藏密钥后的副本，用于接口输出。
+func (r Rule) Redacted() Rule {
+	if r.Webhook.Secret != "" {
+		r.Webhook.Secret = redactedSecret
+	}
+	return r
+}
+
+// cooldown 解析冷却时间，未设置时为 1 小时。
+func (r Rule) cooldown() time.Duration {
+	if r.Cooldown == "" {
+		return time.Hour
+	}
+	d, _ := time.ParseDuration(r.Cooldown)
+	return d
+}
+
+// Validate 检查并归一规则字段。
+func (r *Rule) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Query = strings.TrimSpace(r.Query)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	// 规则按查询词子串匹配，空查询会匹配全部搜索，因此必须填写。
+	if r.Query == "" {
+		return errors.New("query is required")
+	}
+	if r.Cooldown != "" {
+		if d, err := time.ParseDuration(r.Cooldown); err != nil || d < 0 {
+			return fmt.Errorf("invalid cooldown %q", r.Cooldown)
+		}
+	}
+	c := &r.Condition
+	switch c.Type {
+	case CondNewResults:
+	case CondVolume:
+		if d, err := time.ParseDuration(c.Window); err != nil || d <= 0 {
+			return fmt.Errorf("volume condition requires a window such as 1h, got %q", c.Window)
+		}
+		if c.Threshold < 1 {
+			return errors.New("volume threshold must be at least 1")
+		}
+	case CondNegativeShare:
+		if c.Threshold <= 0 || c.Threshold > 100 {
+			return errors.New("negative_share threshold must be a percentage in (0, 100]")
+		}
+		if c.MinResults < 0 {
+			return errors.New("min_results must not be negative")
+		}
+	case CondEngagement:
+		if c.Threshold <= 0 {
+			return errors.New("engagement threshold must be positive")
+		}
+	default:
+		return errors.New("condition type must be one of new_results, volume, negative_share, engagement")
+	}
+
+	w := &r.Webhook
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q", w.URL)
+	}
+	switch w.Format {
+	case "":
+		w.Format = FormatGeneric
+	case FormatGeneric, FormatWeCom, FormatDingTalk, FormatFeishu:
+	default:
+		return errors.New("webhook format must be one of generic, wecom, dingtalk, feishu")
+	}
+	return nil
+}
+
+// Event 一次触发及其投递状态。
+type Event struct {
+	ID       uint64    `json:"id"`
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message"`
+	Value    float64   `json:"value"`
+	Status   string    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// 投递状态。
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// maxEvents 每条规则在内存中保留的触发记录数。
+const maxEvents = 20
+
+// Store 告警规则仓库，配置了文件路径时每次修改后整体写回文件；触发记录只保存在内存中。
+type Store struct {
+	path        string
+	rules       map[uint64]Rule
+	events      map[uint64][]Event
+	nextID      uint64
+	nextEventID uint64
+	mu          sync.RWMutex
+}
+
+// NewStore 创建仓库，path 为空时只保存在内存中，否则从 path 加载已有规则。
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, rules: map[uint64]Rule{}, events: map[uint64][]Event{}, nextID: 1, nextEventID: 1}
+	if path == "" {
+		return s, nil
+	}
+	var rules []Rule
+	if _, err := jsonfile.Read(path, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		s.rules[rule.ID] = rule
+		if rule.ID >= s.nextID {
+			s.nextID = rule.ID + 1
+		}
+	}
+	return s, nil
+}
+
+// List 按 ID 顺序返回 owner 的规则。
+func (s *Store) List(owner string) []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Rule, 0)
+	for _, rule := range s.rules {
+		if rule.Owner == owner {
+			out = append(out, rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Get 返回 owner 的某条规则。
+func (s *Store) Get(owner string, id uint64) (Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rule, ok := s.rules[id]
+	if !ok || rule.Owner != owner {
+		return Rule{}, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create 校验并保存新规则。
+func (s *Store) Create(rule Rule) (Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	rule.ID = s.nextID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	rule.LastFired = time.Time{}
+	s.rules[rule.ID] = rule
+	if err := s.persist(); err != nil {
+		delete(s.rules, rule.ID)
+		return Rule{}, err
+	}
+	s.nextID++
+	return rule, nil
+}
+
+// Update 整体替换 owner 的某条规则，密钥为 "******" 时沿用原密钥。
+func (s *Store) Update(owner string, id uint64, rule Rule) (Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.rules[id]
+	if !ok || prev.Owner != owner {
+		return Rule{}, ErrRuleNotFound
+	}
+	if rule.Webhook.Secret == redactedSecret {
+		rule.Webhook.Secret = prev.Webhook.Secret
+	}
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+	rule.ID = id
+	rule.Owner = owner
+	rule.CreatedAt = prev.CreatedAt
+	rule.UpdatedAt = time.Now()
+	rule.LastFired = prev.LastFired
+	s.rules[id] = rule
+	if err := s.persist(); err != nil {
+		s.rules[id] = prev
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+// Delete 删除 owner 的某条规则及其触发记录。
+func (s *Store) Delete(owner string, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.rules[id]
+	if !ok || prev.Owner != owner {
+		return ErrRuleNotFound
+	}
+	delete(s.rules, id)
+	if err := s.persist(); err != nil {
+		s.rules[id] = prev
+		return err
+	}
+	delete(s.events, id)
+	return nil
+}
+
+// Events 从新到旧返回规则最近的触发记录。
+func (s *Store) Events(owner string, id uint64) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rule, ok := s.rules[id]
+	if !ok || rule.Owner != owner {
+		return nil, ErrRuleNotFound
+	}
+	events := s.events[id]
+	out := make([]Event, len(events))
+	for i, e := range events {
+		out[len(events)-1-i] = e
+	}
+	return out, nil
+}
+
+// matching 返回 owner 名下已启用、且查询词包含规则查询词的规则，owner 为空时不匹配任何规则。
+func (s *Store) matching(owner, query string) []Rule {
+	if owner == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Rule
+	for _, rule := range s.rules {
+		if rule.Enabled && rule.Owner == owner && strings.Contains(query, strings.ToLower(rule.Query)) {
+			out = append(out, rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// fire 在冷却期外记录一次触发并返回事件 ID，冷却期内返回 false。
+// 触发时间只保存在内存中，不为每次触发重写规则文件。
+func (s *Store) fire(id uint64, now time.Time, message string, value float64) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule, ok := s.rules[id]
+	if !ok {
+		return 0, false
+	}
+	if !rule.LastFired.IsZero() && now.Sub(rule.LastFired) < rule.cooldown() {
+		return 0, false
+	}
+	rule.LastFired = now
+	s.rules[id] = rule
+	return s.addEvent(id, now, message, value).ID, true
+}
+
+// record 为测试告警登记一条待投递记录，不受冷却期限制也不更新 LastFired。
+func (s *Store) record(id uint64, now time.Time, message string) (Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[id]; !ok {
+		return Event{}, false
+	}
+	return s.addEvent(id, now, message, 0), true
+}
+
+// addEvent 追加一条待投递记录，调用方需持有 s.mu。
+func (s *Store) addEvent(id uint64, now time.Time, message string, value float64) Event {
+	event := Event{ID: s.nextEventID, Time: now, Message: message, Value: value, Status: StatusPending}
+	s.nextEventID++
+	events := append(s.events[id], event)
+	if len(events) > maxEvents {
+		events = append([]Event(nil), events[len(events)-maxEvents:]...)
+	}
+	s.events[id] = events
+	return event
+}
+
+// finishEvent 更新投递结果。
+func (s *Store) finishEvent(ruleID, eventID uint64, attempts int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events[ruleID]
+	for i := range events {
+		if events[i].ID != eventID {
+			continue
+		}
+		events[i].Attempts = attempts
+		events[i].Status = StatusDelivered
+		if err != nil {
+			events[i].Status = StatusFailed
+			events[i].Error = err.Error()
+		}
+		return
+	}
+}
+
+// persist 先写临时文件再重命名。调用方需持有写锁。
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	rules := make([]Rule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return jsonfile.Write(s.path, rules)
+}
@@ -0,0 +1,144 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrForbiddenTarget Webhook 指向回环、链路本地、内网等地址且不在允许列表中。
+var ErrForbiddenTarget = errors.New("webhook target is not allowed")
+
+// deniedNets net.IP 各分类方法未覆盖、同样视为内网的地址段：
+// 0.0.0.0/8（“本网络”，IsUnspecified 只匹配 0.0.0.0）与运营商级 NAT 地址段 100.64.0.0/10（RFC 6598）。
+var deniedNets = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)},
+}
+
+// targetPolicy 限制 Webhook 可以连接的地址，防止借告警接口探测或访问内网服务。
+// 公网地址总是允许；内网地址只有主机名或所在网段出现在允许列表中时才允许。
+type targetPolicy struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+// newTargetPolicy 解析允许列表，每项可以是主机名、IP 或 CIDR。
+func newTargetPolicy(allow []string) targetPolicy {
+	p := targetPolicy{hosts: map[string]bool{}}
+	for _, item := range allow {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, n, err := net.ParseCIDR(item); err == nil {
+			p.nets = append(p.nets, n)
+			continue
+		}
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			p.nets = append(p.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		p.hosts[strings.ToLower(item)] = true
+	}
+	return p
+}
+
+// allowIP 判断能否连接该地址。
+func (p targetPolicy) allowIP(ip net.IP) bool {
+	if isPublicIP(ip) {
+		return true
+	}
+	for _, n := range p.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve 解析主机名并检查全部地址，允许列表中的主机名不做检查、返回 nil。
+func (p targetPolicy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if p.hosts[strings.ToLower(host)] {
+		return nil, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !p.allowIP(ip) {
+			return nil, fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+		}
+		return []net.IP{ip}, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address for host %s", host)
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if !p.allowIP(addr.IP) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", ErrForbiddenTarget, host, addr.IP)
+		}
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// checkURL 检查 Webhook 地址的协议与目标主机。
+func (p targetPolicy) checkURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("webhook url must be an absolute http(s) URL")
+	}
+	_, err = p.resolve(ctx, u.Hostname())
+	return err
+}
+
+// dialContext 在连接时再次解析并检查地址，只连接检查过的 IP，
+// 避免创建规则后 DNS 改指内网或跳转到内网地址。
+func (p targetPolicy) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := p.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if ips == nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range deniedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"agentgo/internal/history"
+)
+
+// 统计粒度。
+const (
+	Day  = "day"
+	Week = "week"
+)
+
+// Source 提供按时间先后排列的历史记录。
+type Source interface {
+	Range(since, until time.Time) []history.Record
+}
+
+// SourceFunc 把普通函数适配为 Source。
+type SourceFunc func(since, until time.Time) []history.Record
+
+// Range 实现 Source。
+func (f SourceFunc) Range(since, until time.Time) []history.Record { return f(since, until) }
+
+// Config 分析参数。
+type Config struct {
+	// Location 按天、按周分桶使用的时区，为空时使用 time.Local。
+	Location *time.Location
+	// MinRisingCount 本期至少出现多少次才计入上升榜，默认 2。
+	MinRisingCount int
+}
+
+// QueryStat 单个查询词的统计，查询词按小写归并，Query 取最近一次的原始写法。
+// AvgTook、MaxTook 只按未命中缓存的搜索计算，CacheHits 为命中缓存、旧缓存或合并请求的次数。
+type QueryStat struct {
+	Query       string        `json:"query"`
+	Count       int           `json:"count"`
+	ZeroResults int           `json:"zero_results"`
+	CacheHits   int           `json:"cache_hits"`
+	AvgTook     time.Duration `json:"avg_took"`
+	MaxTook     time.Duration `json:"max_took"`
+	LastSeen    time.Time     `json:"last_seen"`
+}
+
+// PeriodQueries 某一天或某一周的热门查询。
+type PeriodQueries struct {
+	Start   time.Time   `json:"start"`
+	Total   int         `json:"total"`
+	Queries []QueryStat `json:"queries"`
+}
+
+// Trend 查询词在本期与上期的次数对比。
+type Trend struct {
+	Query    string  `json:"query"`
+	Current  int     `json:"current"`
+	Previous int     `json:"previous"`
+	Growth   float64 `json:"growth"`
+	// New 上期没有出现过。
+	New bool `json:"new"`
+}
+
+// ProviderStat 单个平台的调用情况。
+type ProviderStat struct {
+	Name      string  `json:"name"`
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	// Empty 调用成功但没有返回结果的次数，用于发现覆盖缺口。
+	Empty     int          `json:"empty"`
+	Results   int          `json:"results"`
+	TopErrors []ErrorCount `json:"top_errors,omitempty"`
+}
+
+// ErrorCount 错误信息及出现次数。
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// maxTopErrors 每个平台最多返回的错误种类数。
+const maxTopErrors = 5
+
+// summaryStatus 聚合器在摘要生成失败时追加到平台状态中的伪平台，不计入平台统计。
+const summaryStatus = "summary"
+
+// Analyzer 基于历史记录计算查询分析报表。
+type Analyzer struct {
+	source Source
+	cfg    Config
+}
+
+// New 创建分析器。
+func New(source Source, cfg Config) *Analyzer {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.MinRisingCount <= 0 {
+		cfg.MinRisingCount = 2
+	}
+	return &Analyzer{source: source, cfg: cfg}
+}
+
+// TopQueries 按天或按周分桶，返回 [since, until) 内每个桶次数最多的 limit 个查询，最新的桶在前。
+func (a *Analyzer) TopQueries(granularity string, since, until time.Time, limit int) []PeriodQueries {
+	buckets := map[time.Time][]history.Record{}
+	for _, r := range a.source.Range(since, until) {
+		start := a.periodStart(r.Time, granularity)
+		buckets[start] = append(buckets[start], r)
+	}
+	out := make([]PeriodQueries, 0, len(buckets))
+	for start, records := range buckets {
+		stats := aggregate(records)
+		sortStats(stats, func(s QueryStat) int64 { return int64(s.Count) })
+		out = append(out, PeriodQueries{Start: start, Total: len(records), Queries: truncate(stats, limit)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.After(out[j].Start) })
+	return out
+}
+
+// Rising 比较截至 now 的最近一个周期与再往前一个周期，返回次数增长最多的查询。
+// 周期为滚动窗口（24 小时或 7 天），避免当天、当周未结束时与完整周期比较。
+func (a *Analyzer) Rising(granularity string, now time.Time, limit int) []Trend {
+	span := periodSpan(granularity)
+	boundary := now.Add(-span)
+	current := map[string]int{}
+	previous := map[string]int{}
+	display := map[string]string{}
+	for _, r := range a.source.Range(now.Add(-2*span), now) {
+		key := normalize(r.Query)
+		display[key] = r.Query
+		if r.Time.Before(boundary) {
+			previous[key]++
+		} else {
+			current[key]++
+		}
+	}
+
+	var out []Trend
+	for key, count := range current {
+		prev := previous[key]
+		if count < a.cfg.MinRisingCount || count <= prev {
+			continue
+		}
+		out = append(out, Trend{
+			Query:    display[key],
+			Current:  count,
+			Previous: prev,
+			Growth:   float64(count-prev) / float64(max(prev, 1)),
+			New:      prev == 0,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		di, dj := out[i].Current-out[i].Previous, out[j].Current-out[j].Previous
+		if di != dj {
+			return di > dj
+		}
+		if out[i].Growth != out[j].Growth {
+			return out[i].Growth > out[j].Growth
+		}
+		return out[i].Query < out[j].Query
+	})
+	return truncate(out, limit)
+}
+
+// ZeroResults 返回 [since, until) 内出现过零结果的查询，按零结果次数排序。
+func (a *Analyzer) ZeroResults(since, until time.Time, limit int) []QueryStat {
+	stats := aggregate(a.source.Range(since, until))
+	out := stats[:0]
+	for _, s := range stats {
+		if s.ZeroResults > 0 {
+			out = append(out, s)
+		}
+	}
+	sortStats(out, func(s QueryStat) int64 { return int64(s.ZeroResults) })
+	return truncate(out, limit)
+}
+
+// Slowest 返回 [since, until) 内平均耗时最长的查询。
+func (a *Analyzer) Slowest(since, until time.Time, limit int) []QueryStat {
+	stats := aggregate(a.source.Range(since, until))
+	sortStats(stats, func(s QueryStat) int64 { return int64(s.AvgTook) })
+	return truncate(stats, limit)
+}
+
+// Providers 统计 [since, until) 内各平台的调用次数、错误率与空结果次数，错误率高的在前。
+// 只统计真正请求了平台的记录：命中缓存、旧缓存或合并请求的记录沿用原请求的状态，
+// 单个平台命中平台缓存时也没有发出请求，都不重复计数。
+func (a *Analyzer) Providers(since, until time.Time) []ProviderStat {
+	byName := map[string]*ProviderStat{}
+	errorCounts := map[string]map[string]int{}
+	for _, r := range a.source.Range(since, until) {
+		if !fetched(r) {
+			continue
+		}
+		for _, st := range r.Statuses {
+			if st.Cached || st.Name == summaryStatus {
+				continue
+			}
+			stat, ok := byName[st.Name]
+			if !ok {
+				stat = &ProviderStat{Name: st.Name}
+				byName[st.Name] = stat
+				errorCounts[st.Name] = map[string]int{}
+			}
+			stat.Calls++
+			stat.Results += st.Count
+			switch {
+			case st.Error != "":
+				stat.Errors++
+				errorCounts[st.Name][st.Error]++
+			case st.Count == 0:
+				stat.Empty++
+			}
+		}
+	}
+
+	out := make([]ProviderStat, 0, len(byName))
+	for _, stat := range byName {
+		stat.ErrorRate = float64(stat.Errors) / float64(stat.Calls)
+		for msg, count := range errorCounts[stat.Name] {
+			stat.TopErrors = append(stat.TopErrors, ErrorCount{Error: msg, Count: count})
+		}
+		sort.Slice(stat.TopErrors, func(i, j int) bool {
+			if stat.TopErrors[i].Count != stat.TopErrors[j].Count {
+				return stat.TopErrors[i].Count > stat.TopErrors[j].Count
+			}
+			return stat.TopErrors[i].Error < stat.TopErrors[j].Error
+		})
+		stat.TopErrors = truncate(stat.TopErrors, maxTopErrors)
+		out = append(out, *stat)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ErrorRate != out[j].ErrorRate {
+			return out[i].ErrorRate > out[j].ErrorRate
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// periodStart 返回时间所在的天或周（周一开始）的起点。
+func (a *Analyzer) periodStart(t time.Time, granularity string) time.Time {
+	t = t.In(a.cfg.Location)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, a.cfg.Location)
+	if granularity != Week {
+		return day
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func periodSpan(granularity string) time.Duration {
+	if granularity == Week {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// ParseGranularity 校验并归一统计粒度，空字符串视为 Day。
+func ParseGranularity(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", Day:
+		return Day, true
+	case Week:
+		return Week, true
+	default:
+		return "", false
+	}
+}
+
+// aggregate 按归一后的查询词汇总记录，records 需按时间先后排列。
+func aggregate(records []history.Record) []QueryStat {
+	index := map[string]int{}
+	var stats []QueryStat
+	var totals []time.Duration
+	var timed []int
+	for _, r := range records {
+		key := normalize(r.Query)
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, QueryStat{})
+			totals = append(totals, 0)
+			timed = append(timed, 0)
+		}
+		s := &stats[i]
+		s.Query = r.Query
+		s.Count++
+		if r.Results == 0 {
+			s.ZeroResults++
+		}
+		s.LastSeen = r.Time
+		if !fetched(r) {
+			s.CacheHits++
+			continue
+		}
+		totals[i] += r.Took
+		timed[i]++
+		s.MaxTook = max(s.MaxTook, r.Took)
+	}
+	for i := range stats {
+		if timed[i] > 0 {
+			stats[i].AvgTook = totals[i] / time.Duration(timed[i])
+		}
+	}
+	return stats
+}
+
+// fetched 判断记录是否真正请求了平台，命中缓存的记录耗时接近 0，平台状态也是复制来的。
+func fetched(r history.Record) bool {
+	return !r.Cached && !r.Stale && !r.Coalesced
+}
+
+// sortStats 按 key 降序排列，相同时按次数与查询词排序以保证输出稳定。
+func sortStats(stats []QueryStat, key func(QueryStat) int64) {
+	sort.Slice(stats, func(i, j int) bool {
+		ki, kj := key(stats[i]), key(stats[j])
+		if ki != kj {
+			return ki > kj
+		}
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Query < stats[j].Query
+	})
+}
+
+func normalize(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"agentgo/internal/history"
+)
+
+func fixture(now time.Time) []history.Record {
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+	ok := func(count int) []history.ProviderStatus {
+		return []history.ProviderStatus{{Name: "zhihu", Count: count}, {Name: "wechat", Error: "timeout"}}
+	}
+	return []history.Record{
+		{Query: "咖啡", Results: 5, Took: 100 * time.Millisecond, Time: at(40 * time.Hour), Statuses: ok(5)},
+		{Query: "新品", Results: 0, Took: 900 * time.Millisecond, Time: at(30 * time.Hour), Statuses: ok(0)},
+		{Query: "咖啡", Results: 3, Took: 300 * time.Millisecond, Time: at(20 * time.Hour), Statuses: ok(3)},
+		{Query: "新品", Results: 0, Took: 700 * time.Millisecond, Time: at(10 * time.Hour), Statuses: ok(0)},
+		{Query: "新品 ", Results: 2, Took: 500 * time.Millisecond, Time: at(5 * time.Hour), Statuses: ok(2)},
+		{Query: "奶茶", Results: 4, Took: 200 * time.Millisecond, Time: at(2 * time.Hour), Statuses: []history.ProviderStatus{{Name: "zhihu", Count: 4}, {Name: "wechat", Count: 1}}},
+		{Query: "奶茶", Results: 4, Took: 200 * time.Millisecond, Time: at(time.Hour), Statuses: ok(4)},
+	}
+}
+
+func newAnalyzer(records []history.Record) *Analyzer {
+	return New(SourceFunc(func(since, until time.Time) []history.Record {
+		var out []history.Record
+		for _, r := range records {
+			if (since.IsZero() || !r.Time.Before(since)) && (until.IsZero() || r.Time.Before(until)) {
+				out = append(out, r)
+			}
+		}
+		return out
+	}), Config{Location: time.UTC})
+}
+
+func TestTopQueriesByDay(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	a := newAnalyzer(fixture(now))
+	periods := a.TopQueries(Day, time.Time{}, time.Time{}, 1)
+	if len(periods) != 3 {
+		t.Fatalf("expected 3 days, got %+v", periods)
+	}
+	today := periods[0]
+	if !today.Start.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) || today.Total != 4 {
+		t.Fatalf("unexpected latest bucket %+v", today)
+	}
+	// 新品与奶茶都出现两次，按查询词排序保证结果稳定。
+	if len(today.Queries) != 1 || today.Queries[0].Query != "奶茶" || today.Queries[0].Count != 2 {
+		t.Fatalf("expected 奶茶 to top today on tie, got %+v", today.Queries)
+	}
+
+	weeks := a.TopQueries(Week, time.Time{}, time.Time{}, 0)
+	if len(weeks) != 1 || !weeks[0].Start.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected single week starting Monday, got %+v", weeks)
+	}
+	if weeks[0].Queries[0].Query != "新品 " || weeks[0].Queries[0].Count != 3 {
+		t.Fatalf("expected normalized 新品 to top the week, got %+v", weeks[0].Queries)
+	}
+}
+
+func TestRisingQueries(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	a := newAnalyzer(fixture(now))
+	trends := a.Rising(Day, now, 10)
+	// 最近 24 小时：咖啡1、新品2、奶茶2；之前 24 小时：咖啡1、新品1。
+	if len(trends) != 2 {
+		t.Fatalf("expected 奶茶 and 新品 rising, got %+v", trends)
+	}
+	if trends[0].Query != "奶茶" || !trends[0].New || trends[0].Current != 2 {
+		t.Fatalf("expected new query 奶茶 first, got %+v", trends[0])
+	}
+	if trends[1].Previous != 1 || trends[1].Growth != 1 {
+		t.Fatalf("expected 新品 to double, got %+v", trends[1])
+	}
+}
+
+func TestZeroResultsSlowestAndProviders(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	a := newAnalyzer(fixture(now))
+
+	zero := a.ZeroResults(time.Time{}, time.Time{}, 10)
+	if len(zero) != 1 || zero[0].ZeroResults != 2 || zero[0].Count != 3 {
+		t.Fatalf("expected 新品 with 2 zero-result searches, got %+v", zero)
+	}
+
+	slow := a.Slowest(time.Time{}, time.Time{}, 1)
+	if len(slow) != 1 || slow[0].AvgTook != 700*time.Millisecond || slow[0].MaxTook != 900*time.Millisecond {
+		t.Fatalf("expected 新品 slowest, got %+v", slow)
+	}
+
+	providers := a.Providers(time.Time{}, time.Time{})
+	if len(providers) != 2 || providers[0].Name != "wechat" {
+		t.Fatalf("expected wechat with highest error rate first, got %+v", providers)
+	}
+	wechat, zhihu := providers[0], providers[1]
+	if wechat.Calls != 7 || wechat.Errors != 6 || wechat.TopErrors[0].Error != "timeout" {
+		t.Fatalf("unexpected wechat stats %+v", wechat)
+	}
+	if zhihu.ErrorRate != 0 || zhihu.Empty != 2 || zhihu.Results != 18 {
+		t.Fatalf("unexpected zhihu stats %+v", zhihu)
+	}
+}
+
+func TestCachedRecordsDoNotSkewProvidersOrLatency(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	statuses := []history.ProviderStatus{{Name: "zhihu", Count: 3}, {Name: "wechat", Error: "timeout"}, {Name: "summary", Error: "summarizer down"}}
+	a := newAnalyzer([]history.Record{
+		{Query: "咖啡", Results: 3, Took: 800 * time.Millisecond, Time: now.Add(-3 * time.Hour), Statuses: statuses},
+		{Query: "咖啡", Results: 3, Took: time.Millisecond, Time: now.Add(-2 * time.Hour), Cached: true, Statuses: statuses},
+		{Query: "咖啡", Results: 3, Took: 0, Time: now.Add(-time.Hour), Coalesced: true, Statuses: statuses},
+		{Query: "奶茶", Results: 2, Took: 400 * time.Millisecond, Time: now.Add(-time.Hour), Statuses: []history.ProviderStatus{{Name: "zhihu", Count: 2, Cached: true}, {Name: "wechat", Count: 1}}},
+	})
+
+	providers := a.Providers(time.Time{}, time.Time{})
+	if len(providers) != 2 {
+		t.Fatalf("expected only real providers, got %+v", providers)
+	}
+	wechat, zhihu := providers[0], providers[1]
+	if wechat.Name != "wechat" || wechat.Calls != 2 || wechat.Errors != 1 || wechat.ErrorRate != 0.5 {
+		t.Fatalf("expected cached records to be skipped for wechat, got %+v", wechat)
+	}
+	if zhihu.Calls != 1 || zhihu.Results != 3 {
+		t.Fatalf("expected provider cache hits to be skipped for zhihu, got %+v", zhihu)
+	}
+
+	slow := a.Slowest(time.Time{}, time.Time{}, 10)
+	if slow[0].Query != "咖啡" || slow[0].Count != 3 || slow[0].CacheHits != 2 || slow[0].AvgTook != 800*time.Millisecond {
+		t.Fatalf("expected latency from the uncached search only, got %+v", slow[0])
+	}
+}
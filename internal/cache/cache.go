@@ -1,59 +1,296 @@
 package cache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
 
-type item[V any] struct {
+type item[K comparable, V any] struct {
+	key        K
 	value      V
+	created    time.Time
 	expiration time.Time
+	size       int64
 }
 
-// Cache 是一个简单的基于内存的 TTL 缓存。
+// Options 控制缓存容量与后台清理。
+type Options[K comparable, V any] struct {
+	// MaxEntries 最大条目数，超出时淘汰最久未使用的条目，0 表示不限。
+	MaxEntries int
+	// MaxBytes 近似的最大字节数，需要配合 Sizer 使用，0 表示不限。
+	MaxBytes int64
+	// Sizer 估算单个条目占用的字节数。
+	Sizer func(key K, value V) int64
+	// JanitorInterval 后台清理过期条目的间隔，0 表示不启动清理协程。
+	JanitorInterval time.Duration
+	// StaleTTL 过期后仍可通过 GetStale 读取的宽限期，0 表示过期即失效。
+	StaleTTL time.Duration
+}
+
+// Stats 缓存运行统计。
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	StaleHits   uint64 `json:"stale_hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// Cache 是一个基于内存的 TTL 缓存，可选 LRU 容量限制与后台过期清理。
 type Cache[K comparable, V any] struct {
-	ttl  time.Duration
-	data map[K]item[V]
-	mu   sync.RWMutex
+	ttl   time.Duration
+	opts  Options[K, V]
+	data  map[K]*list.Element
+	order *list.List
+	bytes int64
+	stats Stats
+	mu    sync.Mutex
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-// New 创建缓存实例。
+// New 创建不限容量的缓存实例。
 func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return NewWithOptions[K, V](ttl, Options[K, V]{})
+}
+
+// NewWithOptions 按容量与清理配置创建缓存，启用清理协程时需要调用 Close 释放。
+func NewWithOptions[K comparable, V any](ttl time.Duration, opts Options[K, V]) *Cache[K, V] {
 	if ttl <= 0 {
 		ttl = 2 * time.Minute
 	}
-	return &Cache[K, V]{
-		ttl:  ttl,
-		data: make(map[K]item[V]),
+	c := &Cache[K, V]{
+		ttl:   ttl,
+		opts:  opts,
+		data:  make(map[K]*list.Element),
+		order: list.New(),
 	}
+	if opts.JanitorInterval > 0 {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.janitor(opts.JanitorInterval)
+	}
+	return c
 }
 
 // Get 读取缓存项。
 func (c *Cache[K, V]) Get(key K) (V, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if entry, ok := c.data[key]; ok {
-		if entry.expiration.After(time.Now()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.data[key]; ok {
+		entry := elem.Value.(*item[K, V])
+		now := time.Now()
+		if entry.expiration.After(now) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
 			return entry.value, true
 		}
+		if !c.withinGrace(entry, now) {
+			c.removeElement(elem)
+			c.stats.Expirations++
+		}
 	}
+	c.stats.Misses++
 	var zero V
 	return zero, false
 }
 
+// GetStale 读取缓存项，过期但仍在宽限期内的条目也会返回，并以 stale 标记。
+func (c *Cache[K, V]) GetStale(key K) (value V, stale bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, found := c.data[key]; found {
+		entry := elem.Value.(*item[K, V])
+		now := time.Now()
+		if entry.expiration.After(now) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
+			return entry.value, false, true
+		}
+		if c.withinGrace(entry, now) {
+			c.order.MoveToFront(elem)
+			c.stats.StaleHits++
+			return entry.value, true, true
+		}
+		c.removeElement(elem)
+		c.stats.Expirations++
+	}
+	c.stats.Misses++
+	var zero V
+	return zero, false, false
+}
+
 // Set 写入缓存。
 func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL 以指定有效期写入缓存，ttl 非正数时使用默认有效期。
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var size int64
+	if c.opts.Sizer != nil {
+		size = c.opts.Sizer(key, value)
+	}
+	if elem, ok := c.data[key]; ok {
+		entry := elem.Value.(*item[K, V])
+		c.bytes += size - entry.size
+		entry.value = value
+		entry.size = size
+		entry.created = time.Now()
+		entry.expiration = entry.created.Add(ttl)
+		c.order.MoveToFront(elem)
+	} else {
+		now := time.Now()
+		c.data[key] = c.order.PushFront(&item[K, V]{
+			key:        key,
+			value:      value,
+			created:    now,
+			expiration: now.Add(ttl),
+			size:       size,
+		})
+		c.bytes += size
+	}
+	c.evict()
+}
+
+// Delete 删除缓存项。
+func (c *Cache[K, V]) Delete(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data[key] = item[V]{
-		value:      value,
-		expiration: time.Now().Add(c.ttl),
+	if elem, ok := c.data[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// DeleteFunc 删除所有 match 返回 true 的键，返回删除数量。
+func (c *Cache[K, V]) DeleteFunc(match func(key K) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, elem := range c.data {
+		if match(key) {
+			c.removeElement(elem)
+			removed++
+		}
 	}
+	return removed
+}
+
+// Entries 按最近使用顺序返回条目元信息，已超过宽限期的条目不会返回。
+func (c *Cache[K, V]) Entries() []EntryInfo[K] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	out := make([]EntryInfo[K], 0, len(c.data))
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*item[K, V])
+		if !entry.expiration.After(now) && !c.withinGrace(entry, now) {
+			continue
+		}
+		out = append(out, EntryInfo[K]{Key: entry.key, Created: entry.created, Expiration: entry.expiration, Size: entry.size})
+	}
+	return out
+}
+
+// Len 返回当前条目数，包含尚未清理的过期条目。
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
 }
 
 // Purge 清空缓存。
 func (c *Cache[K, V]) Purge() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data = make(map[K]item[V])
+	c.data = make(map[K]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+}
+
+// Stats 返回命中、未命中、淘汰等统计信息。
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.data)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Close 停止后台清理协程，可重复调用。
+func (c *Cache[K, V]) Close() error {
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+			<-c.done
+		}
+	})
+	return nil
+}
+
+// DeleteExpired 立即清理所有超过宽限期的条目，返回清理数量。
+func (c *Cache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for _, elem := range c.data {
+		entry := elem.Value.(*item[K, V])
+		if !entry.expiration.After(now) && !c.withinGrace(entry, now) {
+			c.removeElement(elem)
+			removed++
+		}
+	}
+	c.stats.Expirations += uint64(removed)
+	return removed
+}
+
+func (c *Cache[K, V]) janitor(interval time.Duration) {
+	defer close(c.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// evict 从最久未使用的一端淘汰，直到满足条目数与字节数限制。
+func (c *Cache[K, V]) evict() {
+	for c.order.Len() > 0 {
+		overEntries := c.opts.MaxEntries > 0 && len(c.data) > c.opts.MaxEntries
+		overBytes := c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes
+		if !overEntries && !overBytes {
+			return
+		}
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache[K, V]) withinGrace(entry *item[K, V], now time.Time) bool {
+	return c.opts.StaleTTL > 0 && entry.expiration.Add(c.opts.StaleTTL).After(now)
+}
+
+func (c *Cache[K, V]) removeElement(elem *list.Element) {
+	entry := elem.Value.(*item[K, V])
+	c.order.Remove(elem)
+	delete(c.data, entry.key)
+	c.bytes -= entry.size
 }
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -16,3 +17,108 @@ func TestCacheSetAndGet(t *testing.T) {
 		t.Fatalf("expected cache item expired")
 	}
 }
+
+func TestCacheLRUEviction(t *testing.T) {
+	c := NewWithOptions[string, int](time.Minute, Options[string, int]{MaxEntries: 2})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected a present")
+	}
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected least recently used key b evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected recently used key a kept")
+	}
+	stats := c.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	c := NewWithOptions[string, string](time.Minute, Options[string, string]{
+		MaxBytes: 10,
+		Sizer:    func(_ string, v string) int64 { return int64(len(v)) },
+	})
+	c.Set("a", "12345")
+	c.Set("b", "12345")
+	c.Set("c", "123")
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("expected oldest entry evicted by size")
+	}
+	if stats := c.Stats(); stats.Bytes != 8 {
+		t.Fatalf("expected 8 bytes, got %d", stats.Bytes)
+	}
+	c.Set("b", "1")
+	if stats := c.Stats(); stats.Bytes != 4 {
+		t.Fatalf("expected size updated on overwrite, got %d", stats.Bytes)
+	}
+}
+
+func TestCacheJanitor(t *testing.T) {
+	c := NewWithOptions[string, int](20*time.Millisecond, Options[string, int]{JanitorInterval: 10 * time.Millisecond})
+	defer c.Close()
+	c.Set("key", 1)
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected janitor to remove expired entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if c.Stats().Expirations != 1 {
+		t.Fatalf("expected one expiration, got %+v", c.Stats())
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("second close should be a no-op: %v", err)
+	}
+}
+
+func TestCacheGetStale(t *testing.T) {
+	c := NewWithOptions[string, int](20*time.Millisecond, Options[string, int]{StaleTTL: time.Second})
+	c.Set("key", 1)
+	if _, stale, ok := c.GetStale("key"); !ok || stale {
+		t.Fatalf("expected fresh hit")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("key"); ok {
+		t.Fatalf("Get should not return stale entries")
+	}
+	if v, stale, ok := c.GetStale("key"); !ok || !stale || v != 1 {
+		t.Fatalf("expected stale hit, got %v/%v/%v", v, stale, ok)
+	}
+	if c.DeleteExpired() != 0 {
+		t.Fatalf("entries within grace period should not be removed")
+	}
+	if c.Stats().StaleHits != 1 {
+		t.Fatalf("expected one stale hit, got %+v", c.Stats())
+	}
+}
+
+func TestCacheEntriesAndDeleteFunc(t *testing.T) {
+	c := NewWithOptions[string, int](time.Minute, Options[string, int]{
+		Sizer: func(string, int) int64 { return 8 },
+	})
+	c.Set("q1|zhihu", 1)
+	c.Set("q1|wechat", 2)
+	c.Set("q2|zhihu", 3)
+
+	entries := c.Entries()
+	if len(entries) != 3 || entries[0].Key != "q2|zhihu" {
+		t.Fatalf("expected entries in recency order, got %+v", entries)
+	}
+	if entries[0].Size != 8 || entries[0].Created.IsZero() || !entries[0].Expiration.After(entries[0].Created) {
+		t.Fatalf("unexpected entry metadata %+v", entries[0])
+	}
+
+	removed := c.DeleteFunc(func(key string) bool { return strings.HasPrefix(key, "q1|") })
+	if removed != 2 || c.Len() != 1 {
+		t.Fatalf("expected 2 removed and 1 left, got %d and %d", removed, c.Len())
+	}
+	if c.Stats().Bytes != 8 {
+		t.Fatalf("expected bytes to drop with removed entries, got %d", c.Stats().Bytes)
+	}
+}
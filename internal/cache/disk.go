@@ -0,0 +1,502 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	diskLogName = "cache.log"
+	// recordHeaderSize 每条记录的头部：4 字节长度 + 4 字节 CRC32。
+	recordHeaderSize = 8
+	maxRecordSize    = 64 << 20
+)
+
+// diskRecord 追加日志中的一条记录，Deleted 为 true 时表示删除。
+type diskRecord struct {
+	Key        string          `json:"k"`
+	Created    int64           `json:"c,omitempty"`
+	Expiration int64           `json:"e,omitempty"`
+	Deleted    bool            `json:"d,omitempty"`
+	Value      json.RawMessage `json:"v,omitempty"`
+}
+
+type diskEntry struct {
+	offset     int64
+	length     int64
+	created    time.Time
+	expiration time.Time
+}
+
+// DiskOptions 磁盘缓存配置。
+type DiskOptions struct {
+	// StaleTTL 过期后仍可通过 GetStale 读取的宽限期。
+	StaleTTL time.Duration
+	// CompactMinBytes 可回收空间超过该值且多于有效数据时触发压缩，默认 4MB。
+	CompactMinBytes int64
+	// SyncWrites 每次写入后调用 fsync，牺牲吞吐换取掉电安全。
+	SyncWrites bool
+	// JanitorInterval 后台清理过期条目并尝试压缩的间隔，0 表示不启动。
+	JanitorInterval time.Duration
+}
+
+// Disk 基于追加日志的磁盘缓存，值以 JSON 编码，进程重启后仍然有效。
+//
+// 启动时顺序回放日志，遇到截断或校验失败的记录即停止并丢弃其后的内容，
+// 因此异常退出只会损失最后几次写入。
+type Disk[V any] struct {
+	path      string
+	ttl       time.Duration
+	opts      DiskOptions
+	file      *os.File
+	size      int64
+	live      int64
+	recovered int64
+	index     map[string]diskEntry
+	stats     Stats
+	mu        sync.Mutex
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// OpenDisk 打开或创建 dir 下的磁盘缓存。
+func OpenDisk[V any](dir string, ttl time.Duration, opts DiskOptions) (*Disk[V], error) {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	if opts.CompactMinBytes <= 0 {
+		opts.CompactMinBytes = 4 << 20
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &Disk[V]{
+		path:  filepath.Join(dir, diskLogName),
+		ttl:   ttl,
+		opts:  opts,
+		index: map[string]diskEntry{},
+	}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	if opts.JanitorInterval > 0 {
+		d.stop = make(chan struct{})
+		d.done = make(chan struct{})
+		go d.janitor(opts.JanitorInterval)
+	}
+	return d, nil
+}
+
+// RecoveredBytes 返回启动时因日志损坏而丢弃的字节数。
+func (d *Disk[V]) RecoveredBytes() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.recovered
+}
+
+// Get 读取未过期的缓存项。
+func (d *Disk[V]) Get(key string) (V, bool) {
+	value, _, stale, ok := d.lookup(key, false)
+	return value, ok && !stale
+}
+
+// GetStale 读取缓存项，宽限期内的过期条目以 stale 标记返回。
+func (d *Disk[V]) GetStale(key string) (V, bool, bool) {
+	value, _, stale, ok := d.lookup(key, true)
+	return value, stale, ok
+}
+
+// Set 写入缓存。
+func (d *Disk[V]) Set(key string, value V) {
+	d.SetWithTTL(key, value, d.ttl)
+}
+
+// SetWithTTL 以指定有效期写入缓存，写盘失败时该条目不会被缓存。
+func (d *Disk[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = d.ttl
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.append(diskRecord{Key: key, Created: now.UnixNano(), Expiration: now.Add(ttl).UnixNano(), Value: raw}); err != nil {
+		d.dropLocked(key)
+		return
+	}
+	d.maybeCompact()
+}
+
+// Delete 删除缓存项，并写入删除标记以便重启后保持一致。
+func (d *Disk[V]) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.index[key]; !ok {
+		return
+	}
+	d.dropLocked(key)
+	_ = d.append(diskRecord{Key: key, Deleted: true})
+}
+
+// DeleteFunc 删除所有 match 返回 true 的键，返回删除数量。
+func (d *Disk[V]) DeleteFunc(match func(key string) bool) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	removed := 0
+	for key := range d.index {
+		if match(key) {
+			d.dropLocked(key)
+			_ = d.append(diskRecord{Key: key, Deleted: true})
+			removed++
+		}
+	}
+	d.maybeCompact()
+	return removed
+}
+
+// Entries 按写入顺序返回条目元信息，Size 为日志记录的字节数。
+func (d *Disk[V]) Entries() []EntryInfo[string] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	out := make([]EntryInfo[string], 0, len(d.index))
+	for key, entry := range d.index {
+		if !d.usable(entry, now) {
+			continue
+		}
+		out = append(out, EntryInfo[string]{Key: key, Created: entry.created, Expiration: entry.expiration, Size: entry.length})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return d.index[out[i].Key].offset < d.index[out[j].Key].offset
+	})
+	return out
+}
+
+// Purge 清空缓存并截断日志文件。
+func (d *Disk[V]) Purge() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.file.Truncate(0); err != nil {
+		return
+	}
+	d.index = map[string]diskEntry{}
+	d.size = 0
+	d.live = 0
+}
+
+// Stats 返回统计信息，Bytes 为日志文件大小。
+func (d *Disk[V]) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats := d.stats
+	stats.Entries = len(d.index)
+	stats.Bytes = d.size
+	return stats
+}
+
+// DeleteExpired 从索引中移除超过宽限期的条目，必要时压缩日志。
+func (d *Disk[V]) DeleteExpired() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for key, entry := range d.index {
+		if !d.usable(entry, now) {
+			d.dropLocked(key)
+			removed++
+		}
+	}
+	d.stats.Expirations += uint64(removed)
+	d.maybeCompact()
+	return removed
+}
+
+// Compact 立即重写日志，只保留仍然有效的条目。
+func (d *Disk[V]) Compact() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.compact()
+}
+
+// Close 停止后台清理并关闭日志文件。
+func (d *Disk[V]) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		if d.stop != nil {
+			close(d.stop)
+			<-d.done
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		err = d.file.Close()
+	})
+	return err
+}
+
+func (d *Disk[V]) janitor(interval time.Duration) {
+	defer close(d.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.DeleteExpired()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// lookup 读取条目并返回其过期时间，allowStale 为 false 时不返回宽限期内的条目。
+func (d *Disk[V]) lookup(key string, allowStale bool) (value V, expiration time.Time, stale bool, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, found := d.index[key]
+	if !found {
+		d.stats.Misses++
+		return value, expiration, false, false
+	}
+	now := time.Now()
+	fresh := entry.expiration.After(now)
+	if !fresh && !(allowStale && d.usable(entry, now)) {
+		if !d.usable(entry, now) {
+			d.dropLocked(key)
+			d.stats.Expirations++
+		}
+		d.stats.Misses++
+		return value, expiration, false, false
+	}
+
+	rec, err := d.readRecord(entry)
+	if err == nil {
+		err = json.Unmarshal(rec.Value, &value)
+	}
+	if err != nil {
+		d.dropLocked(key)
+		d.stats.Misses++
+		return value, expiration, false, false
+	}
+	if fresh {
+		d.stats.Hits++
+	} else {
+		d.stats.StaleHits++
+	}
+	return value, entry.expiration, !fresh, true
+}
+
+// usable 判断条目是否仍在有效期或宽限期内。
+func (d *Disk[V]) usable(entry diskEntry, now time.Time) bool {
+	return entry.expiration.Add(d.opts.StaleTTL).After(now)
+}
+
+func (d *Disk[V]) dropLocked(key string) {
+	if entry, ok := d.index[key]; ok {
+		d.live -= entry.length
+		delete(d.index, key)
+	}
+}
+
+func (d *Disk[V]) append(rec diskRecord) error {
+	buf, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := d.file.WriteAt(buf, d.size); err != nil {
+		return err
+	}
+	if d.opts.SyncWrites {
+		if err := d.file.Sync(); err != nil {
+			return err
+		}
+	}
+	offset := d.size
+	d.size += int64(len(buf))
+	if rec.Deleted {
+		return nil
+	}
+	d.dropLocked(rec.Key)
+	d.index[rec.Key] = diskEntry{
+		offset:     offset,
+		length:     int64(len(buf)),
+		created:    time.Unix(0, rec.Created),
+		expiration: time.Unix(0, rec.Expiration),
+	}
+	d.live += int64(len(buf))
+	return nil
+}
+
+func (d *Disk[V]) readRecord(entry diskEntry) (diskRecord, error) {
+	buf := make([]byte, entry.length)
+	if _, err := d.file.ReadAt(buf, entry.offset); err != nil {
+		return diskRecord{}, err
+	}
+	return decodeRecord(buf[:recordHeaderSize], buf[recordHeaderSize:])
+}
+
+// load 回放日志重建索引，遇到损坏的记录时截断文件。
+func (d *Disk[V]) load() error {
+	file, err := os.OpenFile(d.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	now := time.Now()
+	var offset int64
+	for {
+		header := make([]byte, recordHeaderSize)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > maxRecordSize {
+			break
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			break
+		}
+		rec, err := decodeRecord(header, payload)
+		if err != nil {
+			break
+		}
+
+		recordLen := int64(recordHeaderSize) + int64(length)
+		if rec.Deleted {
+			d.dropLocked(rec.Key)
+		} else {
+			d.dropLocked(rec.Key)
+			entry := diskEntry{
+				offset:     offset,
+				length:     recordLen,
+				created:    time.Unix(0, rec.Created),
+				expiration: time.Unix(0, rec.Expiration),
+			}
+			if d.usable(entry, now) {
+				d.index[rec.Key] = entry
+				d.live += recordLen
+			}
+		}
+		offset += recordLen
+	}
+
+	if offset < info.Size() {
+		if err := file.Truncate(offset); err != nil {
+			file.Close()
+			return fmt.Errorf("truncate corrupted cache log: %w", err)
+		}
+		d.recovered = info.Size() - offset
+	}
+	d.file = file
+	d.size = offset
+	return nil
+}
+
+func (d *Disk[V]) maybeCompact() {
+	garbage := d.size - d.live
+	if garbage >= d.opts.CompactMinBytes && garbage > d.live {
+		_ = d.compact()
+	}
+}
+
+// compact 将有效条目按原顺序写入临时文件后原子替换日志。
+func (d *Disk[V]) compact() error {
+	tmpPath := d.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	keys := make([]string, 0, len(d.index))
+	for key := range d.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return d.index[keys[i]].offset < d.index[keys[j]].offset
+	})
+
+	now := time.Now()
+	index := make(map[string]diskEntry, len(keys))
+	var offset int64
+	for _, key := range keys {
+		entry := d.index[key]
+		if !d.usable(entry, now) {
+			continue
+		}
+		buf := make([]byte, entry.length)
+		if _, err := d.file.ReadAt(buf, entry.offset); err != nil {
+			return cleanup(err)
+		}
+		if _, err := tmp.Write(buf); err != nil {
+			return cleanup(err)
+		}
+		entry.offset = offset
+		index[key] = entry
+		offset += entry.length
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := os.Rename(tmpPath, d.path); err != nil {
+		return cleanup(err)
+	}
+
+	d.file.Close()
+	d.file = tmp
+	d.index = index
+	d.size = offset
+	d.live = offset
+	return nil
+}
+
+func encodeRecord(rec diskRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxRecordSize {
+		return nil, errors.New("cache record too large")
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHeaderSize:], payload)
+	return buf, nil
+}
+
+func decodeRecord(header, payload []byte) (diskRecord, error) {
+	var rec diskRecord
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return rec, errors.New("cache record checksum mismatch")
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
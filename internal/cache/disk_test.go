@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[[]string](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.Set("a", []string{"x", "y"})
+	d.Set("b", []string{"z"})
+	d.Set("a", []string{"updated"})
+	d.Delete("b")
+	if err := d.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	d, err = OpenDisk[[]string](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+	if val, ok := d.Get("a"); !ok || len(val) != 1 || val[0] != "updated" {
+		t.Fatalf("expected latest value for a after reopen, got %v %v", val, ok)
+	}
+	if _, ok := d.Get("b"); ok {
+		t.Fatalf("expected deleted key b to stay deleted")
+	}
+}
+
+func TestDiskExpirationPersists(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[int](dir, time.Minute, DiskOptions{StaleTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.SetWithTTL("short", 1, 20*time.Millisecond)
+	d.Set("long", 2)
+	d.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	d, err = OpenDisk[int](dir, time.Minute, DiskOptions{StaleTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+	if _, ok := d.Get("short"); ok {
+		t.Fatalf("expected short entry expired after reopen")
+	}
+	if val, stale, ok := d.GetStale("short"); !ok || !stale || val != 1 {
+		t.Fatalf("expected stale short entry within grace, got %v %v %v", val, stale, ok)
+	}
+	if val, ok := d.Get("long"); !ok || val != 2 {
+		t.Fatalf("expected long entry, got %v %v", val, ok)
+	}
+}
+
+func TestDiskTruncatesCorruptedTail(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[string](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.Set("a", "first")
+	d.Set("b", "second")
+	d.Close()
+
+	// 模拟写入一半时进程退出：追加半条记录和一段垃圾数据。
+	path := filepath.Join(dir, diskLogName)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 40, 1, 2, 3, 4, '{', '"'})
+	f.Close()
+
+	d, err = OpenDisk[string](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen corrupted log: %v", err)
+	}
+	defer d.Close()
+	if d.RecoveredBytes() != 10 {
+		t.Fatalf("expected 10 corrupted bytes dropped, got %d", d.RecoveredBytes())
+	}
+	if val, ok := d.Get("b"); !ok || val != "second" {
+		t.Fatalf("expected records before corruption kept, got %v %v", val, ok)
+	}
+	d.Set("c", "third")
+	if val, ok := d.Get("c"); !ok || val != "third" {
+		t.Fatalf("expected writes after recovery to work, got %v %v", val, ok)
+	}
+}
+
+func TestDiskCompaction(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[int](dir, time.Minute, DiskOptions{CompactMinBytes: 1})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		d.Set("counter", i)
+	}
+	d.Set("other", 7)
+	if err := d.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	stats := d.Stats()
+	if stats.Entries != 2 {
+		t.Fatalf("expected 2 entries, got %d", stats.Entries)
+	}
+	info, err := os.Stat(filepath.Join(dir, diskLogName))
+	if err != nil {
+		t.Fatalf("stat log: %v", err)
+	}
+	if info.Size() != stats.Bytes {
+		t.Fatalf("expected log size %d to match stats %d", info.Size(), stats.Bytes)
+	}
+	d.Close()
+
+	d, err = OpenDisk[int](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+	if val, ok := d.Get("counter"); !ok || val != 49 {
+		t.Fatalf("expected counter 49 after compaction, got %v %v", val, ok)
+	}
+	if val, ok := d.Get("other"); !ok || val != 7 {
+		t.Fatalf("expected other 7 after compaction, got %v %v", val, ok)
+	}
+}
+
+func TestTieredPromotesDiskHits(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[int](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.Set("warm", 1)
+	d.Close()
+
+	d, err = OpenDisk[int](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	memory := New[string, int](time.Minute)
+	tiered := NewTiered(memory, d)
+	defer tiered.Close()
+
+	if val, ok := tiered.Get("warm"); !ok || val != 1 {
+		t.Fatalf("expected disk hit, got %v %v", val, ok)
+	}
+	if val, ok := memory.Get("warm"); !ok || val != 1 {
+		t.Fatalf("expected disk hit promoted to memory, got %v %v", val, ok)
+	}
+
+	tiered.Set("fresh", 2)
+	tiered.Delete("warm")
+	if _, ok := tiered.Get("warm"); ok {
+		t.Fatalf("expected warm deleted from both tiers")
+	}
+	if val, ok := d.Get("fresh"); !ok || val != 2 {
+		t.Fatalf("expected write-through to disk, got %v %v", val, ok)
+	}
+}
+
+func TestDiskDeleteFuncPersists(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDisk[int](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.Set("a|1", 1)
+	d.Set("a|2", 2)
+	d.Set("b|1", 3)
+	if removed := d.DeleteFunc(func(key string) bool { return key[0] == 'a' }); removed != 2 {
+		t.Fatalf("expected 2 removed, got %d", removed)
+	}
+	d.Close()
+
+	d, err = OpenDisk[int](dir, time.Minute, DiskOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+	entries := d.Entries()
+	if len(entries) != 1 || entries[0].Key != "b|1" || entries[0].Created.IsZero() {
+		t.Fatalf("expected only b|1 with metadata after reopen, got %+v", entries)
+	}
+}
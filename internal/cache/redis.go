@@ -0,0 +1,359 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisHeaderSize 值头部：8 字节过期时间 + 8 字节写入时间，均为 Unix 纳秒。
+const redisHeaderSize = 16
+
+// RedisOptions Redis 缓存配置。
+type RedisOptions[V any] struct {
+	// Addr Redis 地址，如 127.0.0.1:6379。
+	Addr     string
+	Password string
+	DB       int
+	// Prefix 所有键的前缀，多个缓存共用同一个 Redis 时用于区分命名空间。
+	Prefix string
+	// Serializer 值的编码方式，默认 JSONSerializer。
+	Serializer Serializer[V]
+	// Timeout 建连与单条命令的超时，默认 500ms。
+	Timeout time.Duration
+	// PoolSize 空闲连接上限，默认 8。
+	PoolSize int
+	// StaleTTL 过期后仍可通过 GetStale 读取的宽限期，键在 Redis 中的 EX 为 ttl+StaleTTL。
+	StaleTTL time.Duration
+	// Fallback Redis 不可用时使用的本地缓存，写入时也会同步一份，可为空。
+	Fallback Store[string, V]
+	// RetryInterval Redis 出错后直接使用本地缓存的时长，期满后再尝试连接，默认 5s。
+	RetryInterval time.Duration
+}
+
+// Redis 通过 RESP 协议把缓存保存在 Redis 中，使多个副本共享缓存。
+//
+// 值前 16 字节依次保存逻辑过期时间与写入时间，其后为 Serializer 编码的内容，
+// 以便在 Redis 层面的过期之前区分新鲜与宽限期内的条目。Redis 不可达时自动降级到 Fallback。
+type Redis[V any] struct {
+	client    *respClient
+	ttl       time.Duration
+	opts      RedisOptions[V]
+	downUntil time.Time
+	stats     Stats
+	mu        sync.Mutex
+}
+
+// NewRedis 创建 Redis 缓存，连接在首次使用时建立，因此 Redis 暂时不可用不会导致启动失败。
+func NewRedis[V any](ttl time.Duration, opts RedisOptions[V]) (*Redis[V], error) {
+	if opts.Addr == "" {
+		return nil, errors.New("redis address is required")
+	}
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	if opts.Serializer == nil {
+		opts.Serializer = JSONSerializer[V]{}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 500 * time.Millisecond
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 8
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 5 * time.Second
+	}
+	return &Redis[V]{
+		client: newRESPClient(opts.Addr, opts.Password, opts.DB, opts.Timeout, opts.PoolSize),
+		ttl:    ttl,
+		opts:   opts,
+	}, nil
+}
+
+// Available 报告当前是否在使用 Redis，false 表示处于降级状态。
+func (r *Redis[V]) Available() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !time.Now().Before(r.downUntil)
+}
+
+// Get 读取未过期的缓存项。
+func (r *Redis[V]) Get(key string) (V, bool) {
+	value, stale, ok := r.GetStale(key)
+	if stale {
+		var zero V
+		return zero, false
+	}
+	return value, ok
+}
+
+// GetStale 读取缓存项，宽限期内的过期条目以 stale 标记返回。
+func (r *Redis[V]) GetStale(key string) (V, bool, bool) {
+	var zero V
+	if !r.Available() {
+		return r.fallbackGetStale(key)
+	}
+	reply, err := r.client.do("GET", r.opts.Prefix+key)
+	if err != nil {
+		r.fail(err)
+		return r.fallbackGetStale(key)
+	}
+	data, _ := reply.([]byte)
+	info, ok := decodeRedisHeader(key, data)
+	if !ok {
+		r.count(func(s *Stats) { s.Misses++ })
+		return zero, false, false
+	}
+
+	expiration := info.Expiration
+	var value V
+	if err := r.opts.Serializer.Unmarshal(data[redisHeaderSize:], &value); err != nil {
+		r.count(func(s *Stats) { s.Misses++ })
+		return zero, false, false
+	}
+	now := time.Now()
+	switch {
+	case expiration.After(now):
+		r.count(func(s *Stats) { s.Hits++ })
+		return value, false, true
+	case expiration.Add(r.opts.StaleTTL).After(now):
+		r.count(func(s *Stats) { s.StaleHits++ })
+		return value, true, true
+	default:
+		r.count(func(s *Stats) { s.Misses++ })
+		return zero, false, false
+	}
+}
+
+// Set 写入缓存。
+func (r *Redis[V]) Set(key string, value V) {
+	r.SetWithTTL(key, value, r.ttl)
+}
+
+// SetWithTTL 以指定有效期写入缓存。
+func (r *Redis[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = r.ttl
+	}
+	if r.opts.Fallback != nil {
+		r.opts.Fallback.SetWithTTL(key, value, ttl)
+	}
+	if !r.Available() {
+		return
+	}
+	encoded, err := r.opts.Serializer.Marshal(value)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	data := make([]byte, redisHeaderSize+len(encoded))
+	binary.BigEndian.PutUint64(data[:8], uint64(now.Add(ttl).UnixNano()))
+	binary.BigEndian.PutUint64(data[8:16], uint64(now.UnixNano()))
+	copy(data[redisHeaderSize:], encoded)
+
+	seconds := int64((ttl + r.opts.StaleTTL + time.Second - 1) / time.Second)
+	if _, err := r.client.do("SET", r.opts.Prefix+key, string(data), "EX", strconv.FormatInt(seconds, 10)); err != nil {
+		r.fail(err)
+	}
+}
+
+// Delete 删除缓存项。
+func (r *Redis[V]) Delete(key string) {
+	if r.opts.Fallback != nil {
+		r.opts.Fallback.Delete(key)
+	}
+	if !r.Available() {
+		return
+	}
+	if _, err := r.client.do("DEL", r.opts.Prefix+key); err != nil {
+		r.fail(err)
+	}
+}
+
+// Purge 用 SCAN 遍历前缀下的所有键并删除，不影响其他前缀的数据。
+func (r *Redis[V]) Purge() {
+	if r.opts.Fallback != nil {
+		r.opts.Fallback.Purge()
+	}
+	if !r.Available() {
+		return
+	}
+	err := r.scan(func(keys []string) error {
+		_, err := r.client.do(append([]string{"DEL"}, keys...)...)
+		return err
+	})
+	if err != nil {
+		r.fail(err)
+	}
+}
+
+// Entries 遍历前缀下的键并读取元信息，会对每个键执行一次 GET，只适合管理场景。
+// Redis 不可用时返回本地缓存的条目。
+func (r *Redis[V]) Entries() []EntryInfo[string] {
+	if !r.Available() {
+		return r.fallbackEntries()
+	}
+	var out []EntryInfo[string]
+	now := time.Now()
+	err := r.scan(func(keys []string) error {
+		for _, fullKey := range keys {
+			reply, err := r.client.do("GET", fullKey)
+			if err != nil {
+				return err
+			}
+			data, _ := reply.([]byte)
+			info, ok := decodeRedisHeader(strings.TrimPrefix(fullKey, r.opts.Prefix), data)
+			if ok && info.Expiration.Add(r.opts.StaleTTL).After(now) {
+				out = append(out, info)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.fail(err)
+		return r.fallbackEntries()
+	}
+	return out
+}
+
+// DeleteFunc 删除前缀下所有匹配的键，匹配时传入去掉前缀后的键。
+func (r *Redis[V]) DeleteFunc(match func(key string) bool) int {
+	localRemoved := 0
+	if r.opts.Fallback != nil {
+		localRemoved = r.opts.Fallback.DeleteFunc(match)
+	}
+	if !r.Available() {
+		return localRemoved
+	}
+	removed := 0
+	err := r.scan(func(keys []string) error {
+		matched := []string{"DEL"}
+		for _, fullKey := range keys {
+			if match(strings.TrimPrefix(fullKey, r.opts.Prefix)) {
+				matched = append(matched, fullKey)
+			}
+		}
+		if len(matched) == 1 {
+			return nil
+		}
+		reply, err := r.client.do(matched...)
+		if n, ok := reply.(int64); ok {
+			removed += int(n)
+		}
+		return err
+	})
+	if err != nil {
+		r.fail(err)
+	}
+	return removed
+}
+
+// Stats 返回本实例观察到的命中统计，Redis 中的条目数不在统计范围内。
+func (r *Redis[V]) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// Close 关闭连接池与本地缓存。
+func (r *Redis[V]) Close() error {
+	var err error
+	if r.opts.Fallback != nil {
+		err = r.opts.Fallback.Close()
+	}
+	return errors.Join(r.client.close(), err)
+}
+
+// scan 分批遍历前缀下的键。
+func (r *Redis[V]) scan(fn func(keys []string) error) error {
+	cursor := "0"
+	for {
+		reply, err := r.client.do("SCAN", cursor, "MATCH", escapeGlob(r.opts.Prefix)+"*", "COUNT", "100")
+		if err != nil {
+			return err
+		}
+		parts, _ := reply.([]any)
+		if len(parts) != 2 {
+			return errors.New("redis: malformed SCAN reply")
+		}
+		next, _ := parts[0].([]byte)
+		items, _ := parts[1].([]any)
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if key, ok := item.([]byte); ok {
+				keys = append(keys, string(key))
+			}
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func (r *Redis[V]) fallbackEntries() []EntryInfo[string] {
+	if r.opts.Fallback == nil {
+		return nil
+	}
+	return r.opts.Fallback.Entries()
+}
+
+func (r *Redis[V]) fallbackGetStale(key string) (V, bool, bool) {
+	if r.opts.Fallback == nil {
+		var zero V
+		r.count(func(s *Stats) { s.Misses++ })
+		return zero, false, false
+	}
+	return r.opts.Fallback.GetStale(key)
+}
+
+// fail 在网络错误时进入降级状态，Redis 返回的错误回复不影响可用性。
+func (r *Redis[V]) fail(err error) {
+	var replyErr respError
+	if errors.As(err, &replyErr) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = time.Now().Add(r.opts.RetryInterval)
+}
+
+func (r *Redis[V]) count(fn func(*Stats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.stats)
+}
+
+// decodeRedisHeader 解析值头部的过期与写入时间，Size 为整个值的字节数。
+func decodeRedisHeader(key string, data []byte) (EntryInfo[string], bool) {
+	if len(data) < redisHeaderSize {
+		return EntryInfo[string]{}, false
+	}
+	return EntryInfo[string]{
+		Key:        key,
+		Expiration: time.Unix(0, int64(binary.BigEndian.Uint64(data[:8]))),
+		Created:    time.Unix(0, int64(binary.BigEndian.Uint64(data[8:16]))),
+		Size:       int64(len(data)),
+	}, true
+}
+
+// escapeGlob 转义 SCAN MATCH 模式中的特殊字符。
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
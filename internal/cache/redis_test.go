@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"bufio"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 进程内的 RESP 服务，只实现缓存用到的命令。
+type fakeRedis struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	data map[string]fakeValue
+}
+
+type fakeValue struct {
+	value      string
+	expiration time.Time
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeRedis{ln: ln, data: map[string]fakeValue{}}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeRedis) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			b, _ := item.([]byte)
+			args[i] = string(b)
+		}
+		s.exec(w, args)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) exec(w *bufio.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		v, ok := s.data[args[1]]
+		if !ok || (!v.expiration.IsZero() && time.Now().After(v.expiration)) {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		w.WriteString("$" + strconv.Itoa(len(v.value)) + "\r\n" + v.value + "\r\n")
+	case "SET":
+		v := fakeValue{value: args[2]}
+		if len(args) == 5 && strings.EqualFold(args[3], "EX") {
+			seconds, _ := strconv.Atoi(args[4])
+			v.expiration = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		s.data[args[1]] = v
+		w.WriteString("+OK\r\n")
+	case "DEL":
+		removed := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				removed++
+			}
+		}
+		w.WriteString(":" + strconv.Itoa(removed) + "\r\n")
+	case "SCAN":
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for key := range s.data {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+			}
+		}
+		w.WriteString("*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n")
+		for _, key := range keys {
+			w.WriteString("$" + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n")
+		}
+	default:
+		w.WriteString("-ERR unknown command\r\n")
+	}
+}
+
+func TestRedisSharedBetweenReplicas(t *testing.T) {
+	server := newFakeRedis(t)
+	a, err := NewRedis[[]string](time.Minute, RedisOptions[[]string]{Addr: server.addr(), Prefix: "test:"})
+	if err != nil {
+		t.Fatalf("new redis: %v", err)
+	}
+	defer a.Close()
+	b, _ := NewRedis[[]string](time.Minute, RedisOptions[[]string]{Addr: server.addr(), Prefix: "test:", Serializer: JSONSerializer[[]string]{}})
+	defer b.Close()
+
+	a.Set("k", []string{"shared"})
+	if val, ok := b.Get("k"); !ok || len(val) != 1 || val[0] != "shared" {
+		t.Fatalf("expected value written by replica a, got %v %v", val, ok)
+	}
+	b.Delete("k")
+	if _, ok := a.Get("k"); ok {
+		t.Fatalf("expected key deleted through replica b")
+	}
+	if stats := a.Stats(); stats.Hits != 0 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRedisStaleAndPurge(t *testing.T) {
+	server := newFakeRedis(t)
+	r, _ := NewRedis[int](time.Minute, RedisOptions[int]{Addr: server.addr(), Prefix: "p:", StaleTTL: time.Minute, Serializer: GobSerializer[int]{}})
+	defer r.Close()
+
+	r.SetWithTTL("old", 1, 10*time.Millisecond)
+	r.Set("new", 2)
+	server.mu.Lock()
+	server.data["other:keep"] = fakeValue{value: "x"}
+	server.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := r.Get("old"); ok {
+		t.Fatalf("expected old expired")
+	}
+	if val, stale, ok := r.GetStale("old"); !ok || !stale || val != 1 {
+		t.Fatalf("expected stale old within grace, got %v %v %v", val, stale, ok)
+	}
+
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries under prefix, got %+v", entries)
+	}
+	if removed := r.DeleteFunc(func(key string) bool { return key == "old" }); removed != 1 {
+		t.Fatalf("expected old removed, got %d", removed)
+	}
+	if _, _, ok := r.GetStale("old"); ok {
+		t.Fatalf("expected old deleted")
+	}
+
+	r.Purge()
+	if _, ok := r.Get("new"); ok {
+		t.Fatalf("expected purge to remove prefixed keys")
+	}
+	server.mu.Lock()
+	_, kept := server.data["other:keep"]
+	server.mu.Unlock()
+	if !kept {
+		t.Fatalf("expected purge to leave other prefixes untouched")
+	}
+}
+
+func TestRedisFallsBackWhenUnreachable(t *testing.T) {
+	server := newFakeRedis(t)
+	local := New[string, int](time.Minute)
+	r, _ := NewRedis[int](time.Minute, RedisOptions[int]{
+		Addr:          server.addr(),
+		Fallback:      local,
+		Timeout:       100 * time.Millisecond,
+		RetryInterval: time.Hour,
+	})
+	defer r.Close()
+
+	r.Set("k", 1)
+	server.ln.Close()
+	r.client.close()
+	r.client = newRESPClient(server.addr(), "", 0, 100*time.Millisecond, 1)
+
+	if val, ok := r.Get("k"); !ok || val != 1 {
+		t.Fatalf("expected value from local fallback, got %v %v", val, ok)
+	}
+	if r.Available() {
+		t.Fatalf("expected redis marked unavailable")
+	}
+	r.Set("offline", 2)
+	if val, ok := r.Get("offline"); !ok || val != 2 {
+		t.Fatalf("expected writes to land in fallback while degraded, got %v %v", val, ok)
+	}
+}
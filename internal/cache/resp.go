@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// respError Redis 返回的错误回复，连接本身仍然可用。
+type respError string
+
+func (e respError) Error() string { return "redis: " + string(e) }
+
+// respConn 一条 RESP 连接。
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// respClient 极简的 RESP2 客户端，只实现缓存需要的命令，带空闲连接池。
+type respClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	poolSize int
+
+	mu     sync.Mutex
+	idle   []*respConn
+	closed bool
+}
+
+func newRESPClient(addr, password string, db int, timeout time.Duration, poolSize int) *respClient {
+	return &respClient{addr: addr, password: password, db: db, timeout: timeout, poolSize: poolSize}
+}
+
+// do 发送一条命令并读取回复，网络错误时丢弃连接。
+func (c *respClient) do(args ...string) (any, error) {
+	conn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.timeout, args...)
+	var replyErr respError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.conn.Close()
+		return nil, err
+	}
+	c.put(conn)
+	return reply, err
+}
+
+func (c *respClient) get() (*respConn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("redis: client closed")
+	}
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+	return c.dial()
+}
+
+func (c *respClient) put(conn *respConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.poolSize {
+		conn.conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+func (c *respClient) dial() (*respConn, error) {
+	netConn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &respConn{conn: netConn, r: bufio.NewReader(netConn), w: bufio.NewWriter(netConn)}
+	if c.password != "" {
+		if _, err := conn.do(c.timeout, "AUTH", c.password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := conn.do(c.timeout, "SELECT", strconv.Itoa(c.db)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// close 关闭所有空闲连接，之后的调用都会失败。
+func (c *respClient) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, conn := range c.idle {
+		conn.conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *respConn) do(timeout time.Duration, args ...string) (any, error) {
+	if timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := writeCommand(c.w, args); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// writeCommand 以 RESP 数组形式写出命令。
+func writeCommand(w *bufio.Writer, args []string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n", len(arg))
+		w.WriteString(arg)
+		w.WriteString("\r\n")
+	}
+	return w.Flush()
+}
+
+// readReply 读取一条回复：简单字符串返回 string，整数返回 int64，
+// 批量字符串返回 []byte（nil 表示不存在），数组返回 []any。
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, respError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return []byte(nil), nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return []any(nil), nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := readReply(r)
+			var replyErr respError
+			if errors.As(err, &replyErr) {
+				items[i] = replyErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Serializer 负责把缓存值编码为字节，供远程缓存后端使用。
+type Serializer[V any] interface {
+	Marshal(value V) ([]byte, error)
+	Unmarshal(data []byte, value *V) error
+}
+
+// JSONSerializer 使用 encoding/json 编码，便于在 redis-cli 中直接查看。
+type JSONSerializer[V any] struct{}
+
+// Marshal 实现 Serializer。
+func (JSONSerializer[V]) Marshal(value V) ([]byte, error) { return json.Marshal(value) }
+
+// Unmarshal 实现 Serializer。
+func (JSONSerializer[V]) Unmarshal(data []byte, value *V) error { return json.Unmarshal(data, value) }
+
+// GobSerializer 使用 encoding/gob 编码，体积更小但只能被 Go 程序读取。
+type GobSerializer[V any] struct{}
+
+// Marshal 实现 Serializer。
+func (GobSerializer[V]) Marshal(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 实现 Serializer。
+func (GobSerializer[V]) Unmarshal(data []byte, value *V) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
@@ -0,0 +1,34 @@
+package cache
+
+import "time"
+
+// Store 缓存后端的统一接口，内存、磁盘、分层与 Redis 缓存均实现该接口。
+type Store[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	GetStale(key K) (value V, stale bool, ok bool)
+	Set(key K, value V)
+	SetWithTTL(key K, value V, ttl time.Duration)
+	Delete(key K)
+	Purge()
+	Stats() Stats
+	// Entries 返回当前条目（含宽限期内的过期条目）的元信息，供管理接口使用。
+	Entries() []EntryInfo[K]
+	// DeleteFunc 删除所有 match 返回 true 的键，返回删除数量。
+	DeleteFunc(match func(key K) bool) int
+	Close() error
+}
+
+// EntryInfo 缓存条目的元信息。
+type EntryInfo[K comparable] struct {
+	Key        K
+	Created    time.Time
+	Expiration time.Time
+	Size       int64
+}
+
+var (
+	_ Store[string, int] = (*Cache[string, int])(nil)
+	_ Store[string, int] = (*Disk[int])(nil)
+	_ Store[string, int] = (*Tiered[int])(nil)
+	_ Store[string, int] = (*Redis[int])(nil)
+)
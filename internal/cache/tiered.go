@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// Tiered 两级缓存：内存层在前承担热点读取，磁盘层在后保证重启后不丢失。
+// 写入同时落到两层，磁盘层命中的未过期条目会按剩余有效期回填内存层。
+type Tiered[V any] struct {
+	memory *Cache[string, V]
+	disk   *Disk[V]
+}
+
+// NewTiered 组合内存层与磁盘层，Close 时两层都会被关闭。
+func NewTiered[V any](memory *Cache[string, V], disk *Disk[V]) *Tiered[V] {
+	return &Tiered[V]{memory: memory, disk: disk}
+}
+
+// Get 先查内存层，未命中再查磁盘层。
+func (t *Tiered[V]) Get(key string) (V, bool) {
+	if value, ok := t.memory.Get(key); ok {
+		return value, true
+	}
+	value, expiration, stale, ok := t.disk.lookup(key, false)
+	if !ok || stale {
+		var zero V
+		return zero, false
+	}
+	t.memory.SetWithTTL(key, value, time.Until(expiration))
+	return value, true
+}
+
+// GetStale 与 Get 相同，但允许返回磁盘层宽限期内的过期条目。
+func (t *Tiered[V]) GetStale(key string) (V, bool, bool) {
+	if value, ok := t.memory.Get(key); ok {
+		return value, false, true
+	}
+	value, expiration, stale, ok := t.disk.lookup(key, true)
+	if ok && !stale {
+		t.memory.SetWithTTL(key, value, time.Until(expiration))
+	}
+	return value, stale, ok
+}
+
+// Set 写入两层缓存。
+func (t *Tiered[V]) Set(key string, value V) {
+	t.memory.Set(key, value)
+	t.disk.Set(key, value)
+}
+
+// SetWithTTL 以指定有效期写入两层缓存。
+func (t *Tiered[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	t.memory.SetWithTTL(key, value, ttl)
+	t.disk.SetWithTTL(key, value, ttl)
+}
+
+// Delete 从两层缓存中删除。
+func (t *Tiered[V]) Delete(key string) {
+	t.memory.Delete(key)
+	t.disk.Delete(key)
+}
+
+// Purge 清空两层缓存。
+func (t *Tiered[V]) Purge() {
+	t.memory.Purge()
+	t.disk.Purge()
+}
+
+// Entries 返回磁盘层的条目，内存层中的条目总是磁盘层的子集。
+func (t *Tiered[V]) Entries() []EntryInfo[string] {
+	return t.disk.Entries()
+}
+
+// DeleteFunc 从两层缓存中删除匹配的键，返回磁盘层的删除数量。
+func (t *Tiered[V]) DeleteFunc(match func(key string) bool) int {
+	t.memory.DeleteFunc(match)
+	return t.disk.DeleteFunc(match)
+}
+
+// Stats 合并两层统计：命中为两层命中之和，未命中以磁盘层为准，条目与字节数取磁盘层。
+func (t *Tiered[V]) Stats() Stats {
+	mem := t.memory.Stats()
+	disk := t.disk.Stats()
+	return Stats{
+		Hits:        mem.Hits + disk.Hits,
+		StaleHits:   disk.StaleHits,
+		Misses:      disk.Misses,
+		Evictions:   mem.Evictions,
+		Expirations: mem.Expirations + disk.Expirations,
+		Entries:     disk.Entries,
+		Bytes:       disk.Bytes,
+	}
+}
+
+// Close 关闭两层缓存。
+func (t *Tiered[V]) Close() error {
+	return errors.Join(t.memory.Close(), t.disk.Close())
+}
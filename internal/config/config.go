@@ -11,9 +11,49 @@ import (
 type Config struct {
 	Port             string
 	CacheTTL         time.Duration
+	CacheStaleTTL    time.Duration
+	ProviderCacheTTL map[string]time.Duration
+	SummaryCacheTTL  time.Duration
+	CacheMaxEntries  int
+	CacheMaxBytes    int64
+	CacheBackend     string
+	CacheDir         string
+	RedisAddr        string
+	RedisPassword    string
+	RedisDB          int
+	RedisPrefix      string
+	RedisSerializer  string
 	RequestTimeout   time.Duration
 	HistorySize      int
+	HistoryDiskSize  int
+	HistoryDir       string
+	HistoryMaxAge    time.Duration
+	HistoryFileBytes int64
+	HistorySnapshots int
 	DefaultProviders []string
+	SentimentLexicon string
+	SentimentAspects string
+	EntityGazetteer  string
+	SummaryLocale    string
+	TemplateDir      string
+	AdminToken       string
+	APIKeys          map[string]string
+	SavedSearchFile  string
+	MonitorDir       string
+	MonitorTimezone  string
+	MonitorMaxRuns   int
+	AlertRulesFile   string
+	AlertAllowHosts  []string
+	SMTPAddr         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	SMTPPlaintext    bool
+	DigestSchedule   string
+	DigestTo         []string
+	DigestOwner      string
+	DigestPeriod     time.Duration
+	DigestTemplates  string
 }
 
 // Load 从环境变量读取配置。
@@ -21,9 +61,49 @@ func Load() Config {
 	cfg := Config{
 		Port:             getEnv("APP_PORT", "8080"),
 		CacheTTL:         parseDuration("CACHE_TTL", 5*time.Minute),
+		CacheStaleTTL:    parseDuration("CACHE_STALE_TTL", time.Minute),
+		ProviderCacheTTL: parseDurationMap("PROVIDER_CACHE_TTL"),
+		SummaryCacheTTL:  parseDuration("SUMMARY_CACHE_TTL", 0),
+		CacheMaxEntries:  parseInt("CACHE_MAX_ENTRIES", 1000),
+		CacheMaxBytes:    int64(parseInt("CACHE_MAX_BYTES", 64<<20)),
+		CacheBackend:     strings.ToLower(getEnv("CACHE_BACKEND", "memory")),
+		CacheDir:         getEnv("CACHE_DIR", "data/cache"),
+		RedisAddr:        getEnv("REDIS_ADDR", "127.0.0.1:6379"),
+		RedisPassword:    getEnv("REDIS_PASSWORD", ""),
+		RedisDB:          parseInt("REDIS_DB", 0),
+		RedisPrefix:      getEnv("REDIS_PREFIX", "agentgo:"),
+		RedisSerializer:  strings.ToLower(getEnv("REDIS_SERIALIZER", "json")),
 		RequestTimeout:   parseDuration("REQUEST_TIMEOUT", 8*time.Second),
 		HistorySize:      parseInt("HISTORY_SIZE", 50),
+		HistoryDiskSize:  parseInt("HISTORY_DISK_RECORDS", 10000),
+		HistoryDir:       getEnv("HISTORY_DIR", ""),
+		HistoryMaxAge:    parseDuration("HISTORY_MAX_AGE", 0),
+		HistoryFileBytes: int64(parseInt("HISTORY_FILE_BYTES", 8<<20)),
+		HistorySnapshots: parseInt("HISTORY_SNAPSHOTS", 100),
 		DefaultProviders: parseList("PROVIDERS", []string{"mock"}),
+		SentimentLexicon: getEnv("SENTIMENT_LEXICON", ""),
+		SentimentAspects: getEnv("SENTIMENT_ASPECTS", ""),
+		EntityGazetteer:  getEnv("ENTITY_GAZETTEER", ""),
+		SummaryLocale:    getEnv("SUMMARY_LOCALE", "zh-CN"),
+		TemplateDir:      getEnv("SUMMARY_TEMPLATE_DIR", ""),
+		AdminToken:       getEnv("ADMIN_TOKEN", ""),
+		APIKeys:          parseStringMap("API_KEYS"),
+		SavedSearchFile:  getEnv("SAVED_SEARCH_FILE", ""),
+		MonitorDir:       getEnv("MONITOR_DIR", ""),
+		MonitorTimezone:  getEnv("MONITOR_TIMEZONE", ""),
+		MonitorMaxRuns:   parseInt("MONITOR_MAX_RUNS", 50),
+		AlertRulesFile:   getEnv("ALERT_RULES_FILE", ""),
+		AlertAllowHosts:  parseList("ALERT_WEBHOOK_ALLOW", nil),
+		SMTPAddr:         getEnv("SMTP_ADDR", ""),
+		SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:         getEnv("SMTP_FROM", ""),
+		SMTPPlaintext:    getEnv("SMTP_ALLOW_PLAINTEXT", "") == "true",
+		DigestSchedule:   getEnv("DIGEST_SCHEDULE", ""),
+		DigestTo:         parseList("DIGEST_TO", nil),
+		DigestOwner:      getEnv("DIGEST_OWNER", ""),
+		DigestPeriod:     parseDuration("DIGEST_PERIOD", 24*time.Hour),
+		DigestTemplates:  getEnv("DIGEST_TEMPLATE_DIR", ""),
 	}
 	return cfg
 }
@@ -47,6 +127,36 @@ func parseDuration(key string, fallback time.Duration) time.Duration {
 	return d
 }
 
+// parseStringMap 解析形如 “alice=key1,bob=key2” 的配置，缺少名称或值的项会被忽略。
+func parseStringMap(key string) map[string]string {
+	out := map[string]string{}
+	for _, part := range parseList(key, nil) {
+		name, value, ok := strings.Cut(part, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if ok && name != "" && value != "" {
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// parseDurationMap 解析形如 “zhihu=1m,wechat=10m” 的配置，格式错误的项会被忽略。
+func parseDurationMap(key string) map[string]time.Duration {
+	out := map[string]time.Duration{}
+	for _, part := range parseList(key, nil) {
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d <= 0 {
+			continue
+		}
+		out[strings.TrimSpace(name)] = d
+	}
+	return out
+}
+
 func parseInt(key string, fallback int) int {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
@@ -0,0 +1,411 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+	"agentgo/internal/monitor"
+	"agentgo/internal/provider"
+	"agentgo/internal/provider/mock"
+	"agentgo/internal/saved"
+	"agentgo/internal/summary/sentiment"
+	"agentgo/internal/summary/simple"
+)
+
+func sampleReport() Report {
+	now := time.Date(2026, 5, 2, 8, 0, 0, 0, time.UTC)
+	since := now.Add(-24 * time.Hour)
+	resp := aggregator.Response{
+		Query: "品牌A",
+		Results: []model.Result{
+			{Title: "旧闻", URL: "https://example.com/old", Source: "weibo", PublishedAt: since.Add(-time.Hour), Metrics: map[string]int64{"likes": 1000}},
+			{Title: "小讨论", URL: "https://example.com/a", Source: "zhihu", PublishedAt: since.Add(time.Hour), Metrics: map[string]int64{"likes": 3}},
+			{Title: "热帖 <b>品牌A</b>", URL: "https://example.com/b", Source: "weibo", PublishedAt: since.Add(2 * time.Hour), Metrics: map[string]int64{"likes": 50, "comments": 20}},
+		},
+		Summary: &model.Summary{
+			Overview:       "讨论集中在新品发布。",
+			Keywords:       []string{"新品", "价格"},
+			Sentiment:      sentiment.Negative,
+			SentimentStats: model.SentimentStats{Positive: 1, Negative: 2},
+		},
+	}
+	return Report{
+		Title:       "舆情日报",
+		Since:       since,
+		GeneratedAt: now,
+		Sections: []Section{
+			BuildSection("品牌监控", resp, since, 5),
+			{Name: "竞品", Query: "品牌B", Error: "all providers failed"},
+		},
+	}
+}
+
+func TestBuildSectionNewResultsByEngagement(t *testing.T) {
+	section := sampleReport().Sections[0]
+	if section.Total != 3 || section.NewCount != 2 {
+		t.Fatalf("total=%d new=%d, want 3 and 2", section.Total, section.NewCount)
+	}
+	if len(section.Top) != 2 || section.Top[0].URL != "https://example.com/b" {
+		t.Fatalf("top should be ordered by engagement: %+v", section.Top)
+	}
+	if section.Sentiment != sentiment.Negative || section.Stats.Negative != 2 {
+		t.Fatalf("summary not copied: %+v", section)
+	}
+}
+
+func TestGeneratorUsesMonitorRuns(t *testing.T) {
+	agg := aggregator.New(map[string]provider.Provider{"mock": mock.New()}, simple.New(), aggregator.Config{CacheTTL: time.Minute})
+	defer agg.Close()
+	searches, _ := saved.NewStore("")
+	watched, _ := searches.Create(saved.Search{Owner: "user:alice", Name: "监控", Query: "运营"})
+	unwatched, _ := searches.Create(saved.Search{Owner: "user:alice", Name: "无任务", Query: "运营"})
+
+	batches := [][]model.Result{
+		{{Title: "a", URL: "https://a", Metrics: map[string]int64{"likes": 1}}, {Title: "b", URL: "https://b"}},
+		{{Title: "a", URL: "https://a"}, {Title: "b", URL: "https://b"}, {Title: "c", URL: "https://c", Metrics: map[string]int64{"likes": 9}}},
+	}
+	jobs, _ := monitor.NewStore(monitor.StoreConfig{})
+	calls := 0
+	scheduler := monitor.New(jobs, func(context.Context, monitor.Job) ([]model.Result, error) {
+		calls++
+		return batches[calls-1], nil
+	}, monitor.Config{})
+	job, err := scheduler.CreateJob(monitor.Job{Owner: "user:alice", SavedSearchID: watched.ID, Schedule: "@daily", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	ctx := context.Background()
+	scheduler.RunNow(ctx, "user:alice", job.ID)
+	time.Sleep(5 * time.Millisecond)
+	mid := time.Now()
+	scheduler.RunNow(ctx, "user:alice", job.ID)
+
+	gen := NewGenerator(agg, Config{Runs: jobs})
+	report := gen.Build(ctx, "日报", []saved.Search{watched, unwatched}, mid, time.Now())
+	section := report.Sections[0]
+	if section.Estimated || section.NewCount != 1 || len(section.Top) != 1 || section.Top[0].URL != "https://c" {
+		t.Fatalf("expected only the result first seen in the period, got %+v", section)
+	}
+	if !report.Sections[1].Estimated {
+		t.Fatalf("expected a search without a job to fall back to publish times")
+	}
+
+	// 周期覆盖两次运行时，两次运行中的新结果去重后合并。
+	section = gen.Build(ctx, "日报", []saved.Search{watched}, start, time.Now()).Sections[0]
+	if section.NewCount != 3 || section.Top[0].URL != "https://c" {
+		t.Fatalf("expected 3 deduplicated new results ordered by engagement, got %+v", section)
+	}
+}
+
+func TestRenderDefaultTemplates(t *testing.T) {
+	out, err := DefaultTemplates(time.UTC).Render(sampleReport())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if out.Subject != "舆情日报（2026-05-02）" {
+		t.Fatalf("subject = %q", out.Subject)
+	}
+	if strings.Contains(out.HTML, "<b>品牌A</b>") || !strings.Contains(out.HTML, "&lt;b&gt;品牌A&lt;/b&gt;") {
+		t.Fatal("html should escape result titles")
+	}
+	for _, want := range []string{"偏负面", "新品、价格", "搜索失败：all providers failed", "1. 热帖 <b>品牌A</b> [weibo]", "2026-05-01 08:00", "按发布时间估算"} {
+		if !strings.Contains(out.Text, want) {
+			t.Fatalf("text missing %q:\n%s", want, out.Text)
+		}
+	}
+}
+
+func TestLoadTemplatesOverridesText(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, textTemplateFile), []byte("{{.Title}}: {{len .Sections}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadTemplates(dir, time.UTC)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	out, err := tmpl.Render(sampleReport())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if out.Text != "舆情日报: 2" {
+		t.Fatalf("text = %q", out.Text)
+	}
+	if !strings.Contains(out.HTML, "品牌监控") {
+		t.Fatal("missing html override should fall back to the default template")
+	}
+}
+
+func TestBuildMessageMultipart(t *testing.T) {
+	from := &mail.Address{Name: "监控", Address: "bot@example.com"}
+	to := []*mail.Address{{Address: "a@example.com"}}
+	content := Rendered{Subject: "日报\r\nBcc: evil@example.com", HTML: "<p>你好</p>", Text: strings.Repeat("你好", 100)}
+	raw, err := BuildMessage(from, to, content, time.Now())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if msg.Header.Get("Bcc") != "" {
+		t.Fatal("subject must not inject headers")
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "日报  Bcc: evil@example.com" {
+		t.Fatalf("subject = %q, %v", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v", mediaType, err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		encoded, _ := io.ReadAll(part)
+		for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+			if len(line) > 76 {
+				t.Fatalf("base64 line longer than 76: %d", len(line))
+			}
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+		if err != nil {
+			t.Fatalf("decode part: %v", err)
+		}
+		bodies = append(bodies, string(decoded))
+	}
+	if len(bodies) != 2 || bodies[0] != content.Text || bodies[1] != content.HTML {
+		t.Fatalf("unexpected parts: %q", bodies)
+	}
+}
+
+// smtpServer 只实现发信所需命令的测试服务器，tlsConfig 不为空时支持 STARTTLS。
+type smtpServer struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+
+	mu     sync.Mutex
+	tls    bool
+	auth   string
+	from   string
+	rcpts  []string
+	data   string
+	closed chan struct{}
+}
+
+func startSMTP(t *testing.T, tlsConfig *tls.Config) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, tlsConfig: tlsConfig, closed: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.closed)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 test ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-test")
+			s.mu.Lock()
+			secure := s.tls
+			s.mu.Unlock()
+			if s.tlsConfig != nil && !secure {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+			s.mu.Lock()
+			s.tls = true
+			s.mu.Unlock()
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = arg
+			s.mu.Unlock()
+			reply("235 ok")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, arg)
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unsupported")
+		}
+	}
+}
+
+func selfSignedTLS(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+	return server, client
+}
+
+func TestMailerSendsOverSTARTTLS(t *testing.T) {
+	serverTLS, clientTLS := selfSignedTLS(t)
+	srv := startSMTP(t, serverTLS)
+	mailer, err := NewMailer(SMTPConfig{
+		Addr:      srv.ln.Addr().String(),
+		Username:  "bot",
+		Password:  "secret",
+		From:      "监控 <bot@example.com>",
+		TLSConfig: clientTLS,
+		Timeout:   5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("new mailer: %v", err)
+	}
+	content, err := DefaultTemplates(time.UTC).Render(sampleReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Send(context.Background(), []string{"a@example.com", "B <b@example.com>"}, content); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	<-srv.closed
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !srv.tls {
+		t.Fatal("expected STARTTLS to be used")
+	}
+	if !strings.HasPrefix(srv.auth, "PLAIN ") {
+		t.Fatalf("auth = %q", srv.auth)
+	}
+	if srv.from != "FROM:<bot@example.com>" || len(srv.rcpts) != 2 || srv.rcpts[1] != "TO:<b@example.com>" {
+		t.Fatalf("envelope from=%q rcpts=%q", srv.from, srv.rcpts)
+	}
+	if !strings.Contains(srv.data, "multipart/alternative") {
+		t.Fatalf("unexpected data:\n%s", srv.data)
+	}
+}
+
+func TestMailerRequiresSTARTTLS(t *testing.T) {
+	srv := startSMTP(t, nil)
+	mailer, err := NewMailer(SMTPConfig{Addr: srv.ln.Addr().String(), From: "bot@example.com", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mailer.Send(context.Background(), []string{"a@example.com"}, Rendered{Subject: "s", Text: "t", HTML: "h"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestMailerAllowPlaintext(t *testing.T) {
+	srv := startSMTP(t, nil)
+	mailer, err := NewMailer(SMTPConfig{Addr: srv.ln.Addr().String(), From: "bot@example.com", AllowPlaintext: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Send(context.Background(), []string{"a@example.com"}, Rendered{Subject: "s", Text: "t", HTML: "h"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	<-srv.closed
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.tls || srv.auth != "" || srv.data == "" {
+		t.Fatalf("unexpected session tls=%v auth=%q data=%d", srv.tls, srv.auth, len(srv.data))
+	}
+}
@@ -0,0 +1,193 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPConfig 发信配置。
+type SMTPConfig struct {
+	// Addr SMTP 服务地址，如 smtp.example.com:587。
+	Addr     string
+	Username string
+	Password string
+	From     string
+	// AllowPlaintext 为 true 时服务器不支持 STARTTLS 也继续明文发送，默认要求 STARTTLS。
+	// net/smtp 的 PLAIN 认证本身也拒绝在非 localhost 的明文连接上发送密码。
+	AllowPlaintext bool
+	// TLSConfig STARTTLS 使用的 TLS 配置，为空时按 Addr 中的主机名校验证书。
+	TLSConfig *tls.Config
+	// Timeout 建立连接与整个会话的超时时间，默认 30 秒。
+	Timeout time.Duration
+}
+
+// Mailer 通过 SMTP 发送报告邮件。
+type Mailer struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+// NewMailer 校验发件人地址并创建 Mailer。
+func NewMailer(cfg SMTPConfig) (*Mailer, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("smtp address is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", cfg.From, err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Mailer{cfg: cfg, from: from}, nil
+}
+
+// Send 把渲染好的报告以 multipart/alternative（纯文本 + HTML）发送给 to。
+func (m *Mailer) Send(ctx context.Context, to []string, content Rendered) error {
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+	recipients := make([]*mail.Address, len(to))
+	for i, raw := range to {
+		addr, err := mail.ParseAddress(raw)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", raw, err)
+		}
+		recipients[i] = addr
+	}
+	msg, err := BuildMessage(m.from, recipients, content, time.Now())
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(m.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := m.cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: host}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	} else if !m.cfg.AllowPlaintext {
+		return errors.New("smtp server does not support STARTTLS")
+	}
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("rcpt %s: %w", rcpt.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// BuildMessage 构造 MIME 邮件，正文各部分使用 base64 编码，标题按 RFC 2047 编码。
+func BuildMessage(from *mail.Address, to []*mail.Address, content Rendered, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	toList := make([]string, len(to))
+	for i, addr := range to {
+		toList[i] = addr.String()
+	}
+	// 标题中的换行会被当作新的头部，先去掉以防头部注入。
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(content.Subject)
+	var idBytes [12]byte
+	rand.Read(idBytes[:])
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var header bytes.Buffer
+	fmt.Fprintf(&header, "From: %s\r\n", from.String())
+	fmt.Fprintf(&header, "To: %s\r\n", strings.Join(toList, ", "))
+	fmt.Fprintf(&header, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&header, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&header, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(idBytes[:]), domain)
+	fmt.Fprintf(&header, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&header, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", content.Text},
+		{"text/html; charset=UTF-8", content.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(pw, []byte(part.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append(header.Bytes(), buf.Bytes()...), nil
+}
+
+// writeBase64Lines 按 RFC 2045 每行 76 个字符写出 base64。
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+	"agentgo/internal/monitor"
+	"agentgo/internal/saved"
+)
+
+// Report 一份摘要报告，每条保存的搜索对应一节。
+type Report struct {
+	Title       string
+	Since       time.Time
+	GeneratedAt time.Time
+	Sections    []Section
+}
+
+// Section 单条保存的搜索在报告周期内的情况。
+type Section struct {
+	Name      string
+	Query     string
+	Overview  string
+	Keywords  []string
+	Sentiment string
+	Stats     model.SentimentStats
+	// Total 本次搜索的结果数，NewCount 报告周期内的新结果数。
+	Total    int
+	NewCount int
+	// Estimated 为 true 表示保存的搜索没有监控任务，新结果按发布时间估算；
+	// 否则取自周期内监控任务运行中标记为新的结果。
+	Estimated bool
+	// Top 新结果按互动量从高到低取前若干条。
+	Top   []model.Result
+	Error string
+}
+
+// Config 报告生成配置。
+type Config struct {
+	// TopResults 每节列出的新结果条数，默认 5。
+	TopResults int
+	// Runs 监控任务仓库，设置后有监控任务的保存的搜索按运行记录统计新结果。
+	Runs *monitor.Store
+}
+
+// Generator 按保存的搜索调用聚合器生成报告。
+type Generator struct {
+	agg *aggregator.Aggregator
+	cfg Config
+}
+
+// NewGenerator 创建报告生成器。
+func NewGenerator(agg *aggregator.Aggregator, cfg Config) *Generator {
+	if cfg.TopResults <= 0 {
+		cfg.TopResults = 5
+	}
+	return &Generator{agg: agg, cfg: cfg}
+}
+
+// Build 依次运行保存的搜索并汇总为报告，单条搜索失败时记录在对应小节中，不影响其余小节。
+func (g *Generator) Build(ctx context.Context, title string, searches []saved.Search, since, now time.Time) Report {
+	report := Report{Title: title, Since: since, GeneratedAt: now, Sections: make([]Section, 0, len(searches))}
+	for _, search := range searches {
+		opts := search.Options(now)
+		opts.Mode = aggregator.ModeFull
+		resp, err := g.agg.Search(ctx, search.Query, opts)
+		if err != nil {
+			report.Sections = append(report.Sections, Section{Name: search.Name, Query: search.Query, Error: err.Error()})
+			continue
+		}
+		section := BuildSection(search.Name, resp, since, g.cfg.TopResults)
+		if fresh, ok := g.monitored(search, since); ok {
+			section.Estimated = false
+			section.NewCount = len(fresh)
+			section.Top = topResults(fresh, g.cfg.TopResults)
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+// monitored 汇总保存的搜索对应的监控任务在 since 之后各次运行中标记为新的结果，按结果键去重。
+// 没有对应的监控任务时 ok 为 false。
+func (g *Generator) monitored(search saved.Search, since time.Time) (fresh []model.Result, ok bool) {
+	if g.cfg.Runs == nil {
+		return nil, false
+	}
+	seen := map[string]bool{}
+	for _, job := range g.cfg.Runs.Jobs(search.Owner) {
+		if job.SavedSearchID != search.ID {
+			continue
+		}
+		ok = true
+		runs, err := g.cfg.Runs.Runs(search.Owner, job.ID, 0)
+		if err != nil {
+			continue
+		}
+		for _, run := range runs {
+			if run.Started.Before(since) {
+				break
+			}
+			for _, r := range run.Results {
+				if key := r.Key(); r.New && !seen[key] {
+					seen[key] = true
+					fresh = append(fresh, r.Result)
+				}
+			}
+		}
+	}
+	return fresh, ok
+}
+
+// BuildSection 从一次搜索的响应生成报告小节，since 之后发布的结果视为新结果（Estimated 为 true）。
+func BuildSection(name string, resp aggregator.Response, since time.Time, top int) Section {
+	section := Section{Name: name, Query: resp.Query, Total: len(resp.Results), Estimated: true}
+	if s := resp.Summary; s != nil {
+		section.Overview = s.Overview
+		section.Keywords = s.Keywords
+		section.Sentiment = s.Sentiment
+		section.Stats = s.SentimentStats
+	}
+	var fresh []model.Result
+	for _, r := range resp.Results {
+		if !r.PublishedAt.Before(since) {
+			fresh = append(fresh, r)
+		}
+	}
+	section.NewCount = len(fresh)
+	section.Top = topResults(fresh, top)
+	return section
+}
+
+// topResults 按互动量从高到低取前 top 条，会重排 results。
+func topResults(results []model.Result, top int) []model.Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return engagement(results[i]) > engagement(results[j])
+	})
+	if len(results) > top {
+		results = results[:top]
+	}
+	return results
+}
+
+// Subject 邮件标题。
+func (r Report) Subject() string {
+	return fmt.Sprintf("%s（%s）", r.Title, r.GeneratedAt.Format("2006-01-02"))
+}
+
+// engagement 全部指标之和，用于排序。
+func engagement(r model.Result) int64 {
+	var total int64
+	for _, v := range r.Metrics {
+		total += v
+	}
+	return total
+}
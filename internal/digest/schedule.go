@@ -0,0 +1,117 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/monitor"
+	"agentgo/internal/saved"
+)
+
+// ScheduleConfig 定期报告配置。
+type ScheduleConfig struct {
+	// Spec 调度表达式，语法同监控任务，如 "0 8 * * *"。
+	Spec string
+	// Location 解释调度表达式的时区，默认 time.Local。
+	Location *time.Location
+	// Owner 报告包含该调用方保存的全部搜索。
+	Owner string
+	To    []string
+	// Period 报告周期，默认 24 小时。
+	Period time.Duration
+	Title  string
+}
+
+// Scheduled 按计划生成并发送报告。
+type Scheduled struct {
+	gen       *Generator
+	templates *Templates
+	mailer    *Mailer
+	searches  *saved.Store
+	cfg       ScheduleConfig
+	schedule  monitor.Schedule
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// StartSchedule 校验配置并启动定期报告。
+func StartSchedule(gen *Generator, templates *Templates, mailer *Mailer, searches *saved.Store, cfg ScheduleConfig) (*Scheduled, error) {
+	schedule, err := monitor.ParseSchedule(cfg.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Owner == "" || len(cfg.To) == 0 {
+		return nil, errors.New("digest schedule requires an owner and recipients")
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = 24 * time.Hour
+	}
+	if cfg.Title == "" {
+		cfg.Title = "舆情日报"
+	}
+	s := &Scheduled{
+		gen:       gen,
+		templates: templates,
+		mailer:    mailer,
+		searches:  searches,
+		cfg:       cfg,
+		schedule:  schedule,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.loop()
+	return s, nil
+}
+
+// Close 停止定期报告，等待正在发送的报告完成。
+func (s *Scheduled) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+// RunOnce 立即生成并发送一份报告。
+func (s *Scheduled) RunOnce(ctx context.Context) error {
+	now := time.Now()
+	ctx = aggregator.WithRequestInfo(ctx, aggregator.RequestInfo{
+		ID:     fmt.Sprintf("digest-%d", now.UnixNano()),
+		Caller: s.cfg.Owner,
+	})
+	report := s.gen.Build(ctx, s.cfg.Title, s.searches.List(s.cfg.Owner), now.Add(-s.cfg.Period), now)
+	content, err := s.templates.Render(report)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, s.cfg.To, content)
+}
+
+func (s *Scheduled) loop() {
+	defer close(s.done)
+	for {
+		next := s.schedule.Next(time.Now().In(s.cfg.Location))
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		if err := s.RunOnce(ctx); err != nil {
+			log.Printf("digest: %v", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,125 @@
+package digest
+
+import (
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"agentgo/internal/summary/sentiment"
+)
+
+//go:embed templates
+var defaultTemplateFS embed.FS
+
+const (
+	htmlTemplateFile = "digest.html.tmpl"
+	textTemplateFile = "digest.txt.tmpl"
+)
+
+// Rendered 渲染后的邮件内容。
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Templates HTML 与纯文本两套报告模板。模板中可用 time、join、sentiment、inc 函数。
+type Templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+	loc  *time.Location
+}
+
+var sentimentNames = map[string]string{
+	sentiment.Positive: "偏正面",
+	sentiment.Neutral:  "中性",
+	sentiment.Negative: "偏负面",
+}
+
+func (t *Templates) funcs() map[string]any {
+	return map[string]any{
+		"time": func(v time.Time) string {
+			if v.IsZero() {
+				return ""
+			}
+			return v.In(t.loc).Format("2006-01-02 15:04")
+		},
+		"join": strings.Join,
+		"sentiment": func(label string) string {
+			if name, ok := sentimentNames[label]; ok {
+				return name
+			}
+			return label
+		},
+		"inc": func(i int) int { return i + 1 },
+	}
+}
+
+// DefaultTemplates 返回内置模板，时间按 loc 显示，为空时使用 time.Local。
+func DefaultTemplates(loc *time.Location) *Templates {
+	t, err := parseTemplates(defaultTemplateFS, "templates", loc)
+	if err != nil {
+		panic("digest: invalid default template: " + err.Error())
+	}
+	return t
+}
+
+// LoadTemplates 在内置模板基础上加载 dir 下的 digest.html.tmpl 与 digest.txt.tmpl 覆盖，缺失的文件沿用内置模板。
+func LoadTemplates(dir string, loc *time.Location) (*Templates, error) {
+	t := DefaultTemplates(loc)
+	if raw, err := os.ReadFile(filepath.Join(dir, htmlTemplateFile)); err == nil {
+		if t.html, err = htmltemplate.New(htmlTemplateFile).Funcs(t.funcs()).Parse(string(raw)); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if raw, err := os.ReadFile(filepath.Join(dir, textTemplateFile)); err == nil {
+		if t.text, err = texttemplate.New(textTemplateFile).Funcs(t.funcs()).Parse(string(raw)); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplates(fsys fs.FS, dir string, loc *time.Location) (*Templates, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	t := &Templates{loc: loc}
+	rawHTML, err := fs.ReadFile(fsys, dir+"/"+htmlTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+	rawText, err := fs.ReadFile(fsys, dir+"/"+textTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+	if t.html, err = htmltemplate.New(htmlTemplateFile).Funcs(t.funcs()).Parse(string(rawHTML)); err != nil {
+		return nil, err
+	}
+	if t.text, err = texttemplate.New(textTemplateFile).Funcs(t.funcs()).Parse(string(rawText)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render 渲染报告的 HTML 与纯文本版本。
+func (t *Templates) Render(report Report) (Rendered, error) {
+	var html, text strings.Builder
+	if err := t.html.Execute(&html, report); err != nil {
+		return Rendered{}, err
+	}
+	if err := t.text.Execute(&text, report); err != nil {
+		return Rendered{}, err
+	}
+	return Rendered{Subject: report.Subject(), HTML: html.String(), Text: text.String()}, nil
+}
@@ -0,0 +1,222 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 导出格式。
+const (
+	CSV   = "csv"
+	JSONL = "jsonl"
+	XLSX  = "xlsx"
+)
+
+// utf8BOM 写在 CSV 开头，使 Excel 按 UTF-8 识别中文。
+const utf8BOM = "\xef\xbb\xbf"
+
+// Writer 逐行写出表格数据，单元格值支持 string、int、int64、float64、bool、time.Time 与 nil。
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []any) error
+	// Flush 把已写入的行推送到底层 io.Writer，流式导出时定期调用。
+	Flush() error
+	// Close 写出剩余内容，不会关闭底层 io.Writer。
+	Close() error
+}
+
+// ParseFormat 校验并归一导出格式，空字符串视为 CSV。
+func ParseFormat(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", CSV:
+		return CSV, true
+	case JSONL, "ndjson":
+		return JSONL, true
+	case XLSX, "excel":
+		return XLSX, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType 返回格式对应的 MIME 类型。
+func ContentType(format string) string {
+	switch format {
+	case JSONL:
+		return "application/x-ndjson; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// NewWriter 按格式创建 Writer。
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w), nil
+	case JSONL:
+		return newJSONLWriter(w), nil
+	case XLSX:
+		return newXLSXWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w   io.Writer
+	csv *csv.Writer
+	bom bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	if !c.bom {
+		c.bom = true
+		if _, err := io.WriteString(c.w, utf8BOM); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = cellText(col)
+	}
+	return c.csv.Write(record)
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+	}
+	return c.csv.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// jsonlWriter 每行输出一个按列顺序排列键的 JSON 对象。
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns [][]byte
+	buf     bytes.Buffer
+	enc     *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	j := &jsonlWriter{w: bufio.NewWriter(w)}
+	j.enc = json.NewEncoder(&j.buf)
+	j.enc.SetEscapeHTML(false)
+	return j
+}
+
+// encode 与 HTTP 接口一致不转义 HTML 字符，返回的切片在下一次调用前有效。
+func (j *jsonlWriter) encode(v any) ([]byte, error) {
+	j.buf.Reset()
+	if err := j.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(j.buf.Bytes(), []byte("\n")), nil
+}
+
+func (j *jsonlWriter) WriteHeader(columns []string) error {
+	j.columns = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := j.encode(col)
+		if err != nil {
+			return err
+		}
+		j.columns[i] = bytes.Clone(key)
+	}
+	return nil
+}
+
+func (j *jsonlWriter) WriteRow(values []any) error {
+	j.w.WriteByte('{')
+	for i, key := range j.columns {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(key)
+		j.w.WriteByte(':')
+		var v any
+		if i < len(values) {
+			v = values[i]
+		}
+		if t, ok := v.(time.Time); ok {
+			v = formatTime(t)
+		}
+		data, err := j.encode(v)
+		if err != nil {
+			return err
+		}
+		j.w.Write(data)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
+
+// cellText 格式化 CSV 与 XLSX 单元格。标题、摘要、URL 等来自外部平台，
+// 以 =、+、-、@、制表符或回车开头的字符串在表格软件中会被当作公式，前面加 ' 使其按文本显示。
+func cellText(v any) string {
+	text := formatText(v)
+	if _, ok := v.(string); ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// formatText 把单元格值格式化为文本，nil 与零值时间输出为空。
+func formatText(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return formatTime(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
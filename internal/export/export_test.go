@@ -0,0 +1,251 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"agentgo/internal/history"
+	"agentgo/internal/model"
+)
+
+func sampleResults() []model.Result {
+	published := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return []model.Result{
+		{
+			Title:       "运营 <技巧> & 方法",
+			URL:         "https://example.com/a",
+			Source:      "zhihu",
+			PublishedAt: published,
+			Metrics:     map[string]int64{"likes": 12, "views": 300},
+			Extras:      map[string]string{"column": "增长"},
+			Sentiment:   &model.SentimentScore{Score: 0.5, Label: "positive"},
+		},
+		{
+			Title:   "第二条",
+			URL:     "https://example.com/b",
+			Source:  "wechat",
+			Metrics: map[string]int64{"comments": 3},
+			Tags:    []string{"运营", "增长"},
+		},
+	}
+}
+
+func TestResultColumnsStableOrder(t *testing.T) {
+	columns := ResultColumns(sampleResults())
+	tail := strings.Join(columns[len(resultBaseColumns):], ",")
+	if tail != "metrics.comments,metrics.likes,metrics.views,extras.column" {
+		t.Fatalf("unexpected flattened columns: %s", tail)
+	}
+	for i := 0; i < 10; i++ {
+		if got := strings.Join(ResultColumns(sampleResults()), ","); got != strings.Join(columns, ",") {
+			t.Fatalf("column order changed: %s", got)
+		}
+	}
+}
+
+func TestCSVWriterBOMAndFlattening(t *testing.T) {
+	var buf bytes.Buffer
+	writeResults(t, &buf, CSV)
+	if !bytes.HasPrefix(buf.Bytes(), []byte(utf8BOM)) {
+		t.Fatalf("expected UTF-8 BOM")
+	}
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes()[len(utf8BOM):])).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d", len(rows))
+	}
+	row := toMap(rows[0], rows[1])
+	if row["metrics.likes"] != "12" || row["extras.column"] != "增长" || row["published_at"] != "2024-05-01T08:00:00Z" {
+		t.Fatalf("unexpected first row: %v", row)
+	}
+	second := toMap(rows[0], rows[2])
+	if second["metrics.likes"] != "" || second["published_at"] != "" || second["tags"] != "运营,增长" {
+		t.Fatalf("unexpected second row: %v", second)
+	}
+}
+
+func TestJSONLWriterKeepsColumnOrder(t *testing.T) {
+	var buf bytes.Buffer
+	writeResults(t, &buf, JSONL)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], `{"title":"运营 <技巧> & 方法","url":`) {
+		t.Fatalf("unexpected key order: %s", lines[0])
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &row); err != nil {
+		t.Fatalf("decode line: %v", err)
+	}
+	if row["metrics.comments"] != float64(3) || row["metrics.likes"] != nil {
+		t.Fatalf("unexpected second row: %v", row)
+	}
+}
+
+func TestXLSXWriterProducesWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	writeResults(t, &buf, XLSX)
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			R     string `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("parse sheet: %v", err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(sheet.Rows))
+	}
+	first := sheet.Rows[1].Cells
+	if first[0].R != "A2" || first[0].Inline != "运营 <技巧> & 方法" {
+		t.Fatalf("unexpected title cell: %+v", first[0])
+	}
+	var likes bool
+	for _, c := range first {
+		if c.R == "L2" {
+			likes = c.T == "" && c.V == "12"
+		}
+	}
+	if !likes {
+		t.Fatalf("expected numeric metrics.likes cell in L2: %+v", first)
+	}
+}
+
+func TestSpreadsheetCellsNeutralizeFormulas(t *testing.T) {
+	values := []any{`=HYPERLINK("http://evil","点我")`, "+1", "-2+3", "@SUM(A1)", "\tcmd", "\rcmd", "正常标题", int64(-5), -1.5}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, CSV)
+	w.WriteHeader([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i"})
+	w.WriteRow(values)
+	w.Close()
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes()[len(utf8BOM):])).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := []string{`'=HYPERLINK("http://evil","点我")`, "'+1", "'-2+3", "'@SUM(A1)", "'\tcmd", "'\rcmd", "正常标题", "-5", "-1.5"}
+	if !slices.Equal(rows[1], want) {
+		t.Fatalf("csv cells = %q, want %q", rows[1], want)
+	}
+
+	buf.Reset()
+	w, _ = NewWriter(&buf, XLSX)
+	w.WriteRow(values)
+	w.Close()
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(data), "'=HYPERLINK") || strings.Contains(string(data), ">=HYPERLINK") {
+			t.Fatalf("expected xlsx formula text to be neutralized: %s", data)
+		}
+	}
+
+	// JSON Lines 不会被表格软件求值，保持原样。
+	buf.Reset()
+	w, _ = NewWriter(&buf, JSONL)
+	w.WriteHeader([]string{"a"})
+	w.WriteRow(values[:1])
+	w.Close()
+	if !strings.Contains(buf.String(), `"=HYPERLINK`) {
+		t.Fatalf("expected jsonl to keep the original value: %s", buf.String())
+	}
+}
+
+func TestHistoryRow(t *testing.T) {
+	row := HistoryRow(history.Record{
+		ID:        7,
+		Query:     "运营",
+		Providers: []string{"zhihu", "wechat"},
+		Took:      1500 * time.Millisecond,
+		Statuses:  []history.ProviderStatus{{Name: "zhihu", Count: 2}, {Name: "wechat", Error: "timeout"}},
+	})
+	if len(row) != len(HistoryColumns) {
+		t.Fatalf("row has %d values, expected %d", len(row), len(HistoryColumns))
+	}
+	if row[5] != "zhihu,wechat" || row[7] != int64(1500) || row[len(row)-1] != "wechat: timeout" {
+		t.Fatalf("unexpected row: %v", row)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for i, want := range cases {
+		if got := columnName(i); got != want {
+			t.Fatalf("columnName(%d)=%s, want %s", i, got, want)
+		}
+	}
+}
+
+func writeResults(t *testing.T, w io.Writer, format string) {
+	t.Helper()
+	out, err := NewWriter(w, format)
+	if err != nil {
+		t.Fatalf("new writer: %v", err)
+	}
+	results := sampleResults()
+	columns := ResultColumns(results)
+	if err := out.WriteHeader(columns); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	for _, r := range results {
+		if err := out.WriteRow(ResultRow(r, columns)); err != nil {
+			t.Fatalf("write row: %v", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func toMap(header, row []string) map[string]string {
+	out := make(map[string]string, len(header))
+	for i, col := range header {
+		out[col] = row[i]
+	}
+	return out
+}
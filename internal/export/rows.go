@@ -0,0 +1,117 @@
+package export
+
+import (
+	"sort"
+	"strings"
+
+	"agentgo/internal/history"
+	"agentgo/internal/model"
+)
+
+// 展开后的指标与扩展字段列名前缀。
+const (
+	MetricPrefix = "metrics."
+	ExtraPrefix  = "extras."
+)
+
+var resultBaseColumns = []string{
+	"title", "url", "summary", "author", "source", "published_at",
+	"tags", "sentiment_score", "sentiment_label", "entities",
+}
+
+// ResultColumns 返回结果表的列：固定列在前，随后是按字母序排列的 metrics.* 与 extras.* 列，
+// 相同的结果集总是得到相同的列顺序。
+func ResultColumns(results []model.Result) []string {
+	metrics := map[string]struct{}{}
+	extras := map[string]struct{}{}
+	for _, r := range results {
+		for k := range r.Metrics {
+			metrics[k] = struct{}{}
+		}
+		for k := range r.Extras {
+			extras[k] = struct{}{}
+		}
+	}
+	columns := append([]string(nil), resultBaseColumns...)
+	columns = append(columns, prefixedKeys(MetricPrefix, metrics)...)
+	return append(columns, prefixedKeys(ExtraPrefix, extras)...)
+}
+
+// ResultRow 按列顺序展开一条结果，缺失的指标与扩展字段为 nil。
+func ResultRow(r model.Result, columns []string) []any {
+	row := make([]any, len(columns))
+	for i, col := range columns {
+		switch col {
+		case "title":
+			row[i] = r.Title
+		case "url":
+			row[i] = r.URL
+		case "summary":
+			row[i] = r.Summary
+		case "author":
+			row[i] = r.Author
+		case "source":
+			row[i] = r.Source
+		case "published_at":
+			row[i] = r.PublishedAt
+		case "tags":
+			row[i] = strings.Join(r.Tags, ",")
+		case "sentiment_score":
+			if r.Sentiment != nil {
+				row[i] = r.Sentiment.Score
+			}
+		case "sentiment_label":
+			if r.Sentiment != nil {
+				row[i] = r.Sentiment.Label
+			}
+		case "entities":
+			names := make([]string, len(r.Entities))
+			for j, e := range r.Entities {
+				names[j] = e.Name
+			}
+			row[i] = strings.Join(names, ",")
+		default:
+			if key, ok := strings.CutPrefix(col, MetricPrefix); ok {
+				if v, ok := r.Metrics[key]; ok {
+					row[i] = v
+				}
+			} else if key, ok := strings.CutPrefix(col, ExtraPrefix); ok {
+				if v, ok := r.Extras[key]; ok {
+					row[i] = v
+				}
+			}
+		}
+	}
+	return row
+}
+
+// HistoryColumns 历史记录表的固定列，不依赖数据内容，可在读取第一条记录前写出表头。
+var HistoryColumns = []string{
+	"id", "time", "request_id", "caller", "query", "providers", "results", "took_ms",
+	"cached", "stale", "coalesced", "limit", "locale", "mode", "start_time", "end_time", "errors",
+}
+
+// HistoryRow 按 HistoryColumns 展开一条历史记录，平台错误合并为 “平台: 错误” 列表。
+func HistoryRow(r history.Record) []any {
+	var errs []string
+	for _, st := range r.Statuses {
+		if st.Error != "" {
+			errs = append(errs, st.Name+": "+st.Error)
+		}
+	}
+	return []any{
+		int64(r.ID), r.Time, r.RequestID, r.Caller, r.Query, strings.Join(r.Providers, ","),
+		r.Results, r.Took.Milliseconds(), r.Cached, r.Stale, r.Coalesced,
+		r.Options.Limit, r.Options.Locale, r.Options.Mode, r.Options.StartTime, r.Options.EndTime,
+		strings.Join(errs, "; "),
+	}
+}
+
+func prefixedKeys(prefix string, set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, prefix+k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// xlsx 的固定部件，工作表数据单独流式写入 xl/worksheets/sheet1.xml。
+var xlsxParts = []struct {
+	name, body string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="1"><fill><patternFill patternType="none"/></fill></fills><borders count="1"><border/></borders><cellStyleXfs count="1"><xf/></cellStyleXfs><cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs></styleSheet>`},
+}
+
+const (
+	sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetFooter = `</sheetData></worksheet>`
+)
+
+// xlsxWriter 使用内联字符串逐行写出工作表，无需先收集共享字符串表，适合流式导出。
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	err   error
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zip: zip.NewWriter(w)}
+}
+
+// start 写出固定部件并打开工作表，工作表必须是 zip 中最后写入的条目。
+func (x *xlsxWriter) start() error {
+	if x.sheet != nil || x.err != nil {
+		return x.err
+	}
+	for _, part := range xlsxParts {
+		f, err := x.zip.Create(part.name)
+		if err != nil {
+			x.err = err
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			x.err = err
+			return err
+		}
+	}
+	f, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		x.err = err
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	_, x.err = x.sheet.WriteString(sheetHeader)
+	return x.err
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	values := make([]any, len(columns))
+	for i, col := range columns {
+		values[i] = col
+	}
+	return x.writeRow(values, 1)
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	return x.writeRow(values, 0)
+}
+
+func (x *xlsxWriter) writeRow(values []any, style int) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	x.row++
+	ref := strconv.Itoa(x.row)
+	w := x.sheet
+	w.WriteString(`<row r="` + ref + `">`)
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		w.WriteString(`<c r="` + columnName(i) + ref + `"`)
+		if style > 0 {
+			w.WriteString(` s="` + strconv.Itoa(style) + `"`)
+		}
+		switch val := v.(type) {
+		case int, int64, float64:
+			w.WriteString(`><v>` + formatText(val) + `</v></c>`)
+		case bool:
+			b := "0"
+			if val {
+				b = "1"
+			}
+			w.WriteString(` t="b"><v>` + b + `</v></c>`)
+		default:
+			w.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+			writeXMLText(w, cellText(val))
+			w.WriteString(`</t></is></c>`)
+		}
+	}
+	_, x.err = w.WriteString(`</row>`)
+	return x.err
+}
+
+func (x *xlsxWriter) Flush() error {
+	if x.sheet != nil {
+		if err := x.sheet.Flush(); err != nil {
+			return err
+		}
+	}
+	return x.zip.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if _, err := x.sheet.WriteString(sheetFooter); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// columnName 把从 0 开始的列下标转换为 A、B、…、AA 形式的列名。
+func columnName(i int) string {
+	var buf [8]byte
+	n := len(buf)
+	for i++; i > 0; i = (i - 1) / 26 {
+		n--
+		buf[n] = byte('A' + (i-1)%26)
+	}
+	return string(buf[n:])
+}
+
+// writeXMLText 转义 XML 特殊字符，并丢弃 XML 1.0 不允许的控制字符。
+func writeXMLText(w *bufio.Writer, s string) {
+	for _, r := range s {
+		switch {
+		case r == '<':
+			w.WriteString("&lt;")
+		case r == '>':
+			w.WriteString("&gt;")
+		case r == '&':
+			w.WriteString("&amp;")
+		case r == utf8.RuneError:
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r':
+		case r == 0xFFFE || r == 0xFFFF:
+		default:
+			w.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package history
+
+import "time"
+
+// Backend 历史记录的持久化后端，Store 会在不持有自身锁的情况下并发调用 Append 与 Prune。
+type Backend interface {
+	// Append 追加一条记录。
+	Append(record Record) error
+	// Load 按时间先后返回已保存的记录，since 非零时跳过更早的记录。
+	Load(since time.Time) ([]Record, error)
+	// Prune 清理早于 before 的数据，并在保证至少保留 keep 条最新记录的前提下删除更旧的数据。
+	// before 为零值或 keep 不大于 0 时对应条件不生效。
+	Prune(before time.Time, keep int) error
+	Close() error
+}
+
+// RecordLoader 是 Backend 的可选接口，按 ID 读取单条完整记录，用于取回内存中已清除的结果快照。
+type RecordLoader interface {
+	LoadRecord(id uint64) (Record, bool, error)
+}
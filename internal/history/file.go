@@ -0,0 +1,234 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	activeFileName  = "history.jsonl"
+	rotatedPrefix   = "history-"
+	rotatedSuffix   = ".jsonl"
+	rotatedTimeForm = "20060102T150405.000000000"
+)
+
+// FileOptions 文件后端配置。
+type FileOptions struct {
+	// MaxFileBytes 当前文件超过该大小后轮转，默认 8MB。
+	MaxFileBytes int64
+}
+
+// FileBackend 以 JSON Lines 格式把记录追加到 dir/history.jsonl，
+// 文件过大时轮转为 history-<时间>.jsonl，清理时以整个轮转文件为单位删除。
+type FileBackend struct {
+	dir  string
+	opts FileOptions
+	file *os.File
+	size int64
+	mu   sync.Mutex
+}
+
+// NewFileBackend 打开或创建 dir 下的历史记录文件。
+func NewFileBackend(dir string, opts FileOptions) (*FileBackend, error) {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = 8 << 20
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	b := &FileBackend{dir: dir, opts: opts}
+	if err := b.openActive(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Append 追加一条记录，超过大小限制时先轮转。
+func (b *FileBackend) Append(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size > 0 && b.size+int64(len(line)) > b.opts.MaxFileBytes {
+		if err := b.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := b.file.Write(line)
+	b.size += int64(n)
+	return err
+}
+
+// Load 依次读取轮转文件与当前文件，无法解析的行会被跳过。
+func (b *FileBackend) Load(since time.Time) ([]Record, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	files, err := b.files()
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), 4<<20)
+		for scanner.Scan() {
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				continue
+			}
+			if since.IsZero() || !record.Time.Before(since) {
+				records = append(records, record)
+			}
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// LoadRecord 从新到旧扫描记录文件查找 id 对应的记录。
+// 记录以 ID 开头序列化，先按前缀筛选，只解析命中的那一行。
+func (b *FileBackend) LoadRecord(id uint64) (Record, bool, error) {
+	b.mu.Lock()
+	files, err := b.files()
+	b.mu.Unlock()
+	if err != nil {
+		return Record{}, false, err
+	}
+	prefix := []byte(fmt.Sprintf(`{"id":%d,`, id))
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(files[i])
+		if errors.Is(err, fs.ErrNotExist) {
+			// 扫描期间被轮转或清理。
+			continue
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			if !bytes.HasPrefix(line, prefix) {
+				continue
+			}
+			var record Record
+			if err := json.Unmarshal(line, &record); err == nil {
+				return record, true, nil
+			}
+		}
+	}
+	return Record{}, false, nil
+}
+
+// Prune 删除最后写入时间早于 before 的轮转文件，以及比最新 keep 条记录更旧的轮转文件。
+func (b *FileBackend) Prune(before time.Time, keep int) error {
+	// 只在列出文件与统计当前文件时持有锁。轮转后的文件不再被写入，
+	// 统计和删除它们时 Append 可以继续进行。
+	b.mu.Lock()
+	files, err := b.files()
+	newer := 0
+	if err == nil {
+		// files 最后一个是当前文件，从新到旧累计记录数。
+		newer, err = countLines(files[len(files)-1])
+	}
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for i := len(files) - 2; i >= 0; i-- {
+		path := files[i]
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		expired := !before.IsZero() && info.ModTime().Before(before)
+		surplus := keep > 0 && newer >= keep
+		if expired || surplus {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			continue
+		}
+		count, err := countLines(path)
+		if err != nil {
+			return err
+		}
+		newer += count
+	}
+	return nil
+}
+
+// Close 关闭当前文件。
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Close()
+}
+
+func (b *FileBackend) openActive() error {
+	file, err := os.OpenFile(filepath.Join(b.dir, activeFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	b.file = file
+	b.size = info.Size()
+	return nil
+}
+
+func (b *FileBackend) rotate() error {
+	if err := b.file.Close(); err != nil {
+		return err
+	}
+	name := rotatedPrefix + time.Now().UTC().Format(rotatedTimeForm) + rotatedSuffix
+	if err := os.Rename(filepath.Join(b.dir, activeFileName), filepath.Join(b.dir, name)); err != nil {
+		return err
+	}
+	return b.openActive()
+}
+
+// files 返回按时间先后排列的轮转文件，最后一个为当前文件。
+func (b *FileBackend) files() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, rotatedPrefix) && strings.HasSuffix(name, rotatedSuffix) {
+			rotated = append(rotated, filepath.Join(b.dir, name))
+		}
+	}
+	sort.Strings(rotated)
+	return append(rotated, filepath.Join(b.dir, activeFileName)), nil
+}
+
+func countLines(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Count(data, []byte{'\n'}), nil
+}
@@ -0,0 +1,280 @@
+package history
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 排序字段。
+const (
+	SortTime    = "time"
+	SortResults = "results"
+	SortTook    = "took"
+)
+
+// 默认与最大分页大小。
+const (
+	defaultPageSize = 20
+	maxPageSize     = 500
+)
+
+// ErrInvalidCursor 游标无法解析或与当前排序方式不一致。
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Filter 历史记录查询条件，零值字段表示不限。
+type Filter struct {
+	// Since、Until 限定记录时间，区间为 [Since, Until)。
+	Since time.Time
+	Until time.Time
+	// Query 查询词子串，不区分大小写。
+	Query    string
+	Provider string
+	// Caller 调用方，精确匹配。
+	Caller string
+	// MinResults、MaxResults 限定结果条数，MaxResults 为空表示不限。
+	MinResults int
+	MaxResults *int
+	// MinTook、MaxTook 限定耗时，0 表示不限。
+	MinTook time.Duration
+	MaxTook time.Duration
+	// Sort 取值见 SortTime、SortResults、SortTook，默认按时间。
+	Sort string
+	// Ascending 为 true 时升序，默认降序（最新、最多、最慢在前）。
+	Ascending bool
+	// Cursor 上一页返回的 NextCursor。
+	Cursor string
+	// Limit 每页条数，默认 20，最大 500。
+	Limit int
+}
+
+// Page 一页查询结果。
+type Page struct {
+	Records    []Record `json:"records"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	// Total 满足条件的记录总数，不受分页影响。
+	Total int `json:"total"`
+}
+
+// cursor 记录上一页最后一条的位置，与排序方式绑定。
+type cursor struct {
+	Sort      string `json:"s"`
+	Ascending bool   `json:"a,omitempty"`
+	Value     int64  `json:"v"`
+	ID        uint64 `json:"i"`
+}
+
+// ParseSort 校验并归一排序字段，空字符串视为 SortTime。
+func ParseSort(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", SortTime:
+		return SortTime, true
+	case SortResults:
+		return SortResults, true
+	case SortTook:
+		return SortTook, true
+	default:
+		return "", false
+	}
+}
+
+// Query 按条件筛选、排序并分页返回记录。
+//
+// 指定 Provider 或 Query 时从倒排索引取候选，否则按时间二分截取范围；
+// 分页使用基于 (排序值, ID) 的游标，翻页期间新增记录不会造成重复或遗漏。
+func (s *Store) Query(f Filter) (Page, error) {
+	sortBy, ok := ParseSort(f.Sort)
+	if !ok {
+		return Page{}, fmt.Errorf("unknown sort %q", f.Sort)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	var after *cursor
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil || c.Sort != sortBy || c.Ascending != f.Ascending {
+			return Page{}, ErrInvalidCursor
+		}
+		after = &c
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since := f.Since
+	if cutoff := s.cutoff(time.Now()); cutoff.After(since) {
+		since = cutoff
+	}
+	candidates, ordered := s.candidates(f, since)
+
+	matched := make([]*entry, 0, len(candidates))
+	for _, e := range candidates {
+		if matches(e, f, since) {
+			matched = append(matched, e)
+		}
+	}
+
+	key := sortKey(sortBy)
+	less := func(a, b *entry) bool {
+		ka, kb := key(a), key(b)
+		if ka != kb {
+			return ka < kb
+		}
+		return a.ID < b.ID
+	}
+	if sortBy != SortTime || !ordered {
+		sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+	}
+
+	// matched 此时为升序，降序分页从末尾向前取。
+	page := Page{Total: len(matched), Records: []Record{}}
+	pos := &entry{}
+	if after != nil {
+		pos.ID = after.ID
+		setSortValue(pos, sortBy, after.Value)
+	}
+	var picked []*entry
+	if f.Ascending {
+		start := 0
+		if after != nil {
+			start = sort.Search(len(matched), func(i int) bool { return less(pos, matched[i]) })
+		}
+		end := min(start+limit, len(matched))
+		picked = matched[start:end]
+		if end < len(matched) && len(picked) > 0 {
+			page.NextCursor = encodeCursor(cursor{Sort: sortBy, Ascending: true, Value: key(picked[len(picked)-1]), ID: picked[len(picked)-1].ID})
+		}
+	} else {
+		end := len(matched)
+		if after != nil {
+			end = sort.Search(len(matched), func(i int) bool { return !less(matched[i], pos) })
+		}
+		start := max(end-limit, 0)
+		picked = slices.Clone(matched[start:end])
+		slices.Reverse(picked)
+		if start > 0 && len(picked) > 0 {
+			page.NextCursor = encodeCursor(cursor{Sort: sortBy, Value: key(picked[len(picked)-1]), ID: picked[len(picked)-1].ID})
+		}
+	}
+	for _, e := range picked {
+		page.Records = append(page.Records, e.Record)
+	}
+	return page, nil
+}
+
+// candidates 选出候选记录，ordered 表示结果已按 (Time, ID) 升序。
+func (s *Store) candidates(f Filter, since time.Time) ([]*entry, bool) {
+	var lists [][]*entry
+	if f.Provider != "" {
+		lists = append(lists, s.byProvider[f.Provider])
+	}
+	if f.Query != "" {
+		needle := strings.ToLower(f.Query)
+		var union []*entry
+		for query, entries := range s.byQuery {
+			if strings.Contains(query, needle) {
+				union = append(union, entries...)
+			}
+		}
+		lists = append(lists, union)
+	}
+
+	if len(lists) == 0 {
+		lo := 0
+		if !since.IsZero() {
+			lo = sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(since) })
+		}
+		hi := len(s.records)
+		if !f.Until.IsZero() {
+			hi = sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(f.Until) })
+		}
+		if hi < lo {
+			hi = lo
+		}
+		return s.records[lo:hi], true
+	}
+
+	// 从最短的列表出发，其余条件在 matches 中逐条校验。
+	shortest := lists[0]
+	for _, l := range lists[1:] {
+		if len(l) < len(shortest) {
+			shortest = l
+		}
+	}
+	return shortest, false
+}
+
+func matches(e *entry, f Filter, since time.Time) bool {
+	if e.removed {
+		return false
+	}
+	if !since.IsZero() && e.Time.Before(since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(e.query, strings.ToLower(f.Query)) {
+		return false
+	}
+	if f.Provider != "" && !slices.Contains(e.Providers, f.Provider) {
+		return false
+	}
+	if f.Caller != "" && e.Caller != f.Caller {
+		return false
+	}
+	if e.Results < f.MinResults || (f.MaxResults != nil && e.Results > *f.MaxResults) {
+		return false
+	}
+	if (f.MinTook > 0 && e.Took < f.MinTook) || (f.MaxTook > 0 && e.Took > f.MaxTook) {
+		return false
+	}
+	return true
+}
+
+func sortKey(sortBy string) func(*entry) int64 {
+	switch sortBy {
+	case SortResults:
+		return func(e *entry) int64 { return int64(e.Results) }
+	case SortTook:
+		return func(e *entry) int64 { return int64(e.Took) }
+	default:
+		return func(e *entry) int64 { return e.Time.UnixNano() }
+	}
+}
+
+func setSortValue(e *entry, sortBy string, value int64) {
+	switch sortBy {
+	case SortResults:
+		e.Results = int(value)
+	case SortTook:
+		e.Took = time.Duration(value)
+	default:
+		e.Time = time.Unix(0, value)
+	}
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
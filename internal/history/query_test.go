@@ -0,0 +1,122 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func seedStore(t testing.TB, n int) (*Store, time.Time) {
+	t.Helper()
+	store := NewStore(n)
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	providers := [][]string{{"zhihu"}, {"wechat"}, {"zhihu", "wechat"}}
+	for i := 0; i < n; i++ {
+		store.Add(Record{
+			Query:     fmt.Sprintf("品牌%d 评价", i%10),
+			Providers: providers[i%3],
+			Results:   i % 7,
+			Took:      time.Duration(i%5) * 100 * time.Millisecond,
+			Time:      base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return store, base
+}
+
+func TestStoreQueryFilters(t *testing.T) {
+	store, base := seedStore(t, 100)
+	maxResults := 3
+	page, err := store.Query(Filter{
+		Since:      base.Add(10 * time.Minute),
+		Until:      base.Add(70 * time.Minute),
+		Query:      "品牌1",
+		Provider:   "zhihu",
+		MinResults: 1,
+		MaxResults: &maxResults,
+		MinTook:    100 * time.Millisecond,
+		Limit:      100,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range page.Records {
+		if r.Time.Before(base.Add(10*time.Minute)) || !r.Time.Before(base.Add(70*time.Minute)) ||
+			r.Results < 1 || r.Results > 3 || r.Took < 100*time.Millisecond || r.Query != "品牌1 评价" {
+			t.Fatalf("record does not match filter: %+v", r)
+		}
+	}
+	// i%10==1、i%3!=1、1<=i%7<=3、i%5!=0，且 10<=i<70。
+	expected := 0
+	for i := 10; i < 70; i++ {
+		if i%10 == 1 && i%3 != 1 && i%7 >= 1 && i%7 <= 3 && i%5 != 0 {
+			expected++
+		}
+	}
+	if page.Total != expected || len(page.Records) != expected {
+		t.Fatalf("expected %d matches, got total=%d records=%d", expected, page.Total, len(page.Records))
+	}
+}
+
+func TestStoreQueryCursorPagination(t *testing.T) {
+	store, _ := seedStore(t, 50)
+	for _, tc := range []struct {
+		sort      string
+		ascending bool
+	}{{SortTime, false}, {SortTime, true}, {SortResults, false}, {SortTook, true}} {
+		seen := map[uint64]bool{}
+		var prev *Record
+		cursor := ""
+		for pages := 0; ; pages++ {
+			page, err := store.Query(Filter{Sort: tc.sort, Ascending: tc.ascending, Cursor: cursor, Limit: 7})
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.sort, err)
+			}
+			if page.Total != 50 {
+				t.Fatalf("%s: expected total 50, got %d", tc.sort, page.Total)
+			}
+			for i := range page.Records {
+				r := page.Records[i]
+				if seen[r.ID] {
+					t.Fatalf("%s: record %d returned twice", tc.sort, r.ID)
+				}
+				seen[r.ID] = true
+				if prev != nil && tc.sort == SortTime && tc.ascending == r.Time.Before(prev.Time) {
+					t.Fatalf("%s: records out of order", tc.sort)
+				}
+				prev = &r
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		if len(seen) != 50 {
+			t.Fatalf("%s asc=%v: expected all 50 records across pages, got %d", tc.sort, tc.ascending, len(seen))
+		}
+	}
+
+	page, _ := store.Query(Filter{Limit: 5})
+	if _, err := store.Query(Filter{Sort: SortResults, Cursor: page.NextCursor}); err != ErrInvalidCursor {
+		t.Fatalf("expected cursor bound to sort order, got %v", err)
+	}
+}
+
+func TestStoreQuerySkipsTrimmedRecords(t *testing.T) {
+	store := NewStore(3)
+	base := time.Now()
+	for i := 0; i < 10; i++ {
+		store.Add(Record{Query: "q", Providers: []string{"mock"}, Time: base.Add(time.Duration(i) * time.Second)})
+	}
+	page, _ := store.Query(Filter{Provider: "mock", Query: "q"})
+	if page.Total != 3 || page.Records[0].ID != 10 {
+		t.Fatalf("expected only 3 newest records, got %+v", page)
+	}
+}
+
+func BenchmarkStoreQuery(b *testing.B) {
+	store, base := seedStore(b, 200000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.Query(Filter{Since: base.Add(24 * time.Hour), Query: "品牌3", Provider: "wechat", Limit: 50})
+	}
+}
@@ -1,58 +1,362 @@
 package history
 
 import (
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
-// Record 表示一次搜索请求的元数据。
+// pruneInterval 两次清理持久化数据之间的最短间隔。
+const pruneInterval = time.Minute
+
+// Record 表示一次搜索请求的元数据，包含审计与重放所需的完整上下文。
 type Record struct {
-	Query     string        `json:"query"`
+	// ID 由 Store 分配，单调递增。
+	ID        uint64 `json:"id"`
+	RequestID string `json:"request_id,omitempty"`
+	// Caller 发起请求的调用方，如用户名或 API Key 的摘要。
+	Caller string `json:"caller,omitempty"`
+	Query  string `json:"query"`
+	// Providers 实际参与搜索的平台。
 	Providers []string      `json:"providers"`
+	Options   SearchOptions `json:"options"`
 	Results   int           `json:"results"`
 	Took      time.Duration `json:"took"`
 	Time      time.Time     `json:"time"`
+	// Cached、Stale、Coalesced 分别表示命中缓存、命中宽限期内的旧缓存、与进行中的同一请求合并。
+	Cached    bool `json:"cached,omitempty"`
+	Stale     bool `json:"stale,omitempty"`
+	Coalesced bool `json:"coalesced,omitempty"`
+	// Statuses 各平台的返回条数与错误，供分析平台覆盖率与错误率。
+	Statuses []ProviderStatus `json:"statuses,omitempty"`
+	// Fingerprints 按返回顺序排列的结果指纹，用于核对重放结果。
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// Snapshot 返回结果的精简快照，重放时据此计算新增、移除与指标变化。
+	Snapshot []ResultSnapshot `json:"snapshot,omitempty"`
+}
+
+// ResultSnapshot 单条结果的精简快照。
+type ResultSnapshot struct {
+	URL         string           `json:"url"`
+	Title       string           `json:"title"`
+	Source      string           `json:"source"`
+	PublishedAt time.Time        `json:"published_at,omitzero"`
+	Metrics     map[string]int64 `json:"metrics,omitempty"`
+}
+
+// SearchOptions 搜索时使用的参数。
+type SearchOptions struct {
+	// Providers 请求中指定的平台，为空表示使用全部平台。
+	Providers    []string  `json:"providers,omitempty"`
+	Limit        int       `json:"limit,omitempty"`
+	ForceRefresh bool      `json:"force_refresh,omitempty"`
+	Locale       string    `json:"locale,omitempty"`
+	StartTime    time.Time `json:"start_time,omitzero"`
+	EndTime      time.Time `json:"end_time,omitzero"`
+	Mode         string    `json:"mode,omitempty"`
+	Sort         string    `json:"sort,omitempty"`
 }
 
-// Store 持久化最近的搜索记录。
+// ProviderStatus 单个平台在一次搜索中的执行情况。
+type ProviderStatus struct {
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+	Error  string `json:"error,omitempty"`
+	Cached bool   `json:"cached,omitempty"`
+}
+
+// Config 历史记录配置。
+type Config struct {
+	// MaxRecords 内存中保留的最大记录数，默认 50。
+	MaxRecords int
+	// MaxDiskRecords Backend 中保留的最大记录数，默认 10000，约为一周的搜索量；
+	// 与 MaxRecords 分开设置，内存上限较小时磁盘上的历史不会随之被清理。
+	MaxDiskRecords int
+	// MaxAge 记录的最长保留时间，0 表示不按时间清理。
+	MaxAge time.Duration
+	// Backend 持久化后端，为空时只保存在内存中。
+	Backend Backend
+	// MaxSnapshots 内存中保留结果快照的最新记录数，默认 100。更早记录的快照只留在 Backend 中，
+	// Get 时按需读取；没有 Backend 或 Backend 不支持按 ID 读取时直接丢弃。
+	MaxSnapshots int
+}
+
+// entry 内存中的记录，removed 标记已被裁剪但仍留在索引中的条目，dropped 标记结果快照已从内存清除。
+type entry struct {
+	Record
+	query   string
+	removed bool
+	dropped bool
+}
+
+// Store 保存最近的搜索记录，可选持久化到 Backend。
+//
+// 记录按 (Time, ID) 有序保存，时间范围查询走二分；查询词与 provider 各有倒排索引，
+// 查询词子串匹配只需扫描去重后的查询词。裁剪的记录先打标记，累计过多时再重建索引。
 type Store struct {
-	limit   int
-	records []Record
-	mu      sync.RWMutex
+	cfg        Config
+	records    []*entry
+	byID       map[uint64]*entry
+	byQuery    map[string][]*entry
+	byProvider map[string][]*entry
+	dead       int
+	nextID     uint64
+	lastPrune  time.Time
+	mu         sync.RWMutex
 }
 
-// NewStore 构建历史记录仓库。
+// NewStore 构建只保存在内存中的历史记录仓库。
 func NewStore(limit int) *Store {
-	if limit <= 0 {
-		limit = 50
+	store, _ := NewStoreWithConfig(Config{MaxRecords: limit})
+	return store
+}
+
+// NewStoreWithConfig 按配置构建历史记录仓库，配置了 Backend 时会先加载保留期内的记录。
+func NewStoreWithConfig(cfg Config) (*Store, error) {
+	if cfg.MaxRecords <= 0 {
+		cfg.MaxRecords = 50
+	}
+	if cfg.MaxDiskRecords <= 0 {
+		cfg.MaxDiskRecords = 10000
+	}
+	if cfg.MaxSnapshots <= 0 {
+		cfg.MaxSnapshots = 100
+	}
+	s := &Store{cfg: cfg, nextID: 1}
+	s.rebuild()
+	if cfg.Backend == nil {
+		return s, nil
+	}
+
+	records, err := cfg.Backend.Load(s.cutoff(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	// 旧版本写入的记录没有 ID，按加载顺序补齐。
+	for _, r := range records {
+		if r.ID >= s.nextID {
+			s.nextID = r.ID + 1
+		}
 	}
-	return &Store{
-		limit: limit,
+	for i := range records {
+		if records[i].ID == 0 {
+			records[i].ID = s.nextID
+			s.nextID++
+		}
 	}
+	if len(records) > cfg.MaxRecords {
+		records = records[len(records)-cfg.MaxRecords:]
+	}
+	for _, r := range records {
+		s.insert(r)
+	}
+	s.dropSnapshots()
+	return s, nil
 }
 
-// Add 追加一条记录。
-func (s *Store) Add(record Record) {
+// Add 追加一条记录并分配 ID，返回持久化失败的错误，记录本身总会保存在内存中。
+// 写入与清理后端在释放 s.mu 之后进行，读取历史与其他搜索不会因磁盘 I/O 而阻塞。
+func (s *Store) Add(record Record) error {
+	now := time.Now()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.records = append([]Record{record}, s.records...)
-	if len(s.records) > s.limit {
-		s.records = s.records[:s.limit]
+	record.ID = s.nextID
+	s.nextID++
+	s.insert(record)
+	s.trim(now)
+	s.dropSnapshots()
+	prune := s.cfg.Backend != nil && now.Sub(s.lastPrune) >= pruneInterval
+	if prune {
+		s.lastPrune = now
+	}
+	s.mu.Unlock()
+
+	if s.cfg.Backend == nil {
+		return nil
+	}
+	if err := s.cfg.Backend.Append(record); err != nil {
+		return err
 	}
+	if prune {
+		return s.cfg.Backend.Prune(s.cutoff(now), s.cfg.MaxDiskRecords)
+	}
+	return nil
 }
 
-// List 返回最近的若干条记录。
+// List 返回最近的若干条记录，最新的在前。
 func (s *Store) List(limit int) []Record {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	if limit <= 0 || limit > len(s.records) {
-		limit = len(s.records)
-	}
-	result := make([]Record, limit)
-	copy(result, s.records[:limit])
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Time.After(result[j].Time)
-	})
+	live := s.records[s.firstLive(time.Now()):]
+	if limit <= 0 || limit > len(live) {
+		limit = len(live)
+	}
+	result := make([]Record, 0, limit)
+	for i := len(live) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, live[i].Record)
+	}
+	return result
+}
+
+// Get 按 ID 返回记录，已被裁剪或超过保留期的记录视为不存在。
+// 快照已从内存清除的记录会从 Backend 读取快照，读取失败时返回不带快照的记录。
+func (s *Store) Get(id uint64) (Record, bool) {
+	s.mu.RLock()
+	e, ok := s.byID[id]
+	if ok {
+		if cutoff := s.cutoff(time.Now()); !cutoff.IsZero() && e.Time.Before(cutoff) {
+			ok = false
+		}
+	}
+	var record Record
+	var dropped bool
+	if ok {
+		record, dropped = e.Record, e.dropped
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return Record{}, false
+	}
+	if loader, isLoader := s.cfg.Backend.(RecordLoader); dropped && isLoader {
+		if stored, found, err := loader.LoadRecord(id); err == nil && found {
+			record.Snapshot = stored.Snapshot
+		}
+	}
+	return record, true
+}
+
+// Range 按时间先后返回 [since, until) 内的记录，零值表示不限。Range 供统计使用，返回的记录不带结果快照。
+func (s *Store) Range(since, until time.Time) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if cutoff := s.cutoff(time.Now()); cutoff.After(since) {
+		since = cutoff
+	}
+	lo := sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(since) })
+	hi := len(s.records)
+	if !until.IsZero() {
+		hi = sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(until) })
+	}
+	result := make([]Record, 0, max(hi-lo, 0))
+	for _, e := range s.records[lo:max(hi, lo)] {
+		record := e.Record
+		record.Snapshot = nil
+		result = append(result, record)
+	}
 	return result
 }
+
+// Len 返回当前保留的记录数。
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// Close 关闭持久化后端。
+func (s *Store) Close() error {
+	if s.cfg.Backend == nil {
+		return nil
+	}
+	return s.cfg.Backend.Close()
+}
+
+// insert 按 (Time, ID) 顺序插入并更新索引，记录通常按时间到达，插入点在末尾附近。
+func (s *Store) insert(record Record) {
+	e := &entry{Record: record, query: strings.ToLower(record.Query)}
+	i := sort.Search(len(s.records), func(i int) bool { return entryBefore(e, s.records[i]) })
+	s.records = slices.Insert(s.records, i, e)
+	s.byID[e.ID] = e
+	s.byQuery[e.query] = append(s.byQuery[e.query], e)
+	for _, p := range record.Providers {
+		s.byProvider[p] = append(s.byProvider[p], e)
+	}
+}
+
+// trim 按条数与保留时间从最旧的一端裁剪记录。
+func (s *Store) trim(now time.Time) {
+	drop := s.firstLive(now)
+	if over := len(s.records) - drop - s.cfg.MaxRecords; over > 0 {
+		drop += over
+	}
+	if drop == 0 {
+		return
+	}
+	for _, e := range s.records[:drop] {
+		e.removed = true
+		delete(s.byID, e.ID)
+	}
+	s.records = s.records[drop:]
+	s.dead += drop
+	if s.dead > len(s.records) {
+		s.rebuild()
+	}
+}
+
+// dropSnapshots 清除最新 MaxSnapshots 条以外记录的结果快照。
+// 从边界向旧的一端清理，遇到已清除的记录即停止，因此每次 Add 均摊只处理一条。
+func (s *Store) dropSnapshots() {
+	for i := len(s.records) - s.cfg.MaxSnapshots - 1; i >= 0; i-- {
+		e := s.records[i]
+		if e.dropped {
+			return
+		}
+		e.Snapshot = nil
+		e.dropped = true
+	}
+}
+
+// firstLive 返回第一条仍在保留期内的记录下标。
+func (s *Store) firstLive(now time.Time) int {
+	cutoff := s.cutoff(now)
+	if cutoff.IsZero() {
+		return 0
+	}
+	return sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(cutoff) })
+}
+
+// rebuild 重新生成记录切片与索引，释放已裁剪的条目。
+func (s *Store) rebuild() {
+	s.records = slices.Clone(s.records)
+	s.byID = make(map[uint64]*entry, len(s.records))
+	s.byQuery = map[string][]*entry{}
+	s.byProvider = map[string][]*entry{}
+	s.dead = 0
+	// 索引按 ID 升序，与插入顺序一致。
+	ordered := slices.Clone(s.records)
+	slices.SortFunc(ordered, func(a, b *entry) int { return compareUint(a.ID, b.ID) })
+	for _, e := range ordered {
+		s.byID[e.ID] = e
+		s.byQuery[e.query] = append(s.byQuery[e.query], e)
+		for _, p := range e.Providers {
+			s.byProvider[p] = append(s.byProvider[p], e)
+		}
+	}
+}
+
+// cutoff 返回保留期的起点，未配置 MaxAge 时为零值。
+func (s *Store) cutoff(now time.Time) time.Time {
+	if s.cfg.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-s.cfg.MaxAge)
+}
+
+func entryBefore(a, b *entry) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.Before(b.Time)
+	}
+	return a.ID < b.ID
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,222 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreReloadsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewFileBackend(dir, FileOptions{})
+	if err != nil {
+		t.Fatalf("open backend: %v", err)
+	}
+	store, err := NewStoreWithConfig(Config{MaxRecords: 2, Backend: backend})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, query := range []string{"a", "b", "c"} {
+		if err := store.Add(Record{Query: query, Results: i, Time: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if got := store.List(0); len(got) != 2 || got[0].Query != "c" {
+		t.Fatalf("expected newest 2 records in memory, got %+v", got)
+	}
+	store.Close()
+
+	// 模拟异常退出时写了一半的行。
+	f, _ := os.OpenFile(filepath.Join(dir, activeFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"query":"broken`)
+	f.Close()
+
+	backend, err = NewFileBackend(dir, FileOptions{})
+	if err != nil {
+		t.Fatalf("reopen backend: %v", err)
+	}
+	store, err = NewStoreWithConfig(Config{MaxRecords: 10, Backend: backend})
+	if err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	defer store.Close()
+	got := store.List(0)
+	if len(got) != 3 || got[0].Query != "c" || got[2].Query != "a" {
+		t.Fatalf("expected all 3 records reloaded newest first, got %+v", got)
+	}
+}
+
+func TestFileBackendRotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewFileBackend(dir, FileOptions{MaxFileBytes: 120})
+	if err != nil {
+		t.Fatalf("open backend: %v", err)
+	}
+	defer backend.Close()
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := backend.Append(Record{Query: "q", Providers: []string{"mock"}, Time: now.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	files, _ := backend.files()
+	if len(files) < 3 {
+		t.Fatalf("expected rotation into several files, got %v", files)
+	}
+	records, err := backend.Load(time.Time{})
+	if err != nil || len(records) != 6 {
+		t.Fatalf("expected 6 records across files, got %d (%v)", len(records), err)
+	}
+	if records, _ := backend.Load(now.Add(4 * time.Second)); len(records) != 2 {
+		t.Fatalf("expected since filter to keep 2 records, got %d", len(records))
+	}
+
+	if err := backend.Prune(time.Time{}, 1); err != nil {
+		t.Fatalf("prune by count: %v", err)
+	}
+	if files, _ := backend.files(); len(files) != 1 {
+		t.Fatalf("expected rotated files beyond keep removed, got %v", files)
+	}
+
+	backend.Append(Record{Query: "old", Time: now})
+	backend.rotate()
+	old := filepath.Join(dir, "history-old"+rotatedSuffix)
+	files, _ = backend.files()
+	os.Rename(files[0], old)
+	os.Chtimes(old, now.Add(-48*time.Hour), now.Add(-48*time.Hour))
+	if err := backend.Prune(now.Add(-24*time.Hour), 0); err != nil {
+		t.Fatalf("prune by age: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatalf("expected expired rotated file removed, got %v", err)
+	}
+}
+
+func TestStoreMaxAge(t *testing.T) {
+	store, _ := NewStoreWithConfig(Config{MaxRecords: 10, MaxAge: time.Hour})
+	store.Add(Record{Query: "old", Time: time.Now().Add(-2 * time.Hour)})
+	store.Add(Record{Query: "new", Time: time.Now()})
+	if got := store.List(0); len(got) != 1 || got[0].Query != "new" {
+		t.Fatalf("expected records older than MaxAge dropped, got %+v", got)
+	}
+}
+
+// pruneBackend 记录 Prune 收到的保留条数。
+type pruneBackend struct {
+	keep []int
+}
+
+func (b *pruneBackend) Append(Record) error              { return nil }
+func (b *pruneBackend) Load(time.Time) ([]Record, error) { return nil, nil }
+func (b *pruneBackend) Prune(_ time.Time, keep int) error {
+	b.keep = append(b.keep, keep)
+	return nil
+}
+func (b *pruneBackend) Close() error { return nil }
+
+func TestStoreDiskRetentionIsSeparateFromMemory(t *testing.T) {
+	for _, tc := range []struct {
+		disk, want int
+	}{{0, 10000}, {500, 500}} {
+		backend := &pruneBackend{}
+		store, err := NewStoreWithConfig(Config{MaxRecords: 2, MaxDiskRecords: tc.disk, Backend: backend})
+		if err != nil {
+			t.Fatalf("new store: %v", err)
+		}
+		if err := store.Add(Record{Query: "a", Time: time.Now()}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+		if len(backend.keep) != 1 || backend.keep[0] != tc.want {
+			t.Fatalf("expected backend to keep %d records regardless of the in-memory limit, got %v", tc.want, backend.keep)
+		}
+	}
+}
+
+// slowBackend 的 Append 阻塞到 release 关闭，用于确认后端 I/O 不持有 Store 的锁。
+type slowBackend struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) Append(Record) error {
+	b.entered <- struct{}{}
+	<-b.release
+	return nil
+}
+func (b *slowBackend) Load(time.Time) ([]Record, error) { return nil, nil }
+func (b *slowBackend) Prune(time.Time, int) error       { return nil }
+func (b *slowBackend) Close() error                     { return nil }
+
+func TestStoreReadsDoNotWaitForBackend(t *testing.T) {
+	backend := &slowBackend{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	store, err := NewStoreWithConfig(Config{Backend: backend})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- store.Add(Record{Query: "a", Time: time.Now()}) }()
+	<-backend.entered
+
+	read := make(chan []Record, 1)
+	go func() { read <- store.List(0) }()
+	select {
+	case got := <-read:
+		if len(got) != 1 || got[0].Query != "a" {
+			t.Fatalf("expected the record visible while it is being persisted, got %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("List blocked on backend Append")
+	}
+	close(backend.release)
+	if err := <-done; err != nil {
+		t.Fatalf("add: %v", err)
+	}
+}
+
+func TestStoreKeepsRecentSnapshotsInMemory(t *testing.T) {
+	snapshot := func(url string) []ResultSnapshot { return []ResultSnapshot{{URL: url, Title: url}} }
+	add := func(store *Store, n int) {
+		base := time.Now().Add(-time.Hour)
+		for i := 0; i < n; i++ {
+			url := fmt.Sprintf("https://example.com/%d", i)
+			if err := store.Add(Record{Query: "q", Results: 1, Time: base.Add(time.Duration(i) * time.Minute), Snapshot: snapshot(url)}); err != nil {
+				t.Fatalf("add: %v", err)
+			}
+		}
+	}
+
+	backend, err := NewFileBackend(t.TempDir(), FileOptions{})
+	if err != nil {
+		t.Fatalf("open backend: %v", err)
+	}
+	store, err := NewStoreWithConfig(Config{MaxRecords: 10, MaxSnapshots: 2, Backend: backend})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	add(store, 4)
+
+	records := store.List(0)
+	if len(records[0].Snapshot) != 1 || len(records[1].Snapshot) != 1 || records[2].Snapshot != nil || records[3].Snapshot != nil {
+		t.Fatalf("expected only the newest 2 snapshots in memory, got %+v", records)
+	}
+	old, ok := store.Get(records[3].ID)
+	if !ok || len(old.Snapshot) != 1 || old.Snapshot[0].URL != "https://example.com/0" {
+		t.Fatalf("expected evicted snapshot loaded from backend, got %+v", old)
+	}
+	for _, r := range store.Range(time.Time{}, time.Time{}) {
+		if r.Snapshot != nil {
+			t.Fatalf("Range should not return snapshots")
+		}
+	}
+
+	memory, _ := NewStoreWithConfig(Config{MaxRecords: 10, MaxSnapshots: 2})
+	add(memory, 3)
+	first := memory.List(0)[2]
+	if got, _ := memory.Get(first.ID); got.Snapshot != nil {
+		t.Fatalf("expected memory-only store to drop old snapshots, got %+v", got.Snapshot)
+	}
+}
@@ -0,0 +1,138 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"agentgo/internal/aggregator"
+)
+
+// warmRequest 预热接口的请求体。
+type warmRequest struct {
+	Queries   []string `json:"queries"`
+	Providers []string `json:"providers"`
+	Limit     int      `json:"limit"`
+	Locale    string   `json:"locale"`
+	Fresh     bool     `json:"fresh"`
+}
+
+// requireAdmin 校验 Authorization: Bearer <token> 或 X-Admin-Token 请求头。
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AdminToken == "" {
+			s.writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin api is disabled"})
+			return
+		}
+		if !s.isAdmin(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid admin token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isAdmin 判断请求是否带有正确的管理令牌（X-Admin-Token 或 Authorization: Bearer）。
+func (s *Server) isAdmin(r *http.Request) bool {
+	if s.cfg.AdminToken == "" {
+		return false
+	}
+	token := r.Header.Get("X-Admin-Token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.cfg.AdminToken)) == 1
+}
+
+// handleAdminCache GET 列出缓存条目，DELETE 按 key、query（前缀）、provider 失效或 all=true 清空。
+func (s *Server) handleAdminCache(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		name := strings.TrimSpace(q.Get("cache"))
+		switch name {
+		case "", aggregator.CacheResponses, aggregator.CacheProviders, aggregator.CacheSummaries:
+		default:
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "cache must be one of responses, providers, summaries"})
+			return
+		}
+		entries := s.aggregator.CacheEntries(name)
+		if prefix := strings.ToLower(strings.TrimSpace(q.Get("query"))); prefix != "" {
+			filtered := entries[:0]
+			for _, e := range entries {
+				if strings.HasPrefix(e.Query, prefix) {
+					filtered = append(filtered, e)
+				}
+			}
+			entries = filtered
+		}
+		total := len(entries)
+		if limit := parseInt(q.Get("limit"), 100); limit > 0 && limit < len(entries) {
+			entries = entries[:limit]
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"stats":   s.aggregator.CacheStats(),
+			"total":   total,
+			"entries": entries,
+		})
+	case http.MethodDelete:
+		var removed int
+		switch {
+		case q.Get("key") != "":
+			removed = s.aggregator.InvalidateKey(q.Get("key"))
+		case strings.TrimSpace(q.Get("query")) != "":
+			removed = s.aggregator.InvalidateQuery(q.Get("query"))
+		case strings.TrimSpace(q.Get("provider")) != "":
+			removed = s.aggregator.InvalidateProvider(strings.TrimSpace(q.Get("provider")))
+		case strings.EqualFold(q.Get("all"), "true"):
+			s.aggregator.PurgeCache()
+			s.writeJSON(w, http.StatusOK, map[string]any{"purged": true})
+			return
+		default:
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "one of key, query, provider or all=true is required"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"removed": removed})
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		s.writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleAdminWarm 按请求体中的查询列表预热缓存。
+func (s *Server) handleAdminWarm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		s.writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var req warmRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	queries := make([]string, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		if query = strings.TrimSpace(query); query != "" {
+			queries = append(queries, query)
+		}
+	}
+	if len(queries) == 0 {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "queries is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	results := s.aggregator.Warm(ctx, queries, aggregator.Options{
+		Providers:    req.Providers,
+		Limit:        req.Limit,
+		ForceRefresh: req.Fresh,
+		Locale:       req.Locale,
+	})
+	s.writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
@@ -0,0 +1,159 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agentgo/internal/alert"
+)
+
+// requireAlerts 未配置告警引擎时告警接口不可用。
+func (s *Server) requireAlerts(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.Alerts == nil {
+			s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "alerting is disabled"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	rules := s.cfg.Alerts.Store().List(ownerFrom(r))
+	for i := range rules {
+		rules[i] = rules[i].Redacted()
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+func (s *Server) handleCreateAlert(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	rule.Owner = ownerFrom(r)
+	created, err := s.cfg.Alerts.Store().Create(rule)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, created.Redacted())
+}
+
+func (s *Server) handleGetAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.alertID(w, r)
+	if !ok {
+		return
+	}
+	rule, err := s.cfg.Alerts.Store().Get(ownerFrom(r), id)
+	if err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, rule.Redacted())
+}
+
+// handleUpdateAlert 整体替换规则，webhook.secret 传 "******" 时沿用原密钥。
+func (s *Server) handleUpdateAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.alertID(w, r)
+	if !ok {
+		return
+	}
+	rule, ok := s.decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	updated, err := s.cfg.Alerts.Store().Update(ownerFrom(r), id, rule)
+	if err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, updated.Redacted())
+}
+
+func (s *Server) handleDeleteAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.alertID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.cfg.Alerts.Store().Delete(ownerFrom(r), id); err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAlertEvents 返回规则最近的触发记录及投递状态。
+func (s *Server) handleAlertEvents(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.alertID(w, r)
+	if !ok {
+		return
+	}
+	events, err := s.cfg.Alerts.Store().Events(ownerFrom(r), id)
+	if err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+// handleTestAlert 把一条测试告警加入投递队列，立即返回 202 与待投递记录，
+// 投递结果可在 /v1/alerts/{id}/events 中查看。
+func (s *Server) handleTestAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.alertID(w, r)
+	if !ok {
+		return
+	}
+	rule, err := s.cfg.Alerts.Store().Get(ownerFrom(r), id)
+	if err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	if err := s.cfg.Alerts.CheckWebhook(r.Context(), rule); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	event, err := s.cfg.Alerts.Test(rule)
+	if errors.Is(err, alert.ErrQueueFull) {
+		s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		s.writeAlertError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, map[string]any{"event": event})
+}
+
+// decodeAlertRule 解析请求体并检查 Webhook 地址，拒绝指向未允许的内网地址的规则。
+func (s *Server) decodeAlertRule(w http.ResponseWriter, r *http.Request) (alert.Rule, bool) {
+	var rule alert.Rule
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&rule); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return rule, false
+	}
+	if err := s.cfg.Alerts.CheckWebhook(r.Context(), rule); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return rule, false
+	}
+	return rule, true
+}
+
+func (s *Server) alertID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid alert rule id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) writeAlertError(w http.ResponseWriter, err error) {
+	if errors.Is(err, alert.ErrRuleNotFound) {
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
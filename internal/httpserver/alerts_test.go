@@ -0,0 +1,116 @@
+package httpserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"agentgo/internal/alert"
+)
+
+// newAlertServer 启动带告警引擎的测试服务，返回服务与接收 Webhook 的地址。
+func newAlertServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	t.Cleanup(hook.Close)
+	store, err := alert.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := alert.NewNotifier(alert.NotifierConfig{Backoff: time.Millisecond, AllowTargets: []string{"127.0.0.1"}})
+	t.Cleanup(func() { n.Close() })
+	srv, _ := newTestServer(t, Config{Alerts: alert.NewEngine(store, n)})
+	return srv, hook.URL
+}
+
+func alertBody(url string) string {
+	return fmt.Sprintf(`{"name":"负面","query":"品牌A","condition":{"type":"negative_share","threshold":40},"webhook":{"url":%q,"secret":"s3cret"},"enabled":true}`, url)
+}
+
+func TestAlertHandlersAreOwnerScoped(t *testing.T) {
+	srv, hookURL := newAlertServer(t)
+
+	expectStatus(t, call(t, srv, "POST", "/v1/alerts", alertBody(hookURL), nil), http.StatusUnauthorized)
+	created := call(t, srv, "POST", "/v1/alerts", alertBody(hookURL), asAlice)
+	expectStatus(t, created, http.StatusCreated)
+	var rule alert.Rule
+	created.decode(t, &rule)
+	if rule.Owner != "user:alice" || rule.Webhook.Secret != "******" {
+		t.Fatalf("expected owner set and secret redacted, got %+v", rule)
+	}
+	path := fmt.Sprintf("/v1/alerts/%d", rule.ID)
+
+	// bob 既看不到也改不了 alice 的规则。
+	var list struct {
+		Rules []alert.Rule `json:"rules"`
+	}
+	call(t, srv, "GET", "/v1/alerts", "", asBob).decode(t, &list)
+	if len(list.Rules) != 0 {
+		t.Fatalf("expected bob to see no rules, got %+v", list.Rules)
+	}
+	expectStatus(t, call(t, srv, "GET", path, "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "PUT", path, alertBody(hookURL), asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "GET", path+"/events", "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "POST", path+"/test", "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "DELETE", path, "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusOK)
+
+	expectStatus(t, call(t, srv, "GET", "/v1/alerts/abc", "", asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/alerts/999", "", asAlice), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "DELETE", path, "", asAlice), http.StatusNoContent)
+	expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusNotFound)
+}
+
+func TestAlertHandlersRejectInvalidRules(t *testing.T) {
+	srv, hookURL := newAlertServer(t)
+
+	expectStatus(t, call(t, srv, "POST", "/v1/alerts", "{", asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "POST", "/v1/alerts", strings.Replace(alertBody(hookURL), `"query":"品牌A",`, "", 1), asAlice), http.StatusBadRequest)
+	for _, target := range []string{"http://192.168.1.10/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]:9000/", "http://0.0.0.1:8080/", "http://100.64.1.1/hook", "file:///etc/passwd"} {
+		resp := call(t, srv, "POST", "/v1/alerts", alertBody(target), asAlice)
+		expectStatus(t, resp, http.StatusBadRequest)
+	}
+
+	var rule alert.Rule
+	call(t, srv, "POST", "/v1/alerts", alertBody(hookURL), asAlice).decode(t, &rule)
+	path := fmt.Sprintf("/v1/alerts/%d", rule.ID)
+	expectStatus(t, call(t, srv, "PUT", path, alertBody("http://10.0.0.1/hook"), asAlice), http.StatusBadRequest)
+}
+
+func TestAlertTestIsQueued(t *testing.T) {
+	srv, hookURL := newAlertServer(t)
+	var rule alert.Rule
+	call(t, srv, "POST", "/v1/alerts", alertBody(hookURL), asAlice).decode(t, &rule)
+	path := fmt.Sprintf("/v1/alerts/%d", rule.ID)
+
+	resp := call(t, srv, "POST", path+"/test", "", asAlice)
+	expectStatus(t, resp, http.StatusAccepted)
+	var accepted struct {
+		Event alert.Event `json:"event"`
+	}
+	resp.decode(t, &accepted)
+	if accepted.Event.Status != alert.StatusPending {
+		t.Fatalf("expected a pending event, got %+v", accepted.Event)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var events struct {
+			Events []alert.Event `json:"events"`
+		}
+		call(t, srv, "GET", path+"/events", "", asAlice).decode(t, &events)
+		if len(events.Events) == 1 && events.Events[0].Status == alert.StatusDelivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the test alert to be delivered, got %+v", events.Events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"agentgo/internal/analytics"
+)
+
+// defaultAnalyticsWindow 未指定时间窗口时统计最近 7 天。
+const defaultAnalyticsWindow = 7 * 24 * time.Hour
+
+// handleTopQueries 按天或按周返回热门查询。
+func (s *Server) handleTopQueries(w http.ResponseWriter, r *http.Request) {
+	granularity, ok := analytics.ParseGranularity(r.URL.Query().Get("granularity"))
+	if !ok {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "granularity must be day or week"})
+		return
+	}
+	window := defaultAnalyticsWindow
+	if granularity == analytics.Week {
+		window = 4 * defaultAnalyticsWindow
+	}
+	since, until, ok := s.analyticsWindow(w, r, window)
+	if !ok {
+		return
+	}
+	periods := s.analytics.TopQueries(granularity, since, until, parseInt(r.URL.Query().Get("limit"), 10))
+	s.writeJSON(w, http.StatusOK, map[string]any{"granularity": granularity, "periods": periods})
+}
+
+// handleRisingQueries 返回与上一周期相比增长最多的查询。
+func (s *Server) handleRisingQueries(w http.ResponseWriter, r *http.Request) {
+	granularity, ok := analytics.ParseGranularity(r.URL.Query().Get("granularity"))
+	if !ok {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "granularity must be day or week"})
+		return
+	}
+	trends := s.analytics.Rising(granularity, time.Now(), parseInt(r.URL.Query().Get("limit"), 10))
+	s.writeJSON(w, http.StatusOK, map[string]any{"granularity": granularity, "queries": trends})
+}
+
+// handleZeroResults 返回出现过零结果的查询。
+func (s *Server) handleZeroResults(w http.ResponseWriter, r *http.Request) {
+	since, until, ok := s.analyticsWindow(w, r, defaultAnalyticsWindow)
+	if !ok {
+		return
+	}
+	stats := s.analytics.ZeroResults(since, until, parseInt(r.URL.Query().Get("limit"), 20))
+	s.writeJSON(w, http.StatusOK, map[string]any{"queries": stats})
+}
+
+// handleSlowestQueries 返回平均耗时最长的查询。
+func (s *Server) handleSlowestQueries(w http.ResponseWriter, r *http.Request) {
+	since, until, ok := s.analyticsWindow(w, r, defaultAnalyticsWindow)
+	if !ok {
+		return
+	}
+	stats := s.analytics.Slowest(since, until, parseInt(r.URL.Query().Get("limit"), 20))
+	s.writeJSON(w, http.StatusOK, map[string]any{"queries": stats})
+}
+
+// handleProviderAnalytics 返回各平台的错误率与空结果次数。
+func (s *Server) handleProviderAnalytics(w http.ResponseWriter, r *http.Request) {
+	since, until, ok := s.analyticsWindow(w, r, defaultAnalyticsWindow)
+	if !ok {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"providers": s.analytics.Providers(since, until)})
+}
+
+// analyticsWindow 解析 start/end/since，未指定起点时取最近 fallback 时长。
+func (s *Server) analyticsWindow(w http.ResponseWriter, r *http.Request, fallback time.Duration) (time.Time, time.Time, bool) {
+	since, until, err := parseWindow(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return since, until, false
+	}
+	if since.IsZero() {
+		since = time.Now().Add(-fallback)
+	}
+	return since, until, true
+}
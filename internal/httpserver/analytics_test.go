@@ -0,0 +1,36 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAnalyticsRequiresAdmin(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, srv, "GET", "/v1/search?q=运营&mode=results_only", "", asAlice), http.StatusOK)
+
+	for _, path := range []string{
+		"/v1/analytics/top-queries",
+		"/v1/analytics/rising",
+		"/v1/analytics/zero-results",
+		"/v1/analytics/slowest",
+		"/v1/analytics/providers",
+	} {
+		expectStatus(t, call(t, srv, "GET", path, "", nil), http.StatusUnauthorized)
+		expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusUnauthorized)
+		expectStatus(t, call(t, srv, "GET", path, "", asAdmin), http.StatusOK)
+	}
+
+	var top struct {
+		Periods []struct {
+			Queries []struct {
+				Query string `json:"query"`
+			} `json:"queries"`
+		} `json:"periods"`
+	}
+	call(t, srv, "GET", "/v1/analytics/top-queries", "", asAdmin).decode(t, &top)
+	if len(top.Periods) == 0 || len(top.Periods[0].Queries) == 0 || top.Periods[0].Queries[0].Query != "运营" {
+		t.Fatalf("expected the search in top queries, got %+v", top)
+	}
+	expectStatus(t, call(t, srv, "GET", "/v1/analytics/top-queries?granularity=hour", "", asAdmin), http.StatusBadRequest)
+}
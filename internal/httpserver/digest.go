@@ -0,0 +1,121 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentgo/internal/digest"
+)
+
+// digestSendRequest 发送报告的请求体。
+type digestSendRequest struct {
+	Owner  string   `json:"owner"`
+	To     []string `json:"to"`
+	Saved  []uint64 `json:"saved"`
+	Period string   `json:"period"`
+	Title  string   `json:"title"`
+}
+
+// handleDigestPreview 按 owner 保存的搜索生成报告并直接返回 HTML（format=text 时返回纯文本）。
+// saved=1,2 限定保存的搜索，period=24h 为报告周期。
+func (s *Server) handleDigestPreview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var ids []uint64
+	for _, raw := range parseList(q.Get("saved")) {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid saved search id " + raw})
+			return
+		}
+		ids = append(ids, id)
+	}
+	content, status, err := s.renderDigest(r.Context(), q.Get("owner"), ids, q.Get("period"), q.Get("title"))
+	if err != nil {
+		s.writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	if strings.EqualFold(q.Get("format"), "text") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(content.Text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(content.HTML))
+}
+
+// handleDigestSend 生成报告并通过 SMTP 发送。
+func (s *Server) handleDigestSend(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Mailer == nil {
+		s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "smtp is not configured"})
+		return
+	}
+	var req digestSendRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if len(req.To) == 0 {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at least one recipient is required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	content, status, err := s.renderDigest(ctx, req.Owner, req.Saved, req.Period, req.Title)
+	if err != nil {
+		s.writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := s.cfg.Mailer.Send(ctx, req.To, content); err != nil {
+		s.writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"sent": true, "subject": content.Subject, "to": req.To})
+}
+
+// renderDigest 生成 owner 的报告，ids 为空时包含其全部保存的搜索。
+func (s *Server) renderDigest(ctx context.Context, owner string, ids []uint64, rawPeriod, title string) (digest.Rendered, int, error) {
+	if owner == "" {
+		return digest.Rendered{}, http.StatusBadRequest, errors.New("owner is required")
+	}
+	period := 24 * time.Hour
+	if rawPeriod != "" {
+		d, err := time.ParseDuration(rawPeriod)
+		if err != nil || d <= 0 {
+			return digest.Rendered{}, http.StatusBadRequest, fmt.Errorf("invalid period %q", rawPeriod)
+		}
+		period = d
+	}
+	if title == "" {
+		title = "舆情日报"
+	}
+	searches := s.saved.List(owner)
+	if len(ids) > 0 {
+		searches = searches[:0:0]
+		for _, id := range ids {
+			search, err := s.saved.Get(owner, id)
+			if err != nil {
+				return digest.Rendered{}, http.StatusNotFound, err
+			}
+			searches = append(searches, search)
+		}
+	}
+	if len(searches) == 0 {
+		return digest.Rendered{}, http.StatusBadRequest, errors.New("no saved searches to report on")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	now := time.Now()
+	report := s.digest.Build(ctx, title, searches, now.Add(-period), now)
+	content, err := s.cfg.DigestTemplates.Render(report)
+	if err != nil {
+		return digest.Rendered{}, http.StatusInternalServerError, err
+	}
+	return content, http.StatusOK, nil
+}
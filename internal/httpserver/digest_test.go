@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDigestPreviewRequiresAdmin(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, srv, "POST", "/v1/saved", savedBody, asAlice), http.StatusCreated)
+
+	path := "/v1/digest/preview?owner=user:alice&format=text"
+	expectStatus(t, call(t, srv, "GET", path, "", nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusUnauthorized)
+
+	resp := call(t, srv, "GET", path, "", asAdmin)
+	expectStatus(t, resp, http.StatusOK)
+	if !strings.HasPrefix(resp.header.Get("Content-Type"), "text/plain") || !strings.Contains(string(resp.body), "== 日报（运营）==") {
+		t.Fatalf("unexpected text digest %q: %s", resp.header.Get("Content-Type"), resp.body)
+	}
+	html := call(t, srv, "GET", "/v1/digest/preview?owner=user:alice", "", asAdmin)
+	expectStatus(t, html, http.StatusOK)
+	if !strings.HasPrefix(html.header.Get("Content-Type"), "text/html") {
+		t.Fatalf("expected html digest, got %q", html.header.Get("Content-Type"))
+	}
+}
+
+func TestDigestErrors(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, srv, "POST", "/v1/saved", savedBody, asAlice), http.StatusCreated)
+
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview", "", asAdmin), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview?owner=user:bob", "", asAdmin), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview?owner=user:alice&saved=x", "", asAdmin), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview?owner=user:alice&period=-1h", "", asAdmin), http.StatusBadRequest)
+	// 保存的搜索属于 alice，不能出现在 bob 的报告里。
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview?owner=user:bob&saved=1", "", asAdmin), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "GET", "/v1/digest/preview?owner=user:alice&saved=99", "", asAdmin), http.StatusNotFound)
+
+	body := `{"owner":"user:alice","to":["ops@example.com"]}`
+	expectStatus(t, call(t, srv, "POST", "/v1/digest/send", body, asAlice), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "POST", "/v1/digest/send", body, asAdmin), http.StatusServiceUnavailable)
+}
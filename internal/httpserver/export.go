@@ -0,0 +1,118 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/export"
+)
+
+// exportPageSize 导出历史时每次从存储读取的条数，每页写完后立即推送给客户端。
+const exportPageSize = 500
+
+// handleExportSearch 执行搜索并按 format=csv|jsonl|xlsx 导出结果，参数与 /v1/search 相同。
+func (s *Server) handleExportSearch(w http.ResponseWriter, r *http.Request) {
+	format, ok := export.ParseFormat(r.URL.Query().Get("format"))
+	if !ok {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be one of csv, jsonl, xlsx"})
+		return
+	}
+	query, opts, err := parseSearchRequest(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	// 情绪与实体列来自摘要对每条结果的标注，导出总是完整运行，忽略 mode 参数。
+	opts.Mode = aggregator.ModeFull
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	resp, err := s.aggregator.Search(ctx, query, opts)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	out := s.startExport(w, "search", format)
+	columns := export.ResultColumns(resp.Results)
+	if err := out.WriteHeader(columns); err != nil {
+		return
+	}
+	for _, result := range resp.Results {
+		if err := out.WriteRow(export.ResultRow(result, columns)); err != nil {
+			return
+		}
+	}
+	_ = out.Close()
+}
+
+// handleExportHistory 按 /v1/history 的筛选条件导出全部匹配的历史记录，limit 限制总条数（默认不限）。
+// 与 /v1/history 相同，非管理员只能导出自己的记录。
+// 记录分页读取并逐页推送，导出大量历史时不会一次性占用内存。
+func (s *Server) handleExportHistory(w http.ResponseWriter, r *http.Request) {
+	format, ok := export.ParseFormat(r.URL.Query().Get("format"))
+	if !ok {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be one of csv, jsonl, xlsx"})
+		return
+	}
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if scope := s.callerScope(r); scope != "" {
+		filter.Caller = scope
+	}
+	remaining := parseInt(r.URL.Query().Get("limit"), 0)
+	filter.Limit = exportPageSize
+	page, err := s.aggregator.QueryHistory(filter)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	out := s.startExport(w, "history", format)
+	flusher, _ := w.(http.Flusher)
+	if err := out.WriteHeader(export.HistoryColumns); err != nil {
+		return
+	}
+	for {
+		for _, record := range page.Records {
+			if err := out.WriteRow(export.HistoryRow(record)); err != nil {
+				return
+			}
+			if remaining--; remaining == 0 {
+				_ = out.Close()
+				return
+			}
+		}
+		if page.NextCursor == "" || r.Context().Err() != nil {
+			break
+		}
+		if err := out.Flush(); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		filter.Cursor = page.NextCursor
+		if page, err = s.aggregator.QueryHistory(filter); err != nil {
+			break
+		}
+	}
+	_ = out.Close()
+}
+
+// startExport 写出下载响应头并返回对应格式的 Writer，此后无法再返回 JSON 错误。
+func (s *Server) startExport(w http.ResponseWriter, name, format string) export.Writer {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	out, _ := export.NewWriter(w, format)
+	return out
+}
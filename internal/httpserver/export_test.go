@@ -0,0 +1,109 @@
+package httpserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportSearchFormats(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	resp := call(t, srv, "GET", "/v1/export/search?q=运营&format=csv", "", nil)
+	expectStatus(t, resp, http.StatusOK)
+	if !strings.Contains(resp.header.Get("Content-Disposition"), `attachment; filename="search-`) {
+		t.Fatalf("unexpected disposition %q", resp.header.Get("Content-Disposition"))
+	}
+	body, ok := bytes.CutPrefix(resp.body, []byte("\ufeff"))
+	if !ok {
+		t.Fatalf("expected csv to start with a BOM")
+	}
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil || len(rows) < 2 {
+		t.Fatalf("expected a header and rows, got %d rows err=%v", len(rows), err)
+	}
+	label := slices.Index(rows[0], "sentiment_label")
+	if label < 0 {
+		t.Fatalf("expected a sentiment_label column, got %v", rows[0])
+	}
+	for _, row := range rows[1:] {
+		if row[label] == "" {
+			t.Fatalf("expected every exported result to carry a sentiment label, got %v", row)
+		}
+	}
+
+	resp = call(t, srv, "GET", "/v1/export/search?q=运营&format=jsonl", "", nil)
+	expectStatus(t, resp, http.StatusOK)
+	lines := strings.Split(strings.TrimSpace(string(resp.body)), "\n")
+	if len(lines) != len(rows)-1 {
+		t.Fatalf("expected %d jsonl lines, got %d", len(rows)-1, len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("invalid jsonl line %q", line)
+		}
+	}
+
+	resp = call(t, srv, "GET", "/v1/export/search?q=运营&format=xlsx", "", nil)
+	expectStatus(t, resp, http.StatusOK)
+	if _, err := zip.NewReader(bytes.NewReader(resp.body), int64(len(resp.body))); err != nil {
+		t.Fatalf("expected a valid xlsx archive: %v", err)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	for _, path := range []string{
+		"/v1/export/search?q=运营&format=pdf",
+		"/v1/export/search?format=csv",
+		"/v1/export/history?format=pdf",
+	} {
+		resp := call(t, srv, "GET", path, "", asAlice)
+		expectStatus(t, resp, http.StatusBadRequest)
+		var body map[string]string
+		resp.decode(t, &body)
+		if body["error"] == "" {
+			t.Fatalf("expected an error message for %s", path)
+		}
+	}
+}
+
+func TestExportHistoryLimit(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	for _, q := range []string{"运营", "品牌", "新品"} {
+		expectStatus(t, call(t, srv, "GET", "/v1/search?mode=results_only&q="+q, "", asAlice), http.StatusOK)
+	}
+
+	resp := call(t, srv, "GET", "/v1/export/history?format=jsonl", "", asAlice)
+	expectStatus(t, resp, http.StatusOK)
+	if lines := strings.Split(strings.TrimSpace(string(resp.body)), "\n"); len(lines) != 3 {
+		t.Fatalf("expected 3 history rows, got %d", len(lines))
+	}
+	resp = call(t, srv, "GET", "/v1/export/history?format=jsonl&limit=2", "", asAlice)
+	if lines := strings.Split(strings.TrimSpace(string(resp.body)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected limit to cap the export at 2 rows, got %d", len(lines))
+	}
+}
+
+func TestExportHistoryIsScopedToCaller(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, srv, "GET", "/v1/search?mode=results_only&q=运营", "", asAlice), http.StatusOK)
+	expectStatus(t, call(t, srv, "GET", "/v1/search?mode=results_only&q=品牌", "", asBob), http.StatusOK)
+
+	expectStatus(t, call(t, srv, "GET", "/v1/export/history?format=jsonl", "", nil), http.StatusUnauthorized)
+	resp := call(t, srv, "GET", "/v1/export/history?format=jsonl&caller=user:alice", "", asBob)
+	expectStatus(t, resp, http.StatusOK)
+	if lines := strings.Split(strings.TrimSpace(string(resp.body)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "user:bob") {
+		t.Fatalf("expected bob to export only his own record, got %q", resp.body)
+	}
+	resp = call(t, srv, "GET", "/v1/export/history?format=jsonl", "", asAdmin)
+	if lines := strings.Split(strings.TrimSpace(string(resp.body)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected admin to export every record, got %d", len(lines))
+	}
+}
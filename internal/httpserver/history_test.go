@@ -0,0 +1,65 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"agentgo/internal/history"
+)
+
+type historyPage struct {
+	Records []history.Record `json:"records"`
+}
+
+func TestHistoryIsScopedToCaller(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	for _, c := range []struct {
+		header http.Header
+		q      string
+	}{{asAlice, "运营"}, {asBob, "品牌"}, {nil, "新品"}} {
+		expectStatus(t, call(t, srv, "GET", "/v1/search?mode=results_only&q="+c.q, "", c.header), http.StatusOK)
+	}
+
+	expectStatus(t, call(t, srv, "GET", "/v1/history", "", nil), http.StatusUnauthorized)
+
+	var page historyPage
+	call(t, srv, "GET", "/v1/history?caller=user:bob", "", asAlice).decode(t, &page)
+	if len(page.Records) != 1 || page.Records[0].Caller != "user:alice" {
+		t.Fatalf("expected alice to see only her own record, got %+v", page.Records)
+	}
+	aliceRecord := page.Records[0].ID
+
+	call(t, srv, "GET", "/v1/history", "", asAdmin).decode(t, &page)
+	if len(page.Records) != 3 {
+		t.Fatalf("expected admin to see every record, got %d", len(page.Records))
+	}
+	call(t, srv, "GET", "/v1/history?caller=user:bob", "", asAdmin).decode(t, &page)
+	if len(page.Records) != 1 || page.Records[0].Caller != "user:bob" {
+		t.Fatalf("expected admin caller filter to apply, got %+v", page.Records)
+	}
+	bobRecord := page.Records[0].ID
+
+	expectStatus(t, call(t, srv, "GET", fmt.Sprintf("/v1/history/%d", aliceRecord), "", nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "GET", fmt.Sprintf("/v1/history/%d", bobRecord), "", asAlice), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "GET", fmt.Sprintf("/v1/history/%d", aliceRecord), "", asAlice), http.StatusOK)
+	expectStatus(t, call(t, srv, "GET", fmt.Sprintf("/v1/history/%d", bobRecord), "", asAdmin), http.StatusOK)
+	expectStatus(t, call(t, srv, "GET", "/v1/history/abc", "", asAlice), http.StatusBadRequest)
+}
+
+func TestReplayRequiresRecordOwner(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, srv, "GET", "/v1/search?q=运营", "", asAlice), http.StatusOK)
+	var page historyPage
+	call(t, srv, "GET", "/v1/history", "", asAlice).decode(t, &page)
+	if len(page.Records) != 1 {
+		t.Fatalf("expected one record, got %d", len(page.Records))
+	}
+	path := fmt.Sprintf("/v1/history/%d/replay", page.Records[0].ID)
+
+	expectStatus(t, call(t, srv, "POST", path, "", nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "POST", path, "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "POST", path, "", asAlice), http.StatusOK)
+	expectStatus(t, call(t, srv, "POST", path, "", asAdmin), http.StatusOK)
+	expectStatus(t, call(t, srv, "POST", "/v1/history/999/replay", "", asAdmin), http.StatusNotFound)
+}
@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"agentgo/internal/monitor"
+)
+
+// jobRequest 创建或修改监控任务的请求体，enabled 缺省为 true。
+type jobRequest struct {
+	SavedSearchID uint64 `json:"saved_search_id"`
+	Schedule      string `json:"schedule"`
+	Enabled       *bool  `json:"enabled"`
+}
+
+// requireScheduler 未配置调度器时监控接口不可用。
+func (s *Server) requireScheduler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.Scheduler == nil {
+			s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "scheduler is disabled"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	owner := ownerFrom(r)
+	s.writeJSON(w, http.StatusOK, map[string]any{"jobs": s.cfg.Scheduler.Store().Jobs(owner)})
+}
+
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	owner := ownerFrom(r)
+	req, ok := s.decodeJobRequest(w, r, owner)
+	if !ok {
+		return
+	}
+	job, err := s.cfg.Scheduler.CreateJob(monitor.Job{
+		Owner:         owner,
+		SavedSearchID: req.SavedSearchID,
+		Schedule:      req.Schedule,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+	})
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.jobID(w, r)
+	if !ok {
+		return
+	}
+	job, err := s.cfg.Scheduler.Store().Job(ownerFrom(r), id)
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.jobID(w, r)
+	if !ok {
+		return
+	}
+	owner := ownerFrom(r)
+	req, ok := s.decodeJobRequest(w, r, owner)
+	if !ok {
+		return
+	}
+	job, err := s.cfg.Scheduler.UpdateJob(owner, id, req.SavedSearchID, req.Schedule, req.Enabled == nil || *req.Enabled)
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.jobID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.cfg.Scheduler.DeleteJob(ownerFrom(r), id); err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunJob 立即运行一次任务，返回本次运行记录。
+func (s *Server) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.jobID(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	run, err := s.cfg.Scheduler.RunNow(ctx, ownerFrom(r), id)
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, run)
+}
+
+// handleJobRuns 从新到旧返回任务的运行记录，new_only=true 时每次运行只保留新出现的结果。
+func (s *Server) handleJobRuns(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.jobID(w, r)
+	if !ok {
+		return
+	}
+	runs, err := s.cfg.Scheduler.Store().Runs(ownerFrom(r), id, parseInt(r.URL.Query().Get("limit"), 20))
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	if r.URL.Query().Get("new_only") == "true" {
+		for i, run := range runs {
+			fresh := make([]monitor.Result, 0, run.NewCount)
+			for _, result := range run.Results {
+				if result.New {
+					fresh = append(fresh, result)
+				}
+			}
+			runs[i].Results = fresh
+		}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"runs": runs})
+}
+
+// decodeJobRequest 解析请求体，并确认引用的保存的搜索属于调用方。
+func (s *Server) decodeJobRequest(w http.ResponseWriter, r *http.Request, owner string) (jobRequest, bool) {
+	var req jobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return req, false
+	}
+	if _, err := monitor.ParseSchedule(req.Schedule); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return req, false
+	}
+	if _, err := s.saved.Get(owner, req.SavedSearchID); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Server) jobID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid job id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, monitor.ErrJobNotFound):
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, monitor.ErrJobRunning):
+		s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
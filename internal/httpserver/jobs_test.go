@@ -0,0 +1,124 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+	"agentgo/internal/monitor"
+	"agentgo/internal/saved"
+)
+
+// newJobServer 启动带调度器的测试服务，调度器不启动定时运行，只用于接口调用。
+func newJobServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	searches, _ := saved.NewStore("")
+	jobs, err := monitor.NewStore(monitor.StoreConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var agg *aggregator.Aggregator
+	run := func(ctx context.Context, job monitor.Job) ([]model.Result, error) {
+		return monitor.SavedSearchRunner(agg, searches)(ctx, job)
+	}
+	srv, agg := newTestServer(t, Config{SavedSearches: searches, Scheduler: monitor.New(jobs, run, monitor.Config{})})
+	return srv
+}
+
+// createSaved 以 header 对应的用户创建保存的搜索并返回其 ID。
+func createSaved(t *testing.T, srv *httptest.Server, header http.Header) uint64 {
+	t.Helper()
+	resp := call(t, srv, "POST", "/v1/saved", savedBody, header)
+	expectStatus(t, resp, http.StatusCreated)
+	var search saved.Search
+	resp.decode(t, &search)
+	return search.ID
+}
+
+func TestJobHandlersAreOwnerScoped(t *testing.T) {
+	srv := newJobServer(t)
+	aliceSaved := createSaved(t, srv, asAlice)
+	body := fmt.Sprintf(`{"saved_search_id":%d,"schedule":"@daily"}`, aliceSaved)
+
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs", body, nil), http.StatusUnauthorized)
+	// bob 不能引用 alice 保存的搜索。
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs", body, asBob), http.StatusBadRequest)
+
+	created := call(t, srv, "POST", "/v1/jobs", body, asAlice)
+	expectStatus(t, created, http.StatusCreated)
+	var job monitor.Job
+	created.decode(t, &job)
+	if job.Owner != "user:alice" || !job.Enabled {
+		t.Fatalf("expected an enabled job owned by alice, got %+v", job)
+	}
+	path := fmt.Sprintf("/v1/jobs/%d", job.ID)
+
+	var list struct {
+		Jobs []monitor.Job `json:"jobs"`
+	}
+	call(t, srv, "GET", "/v1/jobs", "", asBob).decode(t, &list)
+	if len(list.Jobs) != 0 {
+		t.Fatalf("expected bob to see no jobs, got %+v", list.Jobs)
+	}
+	bobSaved := createSaved(t, srv, asBob)
+	expectStatus(t, call(t, srv, "GET", path, "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "PUT", path, fmt.Sprintf(`{"saved_search_id":%d,"schedule":"@hourly"}`, bobSaved), asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "POST", path+"/run", "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "GET", path+"/runs", "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "DELETE", path, "", asBob), http.StatusNotFound)
+
+	updated := call(t, srv, "PUT", path, fmt.Sprintf(`{"saved_search_id":%d,"schedule":"@hourly","enabled":false}`, aliceSaved), asAlice)
+	expectStatus(t, updated, http.StatusOK)
+	updated.decode(t, &job)
+	if job.Schedule != "@hourly" || job.Enabled {
+		t.Fatalf("unexpected update result %+v", job)
+	}
+	expectStatus(t, call(t, srv, "DELETE", path, "", asAlice), http.StatusNoContent)
+	expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusNotFound)
+}
+
+func TestJobHandlersRunAndListRuns(t *testing.T) {
+	srv := newJobServer(t)
+	body := fmt.Sprintf(`{"saved_search_id":%d,"schedule":"@daily"}`, createSaved(t, srv, asAlice))
+	var job monitor.Job
+	call(t, srv, "POST", "/v1/jobs", body, asAlice).decode(t, &job)
+	path := fmt.Sprintf("/v1/jobs/%d", job.ID)
+
+	var first, second monitor.Run
+	call(t, srv, "POST", path+"/run", "", asAlice).decode(t, &first)
+	call(t, srv, "POST", path+"/run", "", asAlice).decode(t, &second)
+	if first.Error != "" || first.Total == 0 || first.NewCount != first.Total {
+		t.Fatalf("expected every result of the first run to be new, got %+v", first)
+	}
+	if second.NewCount != 0 {
+		t.Fatalf("expected no new results on an identical second run, got %d", second.NewCount)
+	}
+
+	var runs struct {
+		Runs []monitor.Run `json:"runs"`
+	}
+	call(t, srv, "GET", path+"/runs?new_only=true", "", asAlice).decode(t, &runs)
+	if len(runs.Runs) != 2 || runs.Runs[0].ID != second.ID || len(runs.Runs[0].Results) != 0 || len(runs.Runs[1].Results) != first.Total {
+		t.Fatalf("unexpected runs with new_only: %+v", runs.Runs)
+	}
+}
+
+func TestJobHandlersRejectInvalidInput(t *testing.T) {
+	srv := newJobServer(t)
+	id := createSaved(t, srv, asAlice)
+
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs", "{", asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs", fmt.Sprintf(`{"saved_search_id":%d,"schedule":"every now and then"}`, id), asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs", `{"saved_search_id":999,"schedule":"@daily"}`, asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/jobs/abc", "", asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/jobs/999", "", asAlice), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "POST", "/v1/jobs/999/run", "", asAlice), http.StatusNotFound)
+
+	// 未配置调度器时监控接口返回 503。
+	plain, _ := newTestServer(t, Config{})
+	expectStatus(t, call(t, plain, "GET", "/v1/jobs", "", asAlice), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,127 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"agentgo/internal/aggregator"
+)
+
+// maxRequestIDLength 调用方传入的请求 ID 最大长度，超出或含非法字符时重新生成。
+const maxRequestIDLength = 64
+
+// ownerKey context 中保存通过 API Key 认证的资源归属者。
+type ownerKey struct{}
+
+// withRequestInfo 为每个请求确定请求 ID 与调用方，写入 context 并回写 X-Request-ID 响应头。
+// 调用方通过认证时同时记录为资源归属者，供保存的搜索、监控任务与告警使用。
+func (s *Server) withRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		caller, owner := s.callerIdentity(r)
+		ctx := aggregator.WithRequestInfo(r.Context(), aggregator.RequestInfo{ID: id, Caller: caller})
+		if owner != "" {
+			ctx = context.WithValue(ctx, ownerKey{}, owner)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// callerIdentity 返回调用方标识与认证后的归属者。
+// X-API-Key 与 Config.APIKeys 中的某个 Key 一致时调用方为 "user:<用户名>" 并视为已认证；
+// 未登记的 Key 只保留 SHA-256 前缀用于审计，避免在历史记录中留下明文凭据；没有 Key 时使用客户端 IP。
+func (s *Server) callerIdentity(r *http.Request) (caller, owner string) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		if user, ok := s.lookupAPIKey(key); ok {
+			return "user:" + user, "user:" + user
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:6]), ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if host == "" {
+		return "", ""
+	}
+	return "ip:" + host, ""
+}
+
+// lookupAPIKey 以定长时间比较查找 Key 对应的用户，比较摘要以免泄露 Key 长度。
+func (s *Server) lookupAPIKey(key string) (string, bool) {
+	sum := sha256.Sum256([]byte(key))
+	found := ""
+	for user, candidate := range s.cfg.APIKeys {
+		want := sha256.Sum256([]byte(candidate))
+		if subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+			found = user
+		}
+	}
+	return found, found != ""
+}
+
+// requireOwner 要求调用方通过 API Key 认证，按调用方隔离的接口都经过它。
+func (s *Server) requireOwner(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ownerFrom(r) == "" {
+			s.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "a valid X-API-Key is required"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireOwnerOrAdmin 允许管理员或通过 API Key 认证的调用方访问，
+// 历史记录等包含全部调用方数据的接口经过它，处理函数再用 callerScope 限定范围。
+func (s *Server) requireOwnerOrAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.isAdmin(r) {
+			next(w, r)
+			return
+		}
+		s.requireOwner(next)(w, r)
+	}
+}
+
+// callerScope 返回调用方只能查看的调用方标识：管理员为空（不限），其余为认证后的归属者。
+func (s *Server) callerScope(r *http.Request) string {
+	if s.isAdmin(r) {
+		return ""
+	}
+	return ownerFrom(r)
+}
+
+// ownerFrom 返回认证后的归属者，未认证时为空。
+func ownerFrom(r *http.Request) string {
+	owner, _ := r.Context().Value(ownerKey{}).(string)
+	return owner
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,139 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/saved"
+)
+
+// handleListSaved 列出当前调用方保存的搜索。
+func (s *Server) handleListSaved(w http.ResponseWriter, r *http.Request) {
+	owner := ownerFrom(r)
+	s.writeJSON(w, http.StatusOK, map[string]any{"saved": s.saved.List(owner)})
+}
+
+func (s *Server) handleCreateSaved(w http.ResponseWriter, r *http.Request) {
+	search, err := decodeSavedSearch(w, r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	search.Owner = ownerFrom(r)
+	created, err := s.saved.Create(search)
+	if err != nil {
+		s.writeSavedError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleGetSaved(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.savedID(w, r)
+	if !ok {
+		return
+	}
+	search, err := s.saved.Get(ownerFrom(r), id)
+	if err != nil {
+		s.writeSavedError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, search)
+}
+
+// handleUpdateSaved 用请求体整体替换已保存的搜索。
+func (s *Server) handleUpdateSaved(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.savedID(w, r)
+	if !ok {
+		return
+	}
+	search, err := decodeSavedSearch(w, r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	updated, err := s.saved.Update(ownerFrom(r), id, search)
+	if err != nil {
+		s.writeSavedError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteSaved(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.savedID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.saved.Delete(ownerFrom(r), id); err != nil {
+		s.writeSavedError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunSaved 按保存的定义执行搜索，fresh=true 时绕过缓存。
+func (s *Server) handleRunSaved(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.savedID(w, r)
+	if !ok {
+		return
+	}
+	search, err := s.saved.Get(ownerFrom(r), id)
+	if err != nil {
+		s.writeSavedError(w, err)
+		return
+	}
+	opts := search.Options(time.Now())
+	opts.ForceRefresh = r.URL.Query().Get("fresh") == "true"
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	resp, err := s.aggregator.Search(ctx, search.Query, opts)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// decodeSavedSearch 解析并校验请求体，排序与模式按聚合器的规则校验。
+func decodeSavedSearch(w http.ResponseWriter, r *http.Request) (saved.Search, error) {
+	var search saved.Search
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&search); err != nil {
+		return search, errors.New("invalid JSON body")
+	}
+	if _, ok := aggregator.ParseSort(search.Sort); !ok {
+		return search, errors.New("sort must be time, sentiment or metrics.<name>")
+	}
+	if _, ok := aggregator.ParseMode(search.Filters.Mode); !ok {
+		return search, errors.New("mode must be one of full, results_only, summary_only")
+	}
+	return search, search.Validate()
+}
+
+func (s *Server) savedID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid saved search id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) writeSavedError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, saved.ErrNotFound):
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, saved.ErrDuplicateName):
+		s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		// 参数已在解码时校验，其余错误来自持久化。
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,85 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/saved"
+)
+
+const savedBody = `{"name":"日报","query":"运营","filters":{"since":"24h"},"sort":"time"}`
+
+func TestSavedHandlersRequireAPIKey(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	expectStatus(t, call(t, srv, "GET", "/v1/saved", "", nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "POST", "/v1/saved", savedBody, nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "GET", "/v1/saved", "", http.Header{"X-Api-Key": {"guess"}}), http.StatusUnauthorized)
+	// X-User 不是凭据，不能冒充其他用户。
+	expectStatus(t, call(t, srv, "GET", "/v1/saved", "", http.Header{"X-User": {"alice"}}), http.StatusUnauthorized)
+	expectStatus(t, call(t, srv, "GET", "/v1/saved", "", asAlice), http.StatusOK)
+}
+
+func TestSavedHandlersAreOwnerScoped(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	created := call(t, srv, "POST", "/v1/saved", savedBody, asAlice)
+	expectStatus(t, created, http.StatusCreated)
+	var search saved.Search
+	created.decode(t, &search)
+	if search.Owner != "user:alice" || search.ID == 0 {
+		t.Fatalf("expected alice's saved search, got %+v", search)
+	}
+	path := fmt.Sprintf("/v1/saved/%d", search.ID)
+
+	var list struct {
+		Saved []saved.Search `json:"saved"`
+	}
+	call(t, srv, "GET", "/v1/saved", "", asBob).decode(t, &list)
+	if len(list.Saved) != 0 {
+		t.Fatalf("expected bob to see nothing, got %+v", list.Saved)
+	}
+	expectStatus(t, call(t, srv, "GET", path, "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "PUT", path, savedBody, asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "POST", path+"/run", "", asBob), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "DELETE", path, "", asBob), http.StatusNotFound)
+
+	// 名称只在同一用户内唯一。
+	expectStatus(t, call(t, srv, "POST", "/v1/saved", savedBody, asAlice), http.StatusConflict)
+	expectStatus(t, call(t, srv, "POST", "/v1/saved", savedBody, asBob), http.StatusCreated)
+
+	run := call(t, srv, "POST", path+"/run", "", asAlice)
+	expectStatus(t, run, http.StatusOK)
+	var resp aggregator.Response
+	run.decode(t, &resp)
+	if resp.Query != "运营" {
+		t.Fatalf("expected the saved query to run, got %+v", resp)
+	}
+
+	updated := call(t, srv, "PUT", path, `{"name":"周报","query":"运营","filters":{"since":"168h"}}`, asAlice)
+	expectStatus(t, updated, http.StatusOK)
+	updated.decode(t, &search)
+	if search.Name != "周报" || search.Owner != "user:alice" {
+		t.Fatalf("unexpected update result %+v", search)
+	}
+	expectStatus(t, call(t, srv, "DELETE", path, "", asAlice), http.StatusNoContent)
+	expectStatus(t, call(t, srv, "GET", path, "", asAlice), http.StatusNotFound)
+}
+
+func TestSavedHandlersRejectInvalidInput(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	for _, body := range []string{
+		`{`,
+		`{"name":"日报"}`,
+		`{"name":"日报","query":"运营","sort":"random"}`,
+		`{"name":"日报","query":"运营","filters":{"mode":"everything"}}`,
+	} {
+		expectStatus(t, call(t, srv, "POST", "/v1/saved", body, asAlice), http.StatusBadRequest)
+	}
+	expectStatus(t, call(t, srv, "GET", "/v1/saved/abc", "", asAlice), http.StatusBadRequest)
+	expectStatus(t, call(t, srv, "GET", "/v1/saved/999", "", asAlice), http.StatusNotFound)
+	expectStatus(t, call(t, srv, "PUT", "/v1/saved/999", savedBody, asAlice), http.StatusNotFound)
+}
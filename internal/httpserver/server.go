@@ -3,33 +3,85 @@ package httpserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"agentgo/internal/aggregator"
+	"agentgo/internal/alert"
+	"agentgo/internal/analytics"
+	"agentgo/internal/digest"
+	"agentgo/internal/history"
+	"agentgo/internal/model"
+	"agentgo/internal/monitor"
+	"agentgo/internal/saved"
+	"agentgo/internal/summary"
 )
 
+// Config HTTP 服务配置。
+type Config struct {
+	// AdminToken 管理接口的访问令牌，为空时管理接口不可用。
+	AdminToken string
+	// APIKeys 用户名到 API Key 的映射。保存的搜索、监控任务与告警按认证后的用户隔离，
+	// 没有有效 Key 的请求不能访问这些接口。
+	APIKeys map[string]string
+	// SavedSearches 保存的搜索仓库，为空时使用仅在内存中的仓库。
+	SavedSearches *saved.Store
+	// Scheduler 监控任务调度器，为空时监控接口不可用。
+	Scheduler *monitor.Scheduler
+	// Alerts 告警引擎，为空时告警接口不可用。
+	Alerts *alert.Engine
+	// DigestTemplates 报告模板，为空时使用内置模板。
+	DigestTemplates *digest.Templates
+	// Mailer 报告发信配置，为空时不能通过接口发送报告。
+	Mailer *digest.Mailer
+}
+
 // Server 封装 HTTP 接口。
 type Server struct {
 	aggregator *aggregator.Aggregator
+	analytics  *analytics.Analyzer
+	saved      *saved.Store
+	digest     *digest.Generator
+	cfg        Config
 	mux        *http.ServeMux
 }
 
-// New 创建 HTTP Server。
+// New 创建不开放管理接口的 HTTP Server。
 func New(agg *aggregator.Aggregator) *Server {
+	return NewWithConfig(agg, Config{})
+}
+
+// NewWithConfig 按配置创建 HTTP Server。
+func NewWithConfig(agg *aggregator.Aggregator, cfg Config) *Server {
+	if cfg.SavedSearches == nil {
+		cfg.SavedSearches, _ = saved.NewStore("")
+	}
+	if cfg.DigestTemplates == nil {
+		cfg.DigestTemplates = digest.DefaultTemplates(nil)
+	}
+	var digestCfg digest.Config
+	if cfg.Scheduler != nil {
+		digestCfg.Runs = cfg.Scheduler.Store()
+	}
 	srv := &Server{
 		aggregator: agg,
+		analytics:  analytics.New(analytics.SourceFunc(agg.HistoryRange), analytics.Config{}),
+		saved:      cfg.SavedSearches,
+		digest:     digest.NewGenerator(agg, digestCfg),
+		cfg:        cfg,
 		mux:        http.NewServeMux(),
 	}
 	srv.registerRoutes()
 	return srv
 }
 
-// Handler 返回底层 HTTP 处理器。
+// Handler 返回底层 HTTP 处理器，每个请求都会带上请求 ID、调用方与认证信息。
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return s.withRequestInfo(s.mux)
 }
 
 // Run 启动 HTTP 服务。
@@ -40,8 +92,42 @@ func (s *Server) Run(addr string) error {
 func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/healthz", s.handleHealth)
 	s.mux.HandleFunc("/v1/search", s.handleSearch)
-	s.mux.HandleFunc("/v1/history", s.handleHistory)
+	s.mux.HandleFunc("GET /v1/search/stream", s.handleSearchStream)
+	s.mux.HandleFunc("/v1/history", s.requireOwnerOrAdmin(s.handleHistory))
+	s.mux.HandleFunc("GET /v1/history/{id}", s.requireOwnerOrAdmin(s.handleHistoryRecord))
+	s.mux.HandleFunc("POST /v1/history/{id}/replay", s.requireOwnerOrAdmin(s.handleReplay))
 	s.mux.HandleFunc("/v1/providers", s.handleProviders)
+	s.mux.HandleFunc("GET /v1/saved", s.requireOwner(s.handleListSaved))
+	s.mux.HandleFunc("POST /v1/saved", s.requireOwner(s.handleCreateSaved))
+	s.mux.HandleFunc("GET /v1/saved/{id}", s.requireOwner(s.handleGetSaved))
+	s.mux.HandleFunc("PUT /v1/saved/{id}", s.requireOwner(s.handleUpdateSaved))
+	s.mux.HandleFunc("DELETE /v1/saved/{id}", s.requireOwner(s.handleDeleteSaved))
+	s.mux.HandleFunc("POST /v1/saved/{id}/run", s.requireOwner(s.handleRunSaved))
+	s.mux.HandleFunc("GET /v1/jobs", s.requireOwner(s.requireScheduler(s.handleListJobs)))
+	s.mux.HandleFunc("POST /v1/jobs", s.requireOwner(s.requireScheduler(s.handleCreateJob)))
+	s.mux.HandleFunc("GET /v1/jobs/{id}", s.requireOwner(s.requireScheduler(s.handleGetJob)))
+	s.mux.HandleFunc("PUT /v1/jobs/{id}", s.requireOwner(s.requireScheduler(s.handleUpdateJob)))
+	s.mux.HandleFunc("DELETE /v1/jobs/{id}", s.requireOwner(s.requireScheduler(s.handleDeleteJob)))
+	s.mux.HandleFunc("POST /v1/jobs/{id}/run", s.requireOwner(s.requireScheduler(s.handleRunJob)))
+	s.mux.HandleFunc("GET /v1/jobs/{id}/runs", s.requireOwner(s.requireScheduler(s.handleJobRuns)))
+	s.mux.HandleFunc("GET /v1/alerts", s.requireOwner(s.requireAlerts(s.handleListAlerts)))
+	s.mux.HandleFunc("POST /v1/alerts", s.requireOwner(s.requireAlerts(s.handleCreateAlert)))
+	s.mux.HandleFunc("GET /v1/alerts/{id}", s.requireOwner(s.requireAlerts(s.handleGetAlert)))
+	s.mux.HandleFunc("PUT /v1/alerts/{id}", s.requireOwner(s.requireAlerts(s.handleUpdateAlert)))
+	s.mux.HandleFunc("DELETE /v1/alerts/{id}", s.requireOwner(s.requireAlerts(s.handleDeleteAlert)))
+	s.mux.HandleFunc("GET /v1/alerts/{id}/events", s.requireOwner(s.requireAlerts(s.handleAlertEvents)))
+	s.mux.HandleFunc("POST /v1/alerts/{id}/test", s.requireOwner(s.requireAlerts(s.handleTestAlert)))
+	s.mux.HandleFunc("GET /v1/digest/preview", s.requireAdmin(s.handleDigestPreview))
+	s.mux.HandleFunc("POST /v1/digest/send", s.requireAdmin(s.handleDigestSend))
+	s.mux.HandleFunc("GET /v1/export/search", s.handleExportSearch)
+	s.mux.HandleFunc("GET /v1/export/history", s.requireOwnerOrAdmin(s.handleExportHistory))
+	s.mux.HandleFunc("/v1/analytics/top-queries", s.requireAdmin(s.handleTopQueries))
+	s.mux.HandleFunc("/v1/analytics/rising", s.requireAdmin(s.handleRisingQueries))
+	s.mux.HandleFunc("/v1/analytics/zero-results", s.requireAdmin(s.handleZeroResults))
+	s.mux.HandleFunc("/v1/analytics/slowest", s.requireAdmin(s.handleSlowestQueries))
+	s.mux.HandleFunc("/v1/analytics/providers", s.requireAdmin(s.handleProviderAnalytics))
+	s.mux.HandleFunc("/v1/admin/cache", s.requireAdmin(s.handleAdminCache))
+	s.mux.HandleFunc("/v1/admin/cache/warm", s.requireAdmin(s.handleAdminWarm))
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -52,36 +138,100 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
-	query := strings.TrimSpace(r.URL.Query().Get("q"))
-	if query == "" {
-		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "query parameter `q` is required"})
+	query, opts, err := parseSearchRequest(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
-	limit := parseInt(r.URL.Query().Get("limit"), 10)
-	providers := parseList(r.URL.Query().Get("providers"))
-	forceRefresh := strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("fresh")), "true")
-
 	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
 	defer cancel()
 
-	resp, err := s.aggregator.Search(ctx, query, aggregator.Options{
-		Providers:    providers,
-		Limit:        limit,
-		ForceRefresh: forceRefresh,
-	})
+	resp, err := s.aggregator.Search(ctx, query, opts)
 	if err != nil {
 		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
+	if aspects := parseList(r.URL.Query().Get("aspects")); len(aspects) > 0 && resp.Summary != nil {
+		resp.Summary.Aspects = filterAspects(resp.Summary.Aspects, aspects)
+	}
+
 	s.writeJSON(w, http.StatusOK, resp)
 }
 
+// handleHistory 支持按时间、查询词子串、平台、结果数与耗时筛选，按 sort/order 排序并用 cursor 翻页。
 func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
-	limit := parseInt(r.URL.Query().Get("limit"), 20)
-	records := s.aggregator.History(limit)
-	s.writeJSON(w, http.StatusOK, map[string]any{"records": records})
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if scope := s.callerScope(r); scope != "" {
+		filter.Caller = scope
+	}
+	page, err := s.aggregator.QueryHistory(filter)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, page)
+}
+
+// handleHistoryRecord 返回单条历史记录，包括结果快照。
+func (s *Server) handleHistoryRecord(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid history id"})
+		return
+	}
+	record, ok := s.historyRecord(r, id)
+	if !ok {
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": aggregator.ErrRecordNotFound.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, record)
+}
+
+// historyRecord 返回调用方可以查看的历史记录，其他调用方的记录视为不存在。
+func (s *Server) historyRecord(r *http.Request, id uint64) (history.Record, bool) {
+	record, ok := s.aggregator.HistoryRecord(id)
+	if !ok {
+		return history.Record{}, false
+	}
+	if scope := s.callerScope(r); scope != "" && record.Caller != scope {
+		return history.Record{}, false
+	}
+	return record, true
+}
+
+// handleReplay 按历史记录重新搜索，返回当前结果及与当时结果的差异。
+// 重放绕过缓存，只有记录的调用方本人或管理员可以重放。
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid history id"})
+		return
+	}
+	if _, ok := s.historyRecord(r, id); !ok {
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": aggregator.ErrRecordNotFound.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	replay, err := s.aggregator.Replay(ctx, id)
+	switch {
+	case errors.Is(err, aggregator.ErrRecordNotFound):
+		s.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, aggregator.ErrSnapshotUnavailable):
+		s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		s.writeJSON(w, http.StatusOK, replay)
+	}
 }
 
 func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
@@ -97,6 +247,144 @@ func (s *Server) writeJSON(w http.ResponseWriter, status int, payload any) {
 	_ = encoder.Encode(payload)
 }
 
+// parseSearchRequest 解析搜索接口与导出接口共用的查询参数。
+func parseSearchRequest(r *http.Request) (string, aggregator.Options, error) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		return "", aggregator.Options{}, errors.New("query parameter `q` is required")
+	}
+	mode, ok := aggregator.ParseMode(q.Get("mode"))
+	if !ok {
+		return "", aggregator.Options{}, errors.New("mode must be one of full, results_only, summary_only")
+	}
+	sortBy, ok := aggregator.ParseSort(q.Get("sort"))
+	if !ok {
+		return "", aggregator.Options{}, errors.New("sort must be time, sentiment or metrics.<name>")
+	}
+	start, end, err := parseWindow(r)
+	if err != nil {
+		return "", aggregator.Options{}, err
+	}
+	return query, aggregator.Options{
+		Providers:    parseList(q.Get("providers")),
+		Limit:        parseInt(q.Get("limit"), 10),
+		ForceRefresh: strings.EqualFold(strings.TrimSpace(q.Get("fresh")), "true"),
+		Locale:       requestLocale(r),
+		StartTime:    start,
+		EndTime:      end,
+		Mode:         mode,
+		Sort:         sortBy,
+	}, nil
+}
+
+// parseWindow 解析 start/end 参数，支持 RFC3339 时间或 since=24h 这样的相对窗口。
+func parseWindow(r *http.Request) (time.Time, time.Time, error) {
+	var start, end time.Time
+	q := r.URL.Query()
+	if raw := strings.TrimSpace(q.Get("since")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return start, end, fmt.Errorf("invalid since %q", raw)
+		}
+		// 按分钟取整，使相对窗口在短时间内命中同一缓存。
+		start = time.Now().Add(-d).Truncate(time.Minute)
+	}
+	if raw := strings.TrimSpace(q.Get("start")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid start %q, expected RFC3339", raw)
+		}
+		start = t
+	}
+	if raw := strings.TrimSpace(q.Get("end")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid end %q, expected RFC3339", raw)
+		}
+		end = t
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return start, end, errors.New("end must be after start")
+	}
+	return start, end, nil
+}
+
+// parseHistoryFilter 解析历史查询参数，时间窗口与搜索接口一样支持 start/end 与 since。
+func parseHistoryFilter(r *http.Request) (history.Filter, error) {
+	q := r.URL.Query()
+	start, end, err := parseWindow(r)
+	if err != nil {
+		return history.Filter{}, err
+	}
+	filter := history.Filter{
+		Since:    start,
+		Until:    end,
+		Query:    strings.TrimSpace(q.Get("q")),
+		Provider: strings.TrimSpace(q.Get("provider")),
+		Caller:   strings.TrimSpace(q.Get("caller")),
+		Cursor:   strings.TrimSpace(q.Get("cursor")),
+		Limit:    parseInt(q.Get("limit"), 20),
+	}
+	if raw := q.Get("min_results"); raw != "" {
+		if filter.MinResults, err = strconv.Atoi(raw); err != nil {
+			return filter, fmt.Errorf("invalid min_results %q", raw)
+		}
+	}
+	if raw := q.Get("max_results"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid max_results %q", raw)
+		}
+		filter.MaxResults = &v
+	}
+	if raw := q.Get("min_took"); raw != "" {
+		if filter.MinTook, err = time.ParseDuration(raw); err != nil {
+			return filter, fmt.Errorf("invalid min_took %q", raw)
+		}
+	}
+	if raw := q.Get("max_took"); raw != "" {
+		if filter.MaxTook, err = time.ParseDuration(raw); err != nil {
+			return filter, fmt.Errorf("invalid max_took %q", raw)
+		}
+	}
+	sortBy, ok := history.ParseSort(q.Get("sort"))
+	if !ok {
+		return filter, errors.New("sort must be one of time, results, took")
+	}
+	filter.Sort = sortBy
+	switch strings.ToLower(strings.TrimSpace(q.Get("order"))) {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+	return filter, nil
+}
+
+// requestLocale 优先使用 lang 参数，其次解析 Accept-Language。
+func requestLocale(r *http.Request) string {
+	if locale := summary.NormalizeLocale(r.URL.Query().Get("lang")); locale != "" {
+		return locale
+	}
+	return summary.MatchLocale(r.Header.Get("Accept-Language"))
+}
+
+// filterAspects 只保留请求中指定的维度，名称不区分大小写。
+func filterAspects(aspects []model.AspectSentiment, names []string) []model.AspectSentiment {
+	out := make([]model.AspectSentiment, 0, len(names))
+	for _, a := range aspects {
+		for _, name := range names {
+			if strings.EqualFold(a.Aspect, name) {
+				out = append(out, a)
+				break
+			}
+		}
+	}
+	return out
+}
+
 func parseInt(value string, fallback int) int {
 	if value == "" {
 		return fallback
@@ -0,0 +1,91 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/provider"
+	"agentgo/internal/provider/mock"
+	"agentgo/internal/summary/simple"
+)
+
+const (
+	testAdminToken = "admin-secret"
+	aliceKey       = "alice-key"
+	bobKey         = "bob-key"
+)
+
+var (
+	asAdmin = http.Header{"Authorization": {"Bearer " + testAdminToken}}
+	asAlice = http.Header{"X-Api-Key": {aliceKey}}
+	asBob   = http.Header{"X-Api-Key": {bobKey}}
+)
+
+// newTestServer 使用 mock provider 启动测试服务，未设置时补上管理令牌与 alice、bob 两个用户。
+func newTestServer(t *testing.T, cfg Config) (*httptest.Server, *aggregator.Aggregator) {
+	t.Helper()
+	agg := aggregator.New(map[string]provider.Provider{"mock": mock.New()}, simple.New(), aggregator.Config{CacheTTL: time.Minute})
+	t.Cleanup(func() { agg.Close() })
+	if cfg.AdminToken == "" {
+		cfg.AdminToken = testAdminToken
+	}
+	if cfg.APIKeys == nil {
+		cfg.APIKeys = map[string]string{"alice": aliceKey, "bob": bobKey}
+	}
+	srv := httptest.NewServer(NewWithConfig(agg, cfg).Handler())
+	t.Cleanup(srv.Close)
+	return srv, agg
+}
+
+type testResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// decode 把 JSON 响应解析到 v。
+func (r testResponse) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		t.Fatalf("decode %s: %v", r.body, err)
+	}
+}
+
+// call 发送请求并读取完整响应，header 为空时不带任何凭据。
+func call(t *testing.T, srv *httptest.Server, method, path, body string, header http.Header) testResponse {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testResponse{status: resp.StatusCode, header: resp.Header, body: data}
+}
+
+// expectStatus 校验状态码，不符时输出响应体。
+func expectStatus(t *testing.T, resp testResponse, want int) {
+	t.Helper()
+	if resp.status != want {
+		t.Fatalf("status = %d, want %d: %s", resp.status, want, resp.body)
+	}
+}
@@ -0,0 +1,73 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"agentgo/internal/aggregator"
+)
+
+// sseWriter 以 Server-Sent Events 格式写出事件，第一次写出时才发送响应头，
+// 因此写出任何事件之前仍可以返回普通的 JSON 错误。
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+func (sw *sseWriter) send(event string, payload any) {
+	if !sw.started {
+		sw.started = true
+		h := sw.w.Header()
+		h.Set("Content-Type", "text/event-stream; charset=utf-8")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		sw.w.WriteHeader(http.StatusOK)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+		event = "error"
+	}
+	fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, data)
+	sw.flusher.Flush()
+}
+
+// handleSearchStream 与 /v1/search 参数相同，以 SSE 推送搜索进度：
+// 每个平台返回时发送一个 provider 事件，只带该平台的结果及其在合并排名中的位置；
+// 完整排名只在最后与 /v1/search 响应结构相同的 summary 事件中发送一次。
+func (s *Server) handleSearchStream(w http.ResponseWriter, r *http.Request) {
+	query, opts, err := parseSearchRequest(r)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming is not supported"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	out := &sseWriter{w: w, flusher: flusher}
+	resp, err := s.aggregator.SearchStream(ctx, query, opts, func(u aggregator.ProviderUpdate) {
+		out.send("provider", u)
+	})
+	if err != nil {
+		if !out.started {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		out.send("error", map[string]string{"error": err.Error()})
+		return
+	}
+	if aspects := parseList(r.URL.Query().Get("aspects")); len(aspects) > 0 && resp.Summary != nil {
+		resp.Summary.Aspects = filterAspects(resp.Summary.Aspects, aspects)
+	}
+	out.send("summary", resp)
+}
@@ -0,0 +1,78 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"agentgo/internal/aggregator"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseSSE 按空行拆分事件，每个事件必须恰好由 event 与 data 两行组成。
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	if !strings.HasSuffix(body, "\n\n") {
+		t.Fatalf("stream must end with a blank line: %q", body)
+	}
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n") {
+		lines := strings.Split(block, "\n")
+		if len(lines) != 2 || !strings.HasPrefix(lines[0], "event: ") || !strings.HasPrefix(lines[1], "data: ") {
+			t.Fatalf("malformed event %q", block)
+		}
+		events = append(events, sseEvent{name: strings.TrimPrefix(lines[0], "event: "), data: strings.TrimPrefix(lines[1], "data: ")})
+	}
+	return events
+}
+
+func TestSearchStreamFraming(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	resp := call(t, srv, "GET", "/v1/search/stream?q=运营&sort=time", "", nil)
+	expectStatus(t, resp, http.StatusOK)
+	if ct := resp.header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") || resp.header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("unexpected headers %v", resp.header)
+	}
+	events := parseSSE(t, string(resp.body))
+	if len(events) != 2 || events[0].name != "provider" || events[1].name != "summary" {
+		t.Fatalf("expected one provider event followed by summary, got %+v", events)
+	}
+
+	var update aggregator.ProviderUpdate
+	if err := json.Unmarshal([]byte(events[0].data), &update); err != nil {
+		t.Fatalf("decode provider event: %v", err)
+	}
+	if update.Status.Name != "mock" || update.Completed != 1 || update.Total != 1 || len(update.Positions) != len(update.Results) {
+		t.Fatalf("unexpected provider event %s", events[0].data)
+	}
+	if strings.Contains(events[0].data, `"ranking"`) {
+		t.Fatalf("provider events must not carry the full ranking: %s", events[0].data)
+	}
+	var final aggregator.Response
+	if err := json.Unmarshal([]byte(events[1].data), &final); err != nil {
+		t.Fatalf("decode summary event: %v", err)
+	}
+	if final.Query != "运营" || len(final.Results) == 0 || len(final.Results) != len(update.Results) {
+		t.Fatalf("expected the full ranking in the summary event, got %d results", len(final.Results))
+	}
+}
+
+func TestSearchStreamRejectsBadRequestsBeforeStreaming(t *testing.T) {
+	srv, _ := newTestServer(t, Config{})
+
+	for _, path := range []string{"/v1/search/stream", "/v1/search/stream?q=运营&mode=verbose", "/v1/search/stream?q=运营&sort=random"} {
+		resp := call(t, srv, "GET", path, "", nil)
+		expectStatus(t, resp, http.StatusBadRequest)
+		var body map[string]string
+		resp.decode(t, &body)
+		if body["error"] == "" || strings.HasPrefix(resp.header.Get("Content-Type"), "text/event-stream") {
+			t.Fatalf("expected a JSON error for %s, got %s", path, resp.body)
+		}
+	}
+}
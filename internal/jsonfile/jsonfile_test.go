@@ -0,0 +1,30 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	var got []string
+	if ok, err := Read(path, &got); ok || err != nil {
+		t.Fatalf("missing file should read as absent, got ok=%v err=%v", ok, err)
+	}
+	if err := Write(path, []string{"a", "b"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if ok, err := Read(path, &got); !ok || err != nil || len(got) != 2 || got[1] != "b" {
+		t.Fatalf("read back %v ok=%v err=%v", got, ok, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temp files left behind, got %d entries", len(entries))
+	}
+
+	os.WriteFile(path, []byte("{broken"), 0o644)
+	if _, err := Read(path, &got); err == nil {
+		t.Fatal("expected decode error for a corrupt file")
+	}
+}
@@ -13,15 +13,109 @@ type Result struct {
 	Metrics     map[string]int64  `json:"metrics,omitempty"`
 	Tags        []string          `json:"tags,omitempty"`
 	Extras      map[string]string `json:"extras,omitempty"`
+	Sentiment   *SentimentScore   `json:"sentiment,omitempty"`
+	Entities    []Entity          `json:"entities,omitempty"`
 }
 
-// Summary 聚合后的综述结果。
-type Summary struct {
-	Query           string         `json:"query"`
-	Overview        string         `json:"overview"`
-	Highlights      []string       `json:"highlights"`
+// Key 识别同一条结果，见 ResultKey。
+func (r Result) Key() string {
+	return ResultKey(r.URL, r.Source, r.Title)
+}
+
+// ResultKey 按 URL 识别同一条结果，没有 URL 时退化为来源加标题。
+// 监控任务的新结果判断、告警去重与重放对比都以它为准。
+func ResultKey(url, source, title string) string {
+	if url != "" {
+		return url
+	}
+	return source + "\x00" + title
+}
+
+// Entity 从结果中抽取出的品牌、产品、人物、@提及或话题。
+type Entity struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// EntityMention 实体在整个结果集中的提及统计。
+type EntityMention struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Mentions int    `json:"mentions"`
+	Results  int    `json:"results"`
+}
+
+// SentimentScore 单条结果的情绪评分。
+type SentimentScore struct {
+	Score float64 `json:"score"`
+	Label string  `json:"label"`
+}
+
+// SentimentStats 结果集的情绪分布。
+type SentimentStats struct {
+	Positive  int     `json:"positive"`
+	Neutral   int     `json:"neutral"`
+	Negative  int     `json:"negative"`
+	MeanScore float64 `json:"mean_score"`
+}
+
+// AspectSentiment 某个关注维度（价格、质量、产品名等）的情绪统计。
+type AspectSentiment struct {
+	Aspect    string  `json:"aspect"`
+	Mentions  int     `json:"mentions"`
+	Results   int     `json:"results"`
+	Positive  int     `json:"positive"`
+	Neutral   int     `json:"neutral"`
+	Negative  int     `json:"negative"`
+	MeanScore float64 `json:"mean_score"`
+}
+
+// TopicCluster 摘要中的一个话题簇。
+type TopicCluster struct {
+	Label           string         `json:"label"`
 	Keywords        []string       `json:"keywords"`
-	Sentiment       string         `json:"sentiment"`
+	Members         []int          `json:"members"`
+	Size            int            `json:"size"`
 	SourceBreakdown map[string]int `json:"source_breakdown"`
-	GeneratedAt     time.Time      `json:"generated_at"`
+}
+
+// Timeline 按时间桶统计的结果分布。
+type Timeline struct {
+	Granularity string           `json:"granularity"`
+	Buckets     []TimelineBucket `json:"buckets"`
+	Bursts      []Burst          `json:"bursts,omitempty"`
+}
+
+// TimelineBucket 单个时间桶内的结果数量。
+type TimelineBucket struct {
+	Start    time.Time      `json:"start"`
+	Total    int            `json:"total"`
+	BySource map[string]int `json:"by_source"`
+}
+
+// Burst 某个来源在一段时间内的声量突增。
+type Burst struct {
+	Source   string    `json:"source"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Count    int       `json:"count"`
+	Baseline float64   `json:"baseline"`
+}
+
+// Summary 聚合后的综述结果。
+type Summary struct {
+	Query           string            `json:"query"`
+	Locale          string            `json:"locale,omitempty"`
+	Overview        string            `json:"overview"`
+	Highlights      []string          `json:"highlights"`
+	Keywords        []string          `json:"keywords"`
+	Topics          []TopicCluster    `json:"topics,omitempty"`
+	Entities        []EntityMention   `json:"entities,omitempty"`
+	Sentiment       string            `json:"sentiment"`
+	SentimentStats  SentimentStats    `json:"sentiment_stats"`
+	Aspects         []AspectSentiment `json:"aspects,omitempty"`
+	SourceBreakdown map[string]int    `json:"source_breakdown"`
+	Timeline        *Timeline         `json:"timeline,omitempty"`
+	GeneratedAt     time.Time         `json:"generated_at"`
 }
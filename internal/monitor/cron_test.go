@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	from := time.Date(2026, 10, 18, 9, 30, 20, 0, shanghai) // 周日
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 18, 9, 45, 0, 0, shanghai)},
+		{"0 8 * * *", time.Date(2026, 10, 19, 8, 0, 0, 0, shanghai)},
+		{"0 8,20 * * *", time.Date(2026, 10, 18, 20, 0, 0, 0, shanghai)},
+		{"30 9 * * 1-5", time.Date(2026, 10, 19, 9, 30, 0, 0, shanghai)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, shanghai)},
+		{"0 12 * * 7", time.Date(2026, 10, 18, 12, 0, 0, 0, shanghai)},
+		{"0 0 13 * 5", time.Date(2026, 10, 23, 0, 0, 0, 0, shanghai)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, shanghai)},
+		{"@hourly", time.Date(2026, 10, 18, 10, 0, 0, 0, shanghai)},
+		{"@every 90m", time.Date(2026, 10, 18, 11, 0, 0, 0, shanghai)},
+	}
+	for _, c := range cases {
+		schedule, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(c.want) {
+			t.Fatalf("%q: next=%v, want %v", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestParseScheduleRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "@every 10s", "@every soon", "0 0 31 2 x"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestScheduleImpossibleDate(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected no next run for Feb 31, got %v", next)
+	}
+}
@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/model"
+	"agentgo/internal/saved"
+)
+
+// SavedSearchRunner 返回按任务引用的保存的搜索调用聚合器的 Runner。
+// 监控需要最新数据，运行总是绕过缓存；保留摘要以便结果带上情绪标注，供负面占比等告警使用。
+// 历史记录中的调用方为任务的 owner。
+func SavedSearchRunner(agg *aggregator.Aggregator, searches *saved.Store) Runner {
+	return func(ctx context.Context, job Job) ([]model.Result, error) {
+		search, err := searches.Get(job.Owner, job.SavedSearchID)
+		if err != nil {
+			return nil, err
+		}
+		opts := search.Options(time.Now())
+		opts.ForceRefresh = true
+		opts.Mode = aggregator.ModeFull
+		ctx = aggregator.WithRequestInfo(ctx, aggregator.RequestInfo{
+			ID:     fmt.Sprintf("job-%d-%d", job.ID, time.Now().UnixNano()),
+			Caller: job.Owner,
+		})
+		resp, err := agg.Search(ctx, search.Query, opts)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	}
+}
@@ -0,0 +1,257 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// ErrJobRunning 同一任务的上一次运行尚未结束。
+var ErrJobRunning = errors.New("job is already running")
+
+// Runner 执行任务对应的搜索并返回结果。
+type Runner func(ctx context.Context, job Job) ([]model.Result, error)
+
+// Config 调度器配置。
+type Config struct {
+	// Location 解释调度表达式的时区，默认 time.Local。
+	Location *time.Location
+	// MaxConcurrent 同时运行的任务数上限，默认 4。
+	MaxConcurrent int
+	// RunTimeout 单次运行的超时时间，默认 1 分钟。
+	RunTimeout time.Duration
+}
+
+// Scheduler 按任务的调度表达式定期调用 Runner，并把结果写入 Store。
+//
+// 同一任务不会并发运行；到期时上一次运行仍未结束则跳过本次。
+// 服务停止期间错过的运行不会补跑，重启后从当前时间起计算下一次运行。
+type Scheduler struct {
+	store   *Store
+	run     Runner
+	cfg     Config
+	sem     chan struct{}
+	wake    chan struct{}
+	stop    chan struct{}
+	running map[uint64]bool
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// New 创建调度器，调用 Start 后开始按计划运行。
+func New(store *Store, run Runner, cfg Config) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 4
+	}
+	if cfg.RunTimeout <= 0 {
+		cfg.RunTimeout = time.Minute
+	}
+	return &Scheduler{
+		store:   store,
+		run:     run,
+		cfg:     cfg,
+		sem:     make(chan struct{}, cfg.MaxConcurrent),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		running: map[uint64]bool{},
+	}
+}
+
+// Store 返回任务与运行记录仓库。
+func (s *Scheduler) Store() *Store {
+	return s.store
+}
+
+// Start 重新计算所有任务的下一次运行时间并启动调度循环。
+func (s *Scheduler) Start() {
+	now := time.Now()
+	for _, job := range s.store.allJobs() {
+		_, _ = s.store.updateJob("", job.ID, func(j *Job) {
+			j.NextRun = s.nextRun(*j, now)
+		})
+	}
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Close 停止调度并等待进行中的运行结束。
+func (s *Scheduler) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	s.wg.Wait()
+	return nil
+}
+
+// CreateJob 校验调度表达式并创建任务。
+func (s *Scheduler) CreateJob(job Job) (Job, error) {
+	if _, err := ParseSchedule(job.Schedule); err != nil {
+		return Job{}, err
+	}
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.LastRun = time.Time{}
+	job.NextRun = s.nextRun(job, now)
+	created, err := s.store.createJob(job)
+	if err == nil {
+		s.notify()
+	}
+	return created, err
+}
+
+// UpdateJob 修改任务的保存的搜索、调度表达式与启用状态。
+func (s *Scheduler) UpdateJob(owner string, id uint64, savedSearchID uint64, schedule string, enabled bool) (Job, error) {
+	if _, err := ParseSchedule(schedule); err != nil {
+		return Job{}, err
+	}
+	now := time.Now()
+	job, err := s.store.updateJob(owner, id, func(j *Job) {
+		j.SavedSearchID = savedSearchID
+		j.Schedule = schedule
+		j.Enabled = enabled
+		j.UpdatedAt = now
+		j.NextRun = s.nextRun(*j, now)
+	})
+	if err == nil {
+		s.notify()
+	}
+	return job, err
+}
+
+// DeleteJob 删除任务及其运行记录。
+func (s *Scheduler) DeleteJob(owner string, id uint64) error {
+	return s.store.deleteJob(owner, id)
+}
+
+// RunNow 立即运行一次任务并返回运行记录，不影响下一次计划运行时间。
+func (s *Scheduler) RunNow(ctx context.Context, owner string, id uint64) (Run, error) {
+	job, err := s.store.Job(owner, id)
+	if err != nil {
+		return Run{}, err
+	}
+	if !s.acquire(id) {
+		return Run{}, ErrJobRunning
+	}
+	defer s.release(id)
+	return s.execute(ctx, job)
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-timer.C:
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		now := time.Now()
+		due, next := s.store.due(now)
+		for _, job := range due {
+			s.dispatch(job, now)
+		}
+		// 没有任务时也定期醒来，防止系统时钟跳变后错过运行。
+		wait := time.Minute
+		if !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		timer.Reset(wait)
+	}
+}
+
+// dispatch 先推进任务的下一次运行时间再异步运行，上一次运行未结束时跳过。
+func (s *Scheduler) dispatch(job Job, now time.Time) {
+	_, err := s.store.updateJob("", job.ID, func(j *Job) {
+		j.NextRun = s.nextRun(*j, now)
+	})
+	if err != nil || !s.acquire(job.ID) {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(job.ID)
+		select {
+		case s.sem <- struct{}{}:
+			defer func() { <-s.sem }()
+		case <-s.stop:
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-s.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		_, _ = s.execute(ctx, job)
+	}()
+}
+
+func (s *Scheduler) execute(ctx context.Context, job Job) (Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.RunTimeout)
+	defer cancel()
+	started := time.Now()
+	results, runErr := s.run(ctx, job)
+	run, err := s.store.addRun(job.ID, started, time.Since(started), results, runErr)
+	if err != nil {
+		return run, fmt.Errorf("save run: %w", err)
+	}
+	_, _ = s.store.updateJob("", job.ID, func(j *Job) {
+		j.LastRun = started
+	})
+	return run, nil
+}
+
+// nextRun 计算任务在 now 之后的下一次运行时间，停用或表达式无效时返回零值。
+func (s *Scheduler) nextRun(job Job, now time.Time) time.Time {
+	if !job.Enabled {
+		return time.Time{}
+	}
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(now.In(s.cfg.Location))
+}
+
+func (s *Scheduler) acquire(id uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[id] {
+		return false
+	}
+	s.running[id] = true
+	return true
+}
+
+func (s *Scheduler) release(id uint64) {
+	s.mu.Lock()
+	delete(s.running, id)
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
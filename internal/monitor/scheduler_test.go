@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// scriptedRunner 依次返回预设的结果，nil 表示这一次运行失败。
+type scriptedRunner struct {
+	mu      sync.Mutex
+	batches [][]model.Result
+	calls   int
+	done    chan struct{}
+}
+
+func (r *scriptedRunner) run(_ context.Context, _ Job) ([]model.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer func() {
+		if r.done != nil {
+			r.done <- struct{}{}
+		}
+	}()
+	r.calls++
+	if r.calls > len(r.batches) || r.batches[r.calls-1] == nil {
+		return nil, errors.New("provider down")
+	}
+	return r.batches[r.calls-1], nil
+}
+
+func results(urls ...string) []model.Result {
+	out := make([]model.Result, len(urls))
+	for i, u := range urls {
+		out[i] = model.Result{Title: u, URL: "https://" + u, Source: "zhihu"}
+	}
+	return out
+}
+
+func TestSchedulerTracksNewResults(t *testing.T) {
+	store, _ := NewStore(StoreConfig{})
+	runner := &scriptedRunner{batches: [][]model.Result{results("a", "b"), nil, results("b", "c")}}
+	sched := New(store, runner.run, Config{})
+	job, err := sched.CreateJob(Job{Owner: "user:alice", SavedSearchID: 1, Schedule: "0 8 * * *", Enabled: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.NextRun.IsZero() {
+		t.Fatalf("expected next run computed")
+	}
+	ctx := context.Background()
+
+	first, err := sched.RunNow(ctx, "user:alice", job.ID)
+	if err != nil || first.Total != 2 || first.NewCount != 2 {
+		t.Fatalf("expected all results new on first run, got %+v err=%v", first, err)
+	}
+	// 失败的运行不作为下一次比较的基准。
+	failed, _ := sched.RunNow(ctx, "user:alice", job.ID)
+	if failed.Error == "" {
+		t.Fatalf("expected failed run recorded, got %+v", failed)
+	}
+	third, _ := sched.RunNow(ctx, "user:alice", job.ID)
+	if third.Total != 2 || third.NewCount != 1 || third.Results[0].New || !third.Results[1].New {
+		t.Fatalf("expected only c new relative to last successful run, got %+v", third)
+	}
+
+	runs, err := store.Runs("user:alice", job.ID, 2)
+	if err != nil || len(runs) != 2 || runs[0].ID != third.ID {
+		t.Fatalf("expected newest runs first, got %+v err=%v", runs, err)
+	}
+	if _, err := sched.RunNow(ctx, "user:bob", job.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected other owner to be denied, got %v", err)
+	}
+}
+
+func TestSchedulerRunsDueJobs(t *testing.T) {
+	store, _ := NewStore(StoreConfig{})
+	runner := &scriptedRunner{batches: [][]model.Result{results("a")}, done: make(chan struct{}, 1)}
+	sched := New(store, runner.run, Config{})
+	job, _ := sched.CreateJob(Job{Owner: "user:alice", Schedule: "@hourly", Enabled: true})
+	sched.Start()
+	defer sched.Close()
+
+	// 把下一次运行时间拨到过去，模拟任务到期。
+	store.updateJob("", job.ID, func(j *Job) { j.NextRun = time.Now().Add(-time.Second) })
+	sched.notify()
+	select {
+	case <-runner.done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected due job to run")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ := store.Job("user:alice", job.ID)
+		if !got.LastRun.IsZero() {
+			if !got.NextRun.After(time.Now()) {
+				t.Fatalf("expected next run advanced, got %v", got.NextRun)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected last run recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	disabled, _ := sched.UpdateJob("user:alice", job.ID, 0, "@hourly", false)
+	if !disabled.NextRun.IsZero() {
+		t.Fatalf("expected disabled job to have no next run")
+	}
+}
+
+func TestStorePersistsJobsAndRuns(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(StoreConfig{Dir: dir, MaxRuns: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner := &scriptedRunner{batches: [][]model.Result{results("a"), results("a", "b"), results("b", "c"), results("c")}}
+	sched := New(store, runner.run, Config{})
+	job, _ := sched.CreateJob(Job{Owner: "user:alice", Schedule: "0 8 * * *", Enabled: true})
+	for i := 0; i < 4; i++ {
+		if _, err := sched.RunNow(context.Background(), "user:alice", job.ID); err != nil {
+			t.Fatalf("unexpected run error: %v", err)
+		}
+	}
+	store.Close()
+
+	reopened, err := NewStore(StoreConfig{Dir: dir, MaxRuns: 2})
+	if err != nil {
+		t.Fatalf("unexpected reopen error: %v", err)
+	}
+	defer reopened.Close()
+	runs, err := reopened.Runs("user:alice", job.ID, 0)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("expected 2 retained runs, got %d err=%v", len(runs), err)
+	}
+	if runs[0].Total != 1 || runs[0].NewCount != 0 {
+		t.Fatalf("unexpected latest run after reload: %+v", runs[0])
+	}
+
+	// 重新加载后继续以上一次运行为基准。
+	runner.batches = append(runner.batches, results("c", "d"))
+	sched = New(reopened, runner.run, Config{})
+	run, _ := sched.RunNow(context.Background(), "user:alice", job.ID)
+	if run.NewCount != 1 || run.ID <= runs[0].ID {
+		t.Fatalf("unexpected run after reload: %+v", run)
+	}
+}
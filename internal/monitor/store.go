@@ -0,0 +1,388 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agentgo/internal/jsonfile"
+	"agentgo/internal/model"
+)
+
+const (
+	jobsFileName = "jobs.json"
+	runsFileName = "runs.jsonl"
+)
+
+// ErrJobNotFound 任务不存在或不属于调用方。
+var ErrJobNotFound = errors.New("job not found")
+
+// Job 按调度表达式定期运行一条保存的搜索。
+type Job struct {
+	ID            uint64    `json:"id"`
+	Owner         string    `json:"owner"`
+	SavedSearchID uint64    `json:"saved_search_id"`
+	Schedule      string    `json:"schedule"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	LastRun       time.Time `json:"last_run,omitzero"`
+	NextRun       time.Time `json:"next_run,omitzero"`
+}
+
+// Run 一次运行的结果，New 标记上一次成功运行中没有出现过的结果。
+type Run struct {
+	ID       uint64        `json:"id"`
+	JobID    uint64        `json:"job_id"`
+	Started  time.Time     `json:"started"`
+	Took     time.Duration `json:"took"`
+	Total    int           `json:"total"`
+	NewCount int           `json:"new_count"`
+	Error    string        `json:"error,omitempty"`
+	Results  []Result      `json:"results"`
+}
+
+// Result 运行中的一条结果。
+type Result struct {
+	model.Result
+	New bool `json:"new"`
+}
+
+// StoreConfig 任务与运行记录仓库配置。
+type StoreConfig struct {
+	// Dir 持久化目录，为空时只保存在内存中。
+	Dir string
+	// MaxRuns 每个任务保留的运行记录数，默认 50。
+	MaxRuns int
+}
+
+// Store 保存任务定义与每个任务最近的运行记录。
+//
+// 任务定义整体写入 jobs.json；运行记录追加到 runs.jsonl，
+// 文件中过期的记录超过保留数量时整体重写。
+type Store struct {
+	cfg       StoreConfig
+	jobs      map[uint64]Job
+	runs      map[uint64][]Run
+	nextJobID uint64
+	nextRunID uint64
+	runLines  int
+	runFile   *os.File
+	mu        sync.RWMutex
+}
+
+// NewStore 创建仓库，配置了 Dir 时先加载已有任务与运行记录。
+func NewStore(cfg StoreConfig) (*Store, error) {
+	if cfg.MaxRuns <= 0 {
+		cfg.MaxRuns = 50
+	}
+	s := &Store{cfg: cfg, jobs: map[uint64]Job{}, runs: map[uint64][]Run{}, nextJobID: 1, nextRunID: 1}
+	if cfg.Dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.rewriteRuns(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close 关闭运行记录文件。
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runFile == nil {
+		return nil
+	}
+	err := s.runFile.Close()
+	s.runFile = nil
+	return err
+}
+
+// Jobs 按 ID 顺序返回 owner 的任务。
+func (s *Store) Jobs(owner string) []Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Job, 0)
+	for _, job := range s.jobs {
+		if job.Owner == owner {
+			out = append(out, job)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Job 返回 owner 的某个任务。
+func (s *Store) Job(owner string, id uint64) (Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok || job.Owner != owner {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Runs 从新到旧返回任务最近 limit 次运行，limit<=0 时返回全部保留的记录。
+func (s *Store) Runs(owner string, id uint64, limit int) ([]Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok || job.Owner != owner {
+		return nil, ErrJobNotFound
+	}
+	runs := s.runs[id]
+	if limit <= 0 || limit > len(runs) {
+		limit = len(runs)
+	}
+	out := make([]Run, 0, limit)
+	for i := len(runs) - 1; i >= len(runs)-limit; i-- {
+		out = append(out, runs[i])
+	}
+	return out, nil
+}
+
+func (s *Store) createJob(job Job) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = s.nextJobID
+	s.jobs[job.ID] = job
+	if err := s.saveJobs(); err != nil {
+		delete(s.jobs, job.ID)
+		return Job{}, err
+	}
+	s.nextJobID++
+	return job, nil
+}
+
+// updateJob 在写锁内修改 owner 的任务，owner 为空时不校验归属，供调度器内部使用。
+func (s *Store) updateJob(owner string, id uint64, update func(*Job)) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.jobs[id]
+	if !ok || (owner != "" && prev.Owner != owner) {
+		return Job{}, ErrJobNotFound
+	}
+	job := prev
+	update(&job)
+	s.jobs[id] = job
+	if err := s.saveJobs(); err != nil {
+		s.jobs[id] = prev
+		return Job{}, err
+	}
+	return job, nil
+}
+
+func (s *Store) deleteJob(owner string, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.jobs[id]
+	if !ok || prev.Owner != owner {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	if err := s.saveJobs(); err != nil {
+		s.jobs[id] = prev
+		return err
+	}
+	delete(s.runs, id)
+	return nil
+}
+
+// due 返回已到期的启用任务，以及其余任务中最早的下一次运行时间。
+func (s *Store) due(now time.Time) ([]Job, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var due []Job
+	var next time.Time
+	for _, job := range s.jobs {
+		if !job.Enabled || job.NextRun.IsZero() {
+			continue
+		}
+		if !job.NextRun.After(now) {
+			due = append(due, job)
+		} else if next.IsZero() || job.NextRun.Before(next) {
+			next = job.NextRun
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	return due, next
+}
+
+// addRun 与上一次成功运行比较并标记新结果，然后保存运行记录。第一次运行的结果全部视为新结果。
+func (s *Store) addRun(jobID uint64, started time.Time, took time.Duration, results []model.Result, runErr error) (Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[jobID]; !ok {
+		return Run{}, ErrJobNotFound
+	}
+
+	run := Run{ID: s.nextRunID, JobID: jobID, Started: started, Took: took, Results: []Result{}}
+	s.nextRunID++
+	if runErr != nil {
+		run.Error = runErr.Error()
+	} else {
+		var previous map[string]bool
+		history := s.runs[jobID]
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Error == "" {
+				previous = make(map[string]bool, len(history[i].Results))
+				for _, r := range history[i].Results {
+					previous[r.Result.Key()] = true
+				}
+				break
+			}
+		}
+		run.Results = make([]Result, len(results))
+		for i, r := range results {
+			isNew := !previous[r.Key()]
+			run.Results[i] = Result{Result: r, New: isNew}
+			if isNew {
+				run.NewCount++
+			}
+		}
+		run.Total = len(results)
+	}
+
+	runs := append(s.runs[jobID], run)
+	if len(runs) > s.cfg.MaxRuns {
+		runs = append([]Run(nil), runs[len(runs)-s.cfg.MaxRuns:]...)
+	}
+	s.runs[jobID] = runs
+	return run, s.appendRun(run)
+}
+
+func (s *Store) load() error {
+	var jobs []Job
+	if _, err := jsonfile.Read(filepath.Join(s.cfg.Dir, jobsFileName), &jobs); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		s.jobs[job.ID] = job
+		if job.ID >= s.nextJobID {
+			s.nextJobID = job.ID + 1
+		}
+	}
+
+	f, err := os.Open(filepath.Join(s.cfg.Dir, runsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for scanner.Scan() {
+		var run Run
+		// 崩溃时可能留下半行，跳过无法解析的记录。
+		if json.Unmarshal(scanner.Bytes(), &run) != nil {
+			continue
+		}
+		if run.ID >= s.nextRunID {
+			s.nextRunID = run.ID + 1
+		}
+		if _, ok := s.jobs[run.JobID]; ok {
+			s.runs[run.JobID] = append(s.runs[run.JobID], run)
+		}
+	}
+	for id, runs := range s.runs {
+		if len(runs) > s.cfg.MaxRuns {
+			s.runs[id] = runs[len(runs)-s.cfg.MaxRuns:]
+		}
+	}
+	return scanner.Err()
+}
+
+// saveJobs 先写临时文件再重命名。调用方需持有写锁。
+func (s *Store) saveJobs() error {
+	if s.cfg.Dir == "" {
+		return nil
+	}
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jsonfile.Write(filepath.Join(s.cfg.Dir, jobsFileName), jobs)
+}
+
+// appendRun 追加一条运行记录，文件中的记录数超过保留数的两倍时重写。调用方需持有写锁。
+func (s *Store) appendRun(run Run) error {
+	if s.cfg.Dir == "" {
+		return nil
+	}
+	kept := 0
+	for _, runs := range s.runs {
+		kept += len(runs)
+	}
+	if s.runLines+1 > 2*kept {
+		return s.rewriteRuns()
+	}
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if s.runFile == nil {
+		if s.runFile, err = os.OpenFile(filepath.Join(s.cfg.Dir, runsFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return err
+		}
+	}
+	if _, err := s.runFile.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.runLines++
+	return nil
+}
+
+// rewriteRuns 只写出仍保留的运行记录。调用方需持有写锁或处于初始化阶段。
+func (s *Store) rewriteRuns() error {
+	var buf []byte
+	lines := 0
+	ids := make([]uint64, 0, len(s.runs))
+	for id := range s.runs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		for _, run := range s.runs[id] {
+			line, err := json.Marshal(run)
+			if err != nil {
+				return err
+			}
+			buf = append(append(buf, line...), '\n')
+			lines++
+		}
+	}
+	if s.runFile != nil {
+		s.runFile.Close()
+		s.runFile = nil
+	}
+	if err := jsonfile.WriteAtomic(filepath.Join(s.cfg.Dir, runsFileName), buf); err != nil {
+		return err
+	}
+	s.runLines = lines
+	return nil
+}
+
+func (s *Store) allJobs() []Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		out = append(out, job)
+	}
+	return out
+}
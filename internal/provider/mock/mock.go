@@ -107,6 +107,12 @@ func (p *Provider) Search(_ context.Context, query string, opts provider.SearchO
 	query = strings.ToLower(strings.TrimSpace(query))
 	matched := make([]model.Result, 0)
 	for _, item := range p.data {
+		if !opts.StartTime.IsZero() && item.PublishedAt.Before(opts.StartTime) {
+			continue
+		}
+		if !opts.EndTime.IsZero() && !item.PublishedAt.Before(opts.EndTime) {
+			continue
+		}
 		if query == "" || strings.Contains(strings.ToLower(item.Title), query) || strings.Contains(strings.ToLower(item.Summary), query) {
 			matched = append(matched, item)
 		}
@@ -0,0 +1,237 @@
+package saved
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agentgo/internal/aggregator"
+	"agentgo/internal/jsonfile"
+)
+
+// 保存的搜索相关错误。
+var (
+	ErrNotFound      = errors.New("saved search not found")
+	ErrDuplicateName = errors.New("saved search name already exists")
+)
+
+// Search 一条保存的搜索定义，仅对创建者可见。
+type Search struct {
+	ID        uint64    `json:"id"`
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Providers []string  `json:"providers,omitempty"`
+	Filters   Filters   `json:"filters"`
+	Sort      string    `json:"sort,omitempty"`
+	Limit     int       `json:"limit,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Filters 运行时附加的筛选条件。
+type Filters struct {
+	// Since 相对时间窗口，如 24h，运行时换算为 [now-Since, now)，与 StartTime 互斥。
+	Since     string    `json:"since,omitempty"`
+	StartTime time.Time `json:"start_time,omitzero"`
+	EndTime   time.Time `json:"end_time,omitzero"`
+	Locale    string    `json:"locale,omitempty"`
+	Mode      string    `json:"mode,omitempty"`
+}
+
+// Window 返回运行时的发布时间窗口，零值表示不限。
+func (f Filters) Window(now time.Time) (time.Time, time.Time) {
+	if d, err := time.ParseDuration(f.Since); err == nil && d > 0 {
+		// 与搜索接口一致按分钟取整，便于命中缓存。
+		return now.Add(-d).Truncate(time.Minute), f.EndTime
+	}
+	return f.StartTime, f.EndTime
+}
+
+// Options 把保存的定义换算为本次运行的搜索参数。
+func (s Search) Options(now time.Time) aggregator.Options {
+	start, end := s.Filters.Window(now)
+	return aggregator.Options{
+		Providers: s.Providers,
+		Limit:     s.Limit,
+		Locale:    s.Filters.Locale,
+		StartTime: start,
+		EndTime:   end,
+		Mode:      s.Filters.Mode,
+		Sort:      s.Sort,
+	}
+}
+
+// Validate 检查名称、查询词与时间窗口，排序与模式由调用方按聚合器的规则校验。
+func (s *Search) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Query = strings.TrimSpace(s.Query)
+	switch {
+	case s.Name == "":
+		return errors.New("name is required")
+	case s.Query == "":
+		return errors.New("query is required")
+	case s.Limit < 0:
+		return errors.New("limit must not be negative")
+	}
+	if s.Filters.Since != "" {
+		d, err := time.ParseDuration(s.Filters.Since)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid since %q", s.Filters.Since)
+		}
+		if !s.Filters.StartTime.IsZero() {
+			return errors.New("since and start_time are mutually exclusive")
+		}
+	}
+	if !s.Filters.StartTime.IsZero() && !s.Filters.EndTime.IsZero() && !s.Filters.EndTime.After(s.Filters.StartTime) {
+		return errors.New("end_time must be after start_time")
+	}
+	return nil
+}
+
+// Store 保存的搜索仓库，配置了文件路径时每次修改后整体写回文件。
+type Store struct {
+	path     string
+	searches map[uint64]Search
+	nextID   uint64
+	mu       sync.RWMutex
+}
+
+// NewStore 创建仓库，path 为空时只保存在内存中，否则从 path 加载已有定义。
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, searches: map[uint64]Search{}, nextID: 1}
+	if path == "" {
+		return s, nil
+	}
+	var searches []Search
+	if _, err := jsonfile.Read(path, &searches); err != nil {
+		return nil, err
+	}
+	for _, search := range searches {
+		s.searches[search.ID] = search
+		if search.ID >= s.nextID {
+			s.nextID = search.ID + 1
+		}
+	}
+	return s, nil
+}
+
+// List 按名称顺序返回 owner 的全部定义。
+func (s *Store) List(owner string) []Search {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Search, 0)
+	for _, search := range s.searches {
+		if search.Owner == owner {
+			out = append(out, search)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Get 返回 owner 的某条定义，不属于 owner 时视为不存在。
+func (s *Store) Get(owner string, id uint64) (Search, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	search, ok := s.searches[id]
+	if !ok || search.Owner != owner {
+		return Search{}, ErrNotFound
+	}
+	return search, nil
+}
+
+// Create 校验并保存新定义，同一 owner 下名称不能重复。
+func (s *Store) Create(search Search) (Search, error) {
+	if err := search.Validate(); err != nil {
+		return Search{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(search.Owner, search.Name, 0) {
+		return Search{}, ErrDuplicateName
+	}
+	now := time.Now()
+	search.ID = s.nextID
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	s.searches[search.ID] = search
+	if err := s.persist(); err != nil {
+		delete(s.searches, search.ID)
+		return Search{}, err
+	}
+	s.nextID++
+	return search, nil
+}
+
+// Update 用 search 整体替换 owner 的某条定义，保留 ID、owner 与创建时间。
+func (s *Store) Update(owner string, id uint64, search Search) (Search, error) {
+	if err := search.Validate(); err != nil {
+		return Search{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.searches[id]
+	if !ok || prev.Owner != owner {
+		return Search{}, ErrNotFound
+	}
+	if s.nameTaken(owner, search.Name, id) {
+		return Search{}, ErrDuplicateName
+	}
+	search.ID = id
+	search.Owner = owner
+	search.CreatedAt = prev.CreatedAt
+	search.UpdatedAt = time.Now()
+	s.searches[id] = search
+	if err := s.persist(); err != nil {
+		s.searches[id] = prev
+		return Search{}, err
+	}
+	return search, nil
+}
+
+// Delete 删除 owner 的某条定义。
+func (s *Store) Delete(owner string, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.searches[id]
+	if !ok || prev.Owner != owner {
+		return ErrNotFound
+	}
+	delete(s.searches, id)
+	if err := s.persist(); err != nil {
+		s.searches[id] = prev
+		return err
+	}
+	return nil
+}
+
+func (s *Store) nameTaken(owner, name string, except uint64) bool {
+	for id, search := range s.searches {
+		if id != except && search.Owner == owner && strings.EqualFold(search.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// persist 先写临时文件再重命名，避免写到一半崩溃时损坏已有定义。调用方需持有写锁。
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	searches := make([]Search, 0, len(s.searches))
+	for _, search := range s.searches {
+		searches = append(searches, search)
+	}
+	sort.Slice(searches, func(i, j int) bool { return searches[i].ID < searches[j].ID })
+	return jsonfile.Write(s.path, searches)
+}
@@ -0,0 +1,105 @@
+package saved
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreCRUDScopedByOwner(t *testing.T) {
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created, err := store.Create(Search{Owner: "user:alice", Name: "品牌 A", Query: "品牌A", Limit: 20})
+	if err != nil {
+		t.Fatalf("unexpected create error: %v", err)
+	}
+	if created.ID == 0 || created.CreatedAt.IsZero() {
+		t.Fatalf("expected id and timestamps assigned, got %+v", created)
+	}
+	if _, err := store.Create(Search{Owner: "user:alice", Name: "品牌 a", Query: "x"}); !errors.Is(err, ErrDuplicateName) {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+	if _, err := store.Create(Search{Owner: "user:bob", Name: "品牌 A", Query: "品牌A"}); err != nil {
+		t.Fatalf("expected other owner to reuse the name, got %v", err)
+	}
+
+	if _, err := store.Get("user:bob", created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other owner to be denied, got %v", err)
+	}
+	if got := store.List("user:alice"); len(got) != 1 || got[0].ID != created.ID {
+		t.Fatalf("unexpected list: %+v", got)
+	}
+
+	updated, err := store.Update("user:alice", created.ID, Search{Owner: "user:bob", Name: "品牌 A", Query: "品牌A 新品", Sort: "metrics.likes"})
+	if err != nil {
+		t.Fatalf("unexpected update error: %v", err)
+	}
+	if updated.Owner != "user:alice" || updated.Query != "品牌A 新品" || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("unexpected update result: %+v", updated)
+	}
+	if err := store.Delete("user:bob", created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other owner delete to fail, got %v", err)
+	}
+	if err := store.Delete("user:alice", created.ID); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if len(store.List("user:alice")) != 0 {
+		t.Fatalf("expected search deleted")
+	}
+}
+
+func TestStoreValidation(t *testing.T) {
+	store, _ := NewStore("")
+	cases := []Search{
+		{Name: "", Query: "q"},
+		{Name: "n", Query: " "},
+		{Name: "n", Query: "q", Filters: Filters{Since: "yesterday"}},
+		{Name: "n", Query: "q", Filters: Filters{Since: "24h", StartTime: time.Now()}},
+	}
+	for _, c := range cases {
+		if _, err := store.Create(c); err == nil {
+			t.Fatalf("expected %+v to be rejected", c)
+		}
+	}
+}
+
+func TestStorePersistsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved", "searches.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, _ := store.Create(Search{Owner: "user:alice", Name: "a", Query: "品牌A"})
+	second, _ := store.Create(Search{Owner: "user:alice", Name: "b", Query: "品牌B", Filters: Filters{Since: "24h"}})
+	if err := store.Delete("user:alice", first.ID); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("unexpected reopen error: %v", err)
+	}
+	got := reopened.List("user:alice")
+	if len(got) != 1 || got[0].ID != second.ID || got[0].Filters.Since != "24h" {
+		t.Fatalf("unexpected reloaded searches: %+v", got)
+	}
+	third, _ := reopened.Create(Search{Owner: "user:alice", Name: "c", Query: "品牌C"})
+	if third.ID <= second.ID {
+		t.Fatalf("expected ids to keep increasing after reload, got %d", third.ID)
+	}
+}
+
+func TestFiltersWindow(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 45, 0, time.UTC)
+	start, end := Filters{Since: "24h"}.Window(now)
+	if !start.Equal(time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)) || !end.IsZero() {
+		t.Fatalf("unexpected relative window: %v %v", start, end)
+	}
+	fixed := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if start, _ := (Filters{StartTime: fixed}).Window(now); !start.Equal(fixed) {
+		t.Fatalf("unexpected fixed window: %v", start)
+	}
+}
@@ -0,0 +1,254 @@
+package cluster
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Document 参与聚类的单条文本。
+type Document struct {
+	Text string
+	Tags []string
+}
+
+// Cluster 一个话题簇。
+type Cluster struct {
+	// Members 成员在输入切片中的下标，升序排列。
+	Members []int
+	// Keywords 按权重排序的话题关键词。
+	Keywords []string
+	// Representative 与簇中心最相似的成员下标。
+	Representative int
+}
+
+// Config 聚类参数。
+type Config struct {
+	// Threshold 平均相似度低于该值的两个簇不再合并。
+	Threshold float64
+	// MaxKeywords 每个簇保留的关键词数量。
+	MaxKeywords int
+}
+
+const tagWeight = 2.0
+
+// stopRunes 含有这些虚词的二元组不作为特征。
+const stopRunes = "的了和在是与及或等也都就而从对为以之其这那有个们着把被让"
+
+// Group 基于 TF-IDF 向量做平均链接的层次聚类，输出按簇大小降序排列。
+func Group(docs []Document, cfg Config) []Cluster {
+	if len(docs) == 0 {
+		return nil
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.12
+	}
+	if cfg.MaxKeywords <= 0 {
+		cfg.MaxKeywords = 3
+	}
+
+	vectors := vectorize(docs)
+	sim := make([][]float64, len(vectors))
+	for i := range vectors {
+		sim[i] = make([]float64, len(vectors))
+		for j := range vectors {
+			if i != j {
+				sim[i][j] = cosine(vectors[i], vectors[j])
+			}
+		}
+	}
+
+	groups := make([][]int, len(docs))
+	for i := range docs {
+		groups[i] = []int{i}
+	}
+	for len(groups) > 1 {
+		bestI, bestJ, best := -1, -1, cfg.Threshold
+		for i := 0; i < len(groups); i++ {
+			for j := i + 1; j < len(groups); j++ {
+				s := averageLinkage(sim, groups[i], groups[j])
+				if s >= best && (bestI < 0 || s > best) {
+					bestI, bestJ, best = i, j, s
+				}
+			}
+		}
+		if bestI < 0 {
+			break
+		}
+		merged := append(append([]int(nil), groups[bestI]...), groups[bestJ]...)
+		sort.Ints(merged)
+		groups[bestI] = merged
+		groups = append(groups[:bestJ], groups[bestJ+1:]...)
+	}
+
+	clusters := make([]Cluster, 0, len(groups))
+	for _, members := range groups {
+		centroid := map[string]float64{}
+		for _, m := range members {
+			for term, w := range vectors[m] {
+				centroid[term] += w
+			}
+		}
+		clusters = append(clusters, Cluster{
+			Members:        members,
+			Keywords:       topTerms(centroid, cfg.MaxKeywords),
+			Representative: representative(members, vectors, centroid),
+		})
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].Members) == len(clusters[j].Members) {
+			return clusters[i].Members[0] < clusters[j].Members[0]
+		}
+		return len(clusters[i].Members) > len(clusters[j].Members)
+	})
+	return clusters
+}
+
+// Terms 将文本切分为聚类特征：中文取二元组，其余按单词切分。
+func Terms(text string) []string {
+	terms := make([]string, 0)
+	var han []rune
+	flushHan := func() {
+		for i := 0; i+1 < len(han); i++ {
+			if strings.ContainsRune(stopRunes, han[i]) || strings.ContainsRune(stopRunes, han[i+1]) {
+				continue
+			}
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	var word []rune
+	flushWord := func() {
+		if len(word) >= 2 {
+			terms = append(terms, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushHan()
+			flushWord()
+		}
+	}
+	flushHan()
+	flushWord()
+	return terms
+}
+
+func vectorize(docs []Document) []map[string]float64 {
+	tfs := make([]map[string]float64, len(docs))
+	df := map[string]int{}
+	for i, doc := range docs {
+		tf := map[string]float64{}
+		for _, t := range Terms(doc.Text) {
+			tf[t]++
+		}
+		for _, tag := range doc.Tags {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				tf[tag] += tagWeight
+			}
+		}
+		for t := range tf {
+			df[t]++
+		}
+		tfs[i] = tf
+	}
+
+	n := float64(len(docs))
+	for _, tf := range tfs {
+		var norm float64
+		for t, v := range tf {
+			w := v * (math.Log((1+n)/(1+float64(df[t]))) + 1)
+			tf[t] = w
+			norm += w * w
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for t := range tf {
+			tf[t] /= norm
+		}
+	}
+	return tfs
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for t, v := range a {
+		dot += v * b[t]
+	}
+	return dot
+}
+
+func averageLinkage(sim [][]float64, a, b []int) float64 {
+	var total float64
+	for _, i := range a {
+		for _, j := range b {
+			total += sim[i][j]
+		}
+	}
+	return total / float64(len(a)*len(b))
+}
+
+// topTerms 按权重挑选关键词，跳过与已选词共享字符的二元组碎片。
+func topTerms(weights map[string]float64, limit int) []string {
+	terms := make([]string, 0, len(weights))
+	for t := range weights {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if weights[terms[i]] == weights[terms[j]] {
+			return terms[i] < terms[j]
+		}
+		return weights[terms[i]] > weights[terms[j]]
+	})
+
+	out := make([]string, 0, limit)
+	for _, t := range terms {
+		if len(out) == limit {
+			break
+		}
+		overlap := false
+		for _, chosen := range out {
+			if sharesHan(chosen, t) {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func sharesHan(a, b string) bool {
+	for _, r := range b {
+		if unicode.Is(unicode.Han, r) && strings.ContainsRune(a, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func representative(members []int, vectors []map[string]float64, centroid map[string]float64) int {
+	best, bestScore := members[0], -1.0
+	for _, m := range members {
+		if s := cosine(vectors[m], centroid); s > bestScore {
+			best, bestScore = m, s
+		}
+	}
+	return best
+}
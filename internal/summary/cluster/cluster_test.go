@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupSeparatesTopics(t *testing.T) {
+	docs := []Document{
+		{Text: "品牌私域增长的三个关键步骤", Tags: []string{"私域"}},
+		{Text: "舆情监测工具如何发现负面声量"},
+		{Text: "私域增长复盘：社群运营与复购", Tags: []string{"私域"}},
+		{Text: "品牌舆情监测的全流程方法"},
+	}
+	clusters := Group(docs, Config{})
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d: %+v", len(clusters), clusters)
+	}
+	got := [][]int{clusters[0].Members, clusters[1].Members}
+	want := [][]int{{0, 2}, {1, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected members: %v", got)
+	}
+	if clusters[0].Keywords[0] != "私域" {
+		t.Fatalf("expected 私域 as top keyword, got %v", clusters[0].Keywords)
+	}
+}
+
+func TestGroupDeterministic(t *testing.T) {
+	docs := []Document{{Text: "alpha beta"}, {Text: "gamma delta"}, {Text: "alpha beta gamma"}}
+	first := Group(docs, Config{})
+	for i := 0; i < 5; i++ {
+		if again := Group(docs, Config{}); !reflect.DeepEqual(first, again) {
+			t.Fatalf("expected deterministic output, got %+v vs %+v", first, again)
+		}
+	}
+}
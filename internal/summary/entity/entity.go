@@ -0,0 +1,230 @@
+package entity
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"agentgo/internal/model"
+)
+
+// 实体类型。
+const (
+	TypeBrand   = "brand"
+	TypeProduct = "product"
+	TypePerson  = "person"
+	TypeMention = "mention"
+	TypeHashtag = "hashtag"
+)
+
+var (
+	// hashtagPattern 匹配 #话题# 形式，兼容小红书的 #话题[话题]# 写法。
+	hashtagPattern = regexp.MustCompile(`#([^#\s]{1,40}?)(?:\[话题\])?#`)
+	// tagPattern 匹配未闭合的 #tag 写法。
+	tagPattern     = regexp.MustCompile(`#([\p{L}\p{N}_]{1,40})`)
+	mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_\-·]{1,30})`)
+)
+
+// Entry 词表中的一个实体及其别名。
+type Entry struct {
+	Name    string
+	Type    string
+	Aliases []string
+}
+
+// Gazetteer 由用户维护的品牌、产品与人物词表。
+type Gazetteer struct {
+	entries []Entry
+	index   map[string]int
+	maxLen  int
+}
+
+// NewGazetteer 根据词条构建词表。
+func NewGazetteer(entries ...Entry) *Gazetteer {
+	g := &Gazetteer{index: map[string]int{}}
+	g.Add(entries...)
+	return g
+}
+
+// DefaultGazetteer 返回内置的平台名称词表。
+func DefaultGazetteer() *Gazetteer {
+	return NewGazetteer(
+		Entry{Name: "知乎", Type: TypeBrand},
+		Entry{Name: "微信", Type: TypeBrand, Aliases: []string{"公众号", "WeChat"}},
+		Entry{Name: "小红书", Type: TypeBrand, Aliases: []string{"RED", "xiaohongshu"}},
+		Entry{Name: "抖音", Type: TypeBrand, Aliases: []string{"Douyin", "TikTok"}},
+		Entry{Name: "微博", Type: TypeBrand, Aliases: []string{"Weibo"}},
+		Entry{Name: "哔哩哔哩", Type: TypeBrand, Aliases: []string{"B站", "bilibili"}},
+	)
+}
+
+// LoadGazetteerFile 从文件读取词表。
+func LoadGazetteerFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadGazetteer(f)
+}
+
+// LoadGazetteer 解析形如 “brand|名称|别名1,别名2” 的词表，每行一个实体。
+func LoadGazetteer(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("line %d: expected type|name[|aliases]", lineNo)
+		}
+		entry := Entry{
+			Type: strings.ToLower(strings.TrimSpace(parts[0])),
+			Name: strings.TrimSpace(parts[1]),
+		}
+		switch entry.Type {
+		case TypeBrand, TypeProduct, TypePerson:
+		default:
+			return nil, fmt.Errorf("line %d: unknown entity type %q", lineNo, entry.Type)
+		}
+		if entry.Name == "" {
+			return nil, fmt.Errorf("line %d: empty entity name", lineNo)
+		}
+		if len(parts) > 2 {
+			for _, alias := range strings.Split(parts[2], ",") {
+				if alias = strings.TrimSpace(alias); alias != "" {
+					entry.Aliases = append(entry.Aliases, alias)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Add 追加词条，同名或同别名的旧词条会被覆盖。
+func (g *Gazetteer) Add(entries ...Entry) {
+	for _, entry := range entries {
+		idx := len(g.entries)
+		g.entries = append(g.entries, entry)
+		for _, surface := range append([]string{entry.Name}, entry.Aliases...) {
+			key := strings.ToLower(surface)
+			g.index[key] = idx
+			if n := utf8.RuneCountInString(key); n > g.maxLen {
+				g.maxLen = n
+			}
+		}
+	}
+}
+
+// Extractor 基于词表与规则抽取实体。
+type Extractor struct {
+	gazetteer *Gazetteer
+}
+
+// NewExtractor 创建实体抽取器，g 为空时使用内置词表。
+func NewExtractor(g *Gazetteer) *Extractor {
+	if g == nil {
+		g = DefaultGazetteer()
+	}
+	return &Extractor{gazetteer: g}
+}
+
+// Extract 抽取文本中的实体，按出现次数降序返回。
+func (e *Extractor) Extract(text string) []model.Entity {
+	counts := map[entityKey]int{}
+	order := make([]entityKey, 0)
+	add := func(k entityKey) {
+		if _, ok := counts[k]; !ok {
+			order = append(order, k)
+		}
+		counts[k]++
+	}
+
+	for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		add(entityKey{name: m[1], typ: TypeHashtag})
+	}
+	rest := hashtagPattern.ReplaceAllString(text, " ")
+	for _, m := range tagPattern.FindAllStringSubmatch(rest, -1) {
+		add(entityKey{name: m[1], typ: TypeHashtag})
+	}
+	for _, m := range mentionPattern.FindAllStringSubmatch(rest, -1) {
+		add(entityKey{name: m[1], typ: TypeMention})
+	}
+	rest = mentionPattern.ReplaceAllString(tagPattern.ReplaceAllString(rest, " "), " ")
+	for _, entry := range e.gazetteer.scan(rest) {
+		add(entityKey{name: entry.Name, typ: entry.Type})
+	}
+
+	out := make([]model.Entity, 0, len(order))
+	for _, k := range order {
+		out = append(out, model.Entity{Name: k.name, Type: k.typ, Count: counts[k]})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+	return out
+}
+
+type entityKey struct {
+	name string
+	typ  string
+}
+
+// scan 对文本做正向最长匹配，返回命中的词条。
+func (g *Gazetteer) scan(text string) []Entry {
+	runes := []rune(strings.ToLower(text))
+	hits := make([]Entry, 0)
+	for i := 0; i < len(runes); {
+		matched := 0
+		maxLen := g.maxLen
+		if rest := len(runes) - i; rest < maxLen {
+			maxLen = rest
+		}
+		for n := maxLen; n > 0; n-- {
+			idx, ok := g.index[string(runes[i:i+n])]
+			if !ok || !atBoundary(runes, i, i+n) {
+				continue
+			}
+			hits = append(hits, g.entries[idx])
+			matched = n
+			break
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		i += matched
+	}
+	return hits
+}
+
+// atBoundary 英文词条要求前后不是字母或数字，避免 “RED” 命中 “reduce”。
+func atBoundary(runes []rune, start, end int) bool {
+	if !isASCIIAlnum(runes[start]) && !isASCIIAlnum(runes[end-1]) {
+		return true
+	}
+	if start > 0 && isASCIIAlnum(runes[start-1]) && isASCIIAlnum(runes[start]) {
+		return false
+	}
+	if end < len(runes) && isASCIIAlnum(runes[end]) && isASCIIAlnum(runes[end-1]) {
+		return false
+	}
+	return true
+}
+
+func isASCIIAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"agentgo/internal/model"
+)
+
+func TestExtract(t *testing.T) {
+	entries, err := LoadGazetteer(strings.NewReader("brand|星巴克|Starbucks,星爸爸\nproduct|燕麦拿铁|\nperson|李佳琦|李佳琪\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g := DefaultGazetteer()
+	g.Add(entries...)
+	e := NewExtractor(g)
+
+	text := "@咖啡日记 今天在星爸爸喝了燕麦拿铁，Starbucks 新品不错 #秋季限定[话题]# #咖啡# 李佳琦直播间也在卖 #coffee"
+	got := e.Extract(text)
+	want := map[model.Entity]bool{
+		{Name: "星巴克", Type: TypeBrand, Count: 2}:      true,
+		{Name: "燕麦拿铁", Type: TypeProduct, Count: 1}:   true,
+		{Name: "李佳琦", Type: TypePerson, Count: 1}:     true,
+		{Name: "咖啡日记", Type: TypeMention, Count: 1}:   true,
+		{Name: "秋季限定", Type: TypeHashtag, Count: 1}:   true,
+		{Name: "咖啡", Type: TypeHashtag, Count: 1}:     true,
+		{Name: "coffee", Type: TypeHashtag, Count: 1}: true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entities, got %+v", len(want), got)
+	}
+	for _, ent := range got {
+		if !want[ent] {
+			t.Fatalf("unexpected entity %+v in %+v", ent, got)
+		}
+	}
+	if got[0].Name != "星巴克" {
+		t.Fatalf("expected most frequent entity first, got %+v", got[0])
+	}
+}
+
+func TestExtractASCIIBoundary(t *testing.T) {
+	e := NewExtractor(nil)
+	if got := e.Extract("We need to reduce costs"); len(got) != 0 {
+		t.Fatalf("expected no entities, got %+v", got)
+	}
+	if got := e.Extract("在小红书 RED 上发布"); len(got) != 1 || got[0].Count != 2 {
+		t.Fatalf("expected 小红书 counted twice, got %+v", got)
+	}
+}
+
+func TestLoadGazetteerErrors(t *testing.T) {
+	if _, err := LoadGazetteer(strings.NewReader("company|某公司\n")); err == nil {
+		t.Fatalf("expected error for unknown type")
+	}
+	if _, err := LoadGazetteer(strings.NewReader("brand\n")); err == nil {
+		t.Fatalf("expected error for missing name")
+	}
+}
@@ -0,0 +1,89 @@
+package summary
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持的摘要语言。
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleZhTW = "zh-TW"
+	LocaleEN   = "en"
+)
+
+// DefaultLocale 未指定语言时使用的摘要语言。
+const DefaultLocale = LocaleZhCN
+
+// Options 单次摘要请求的参数，通过 context 传递给 Summarizer。
+type Options struct {
+	Locale string
+	// Start、End 为查询的时间窗口，零值表示不限。
+	Start time.Time
+	End   time.Time
+}
+
+type optionsKey struct{}
+
+// WithOptions 将摘要参数附加到 context。
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// OptionsFrom 读取 context 中的摘要参数。
+func OptionsFrom(ctx context.Context) Options {
+	opts, _ := ctx.Value(optionsKey{}).(Options)
+	return opts
+}
+
+// NormalizeLocale 将语言标签归一为支持的语言，无法识别时返回空字符串。
+func NormalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	switch {
+	case tag == "":
+		return ""
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return LocaleEN
+	case tag == "zh-tw" || tag == "zh-hk" || tag == "zh-mo" || strings.HasPrefix(tag, "zh-hant"):
+		return LocaleZhTW
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return LocaleZhCN
+	default:
+		return ""
+	}
+}
+
+// MatchLocale 按 q 值解析 Accept-Language，返回第一个受支持的语言。
+func MatchLocale(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	candidates := make([]candidate, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag != "" && q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if locale := NormalizeLocale(c.tag); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
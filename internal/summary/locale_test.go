@@ -0,0 +1,20 @@
+package summary
+
+import "testing"
+
+func TestMatchLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                           "",
+		"en-US,en;q=0.9":             LocaleEN,
+		"fr-FR,zh-TW;q=0.8,en;q=0.5": LocaleZhTW,
+		"zh-Hant-HK":                 LocaleZhTW,
+		"ja,zh;q=0.7":                LocaleZhCN,
+		"en;q=0.3,zh-CN;q=0.9":       LocaleZhCN,
+		"de-DE,fr;q=0.5":             "",
+	}
+	for header, want := range cases {
+		if got := MatchLocale(header); got != want {
+			t.Errorf("MatchLocale(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
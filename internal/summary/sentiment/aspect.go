@@ -0,0 +1,79 @@
+package sentiment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Aspect 描述一个需要单独统计情绪的维度，例如价格、质量或某个产品名。
+type Aspect struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+// DefaultAspects 返回内置的通用维度。
+func DefaultAspects() []Aspect {
+	return []Aspect{
+		{Name: "价格", Keywords: []string{"价格", "价钱", "定价", "售价", "性价比", "贵", "便宜", "划算"}},
+		{Name: "质量", Keywords: []string{"质量", "品质", "做工", "材质", "耐用", "缺陷", "故障"}},
+		{Name: "服务", Keywords: []string{"服务", "客服", "售后", "物流", "发货", "退款", "退货"}},
+	}
+}
+
+// ParseAspects 解析形如 “价格=价格,贵,便宜;质量=质量,做工” 的维度定义。
+func ParseAspects(raw string) ([]Aspect, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	aspects := make([]Aspect, 0)
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, words, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid aspect definition %q", part)
+		}
+		aspect := Aspect{Name: name}
+		for _, w := range strings.Split(words, ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				aspect.Keywords = append(aspect.Keywords, w)
+			}
+		}
+		if len(aspect.Keywords) == 0 {
+			return nil, fmt.Errorf("aspect %q has no keywords", name)
+		}
+		aspects = append(aspects, aspect)
+	}
+	return aspects, nil
+}
+
+// ScoreAspect 只对提及维度关键词的分句打分，返回得分与关键词出现次数。
+func (s *Scorer) ScoreAspect(text string, aspect Aspect) (Score, int) {
+	var (
+		raw      float64
+		hits     int
+		mentions int
+	)
+	for _, clause := range splitClauses(strings.ToLower(text)) {
+		n := 0
+		for _, kw := range aspect.Keywords {
+			n += strings.Count(clause, strings.ToLower(kw))
+		}
+		if n == 0 {
+			continue
+		}
+		mentions += n
+		score := s.Score(clause)
+		raw += score.Raw
+		hits += score.Hits
+	}
+	return Score{Value: normalize(raw), Raw: raw, Hits: hits}, mentions
+}
+
+func splitClauses(text string) []string {
+	return strings.FieldsFunc(text, isClauseBreak)
+}
@@ -0,0 +1,209 @@
+package sentiment
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed default_lexicon.txt
+var defaultLexicon string
+
+type termKind int
+
+const (
+	kindWord termKind = iota
+	kindNegation
+	kindDegree
+	kindEmoji
+)
+
+type term struct {
+	kind   termKind
+	weight float64
+}
+
+// Lexicon 情感词典，包含情感词、否定词、程度副词与表情符号的极性。
+type Lexicon struct {
+	Words     map[string]float64
+	Negations map[string]struct{}
+	Degrees   map[string]float64
+	Emoji     map[string]float64
+
+	terms  map[string]term
+	maxLen int
+}
+
+// NewLexicon 创建空词典。
+func NewLexicon() *Lexicon {
+	return &Lexicon{
+		Words:     map[string]float64{},
+		Negations: map[string]struct{}{},
+		Degrees:   map[string]float64{},
+		Emoji:     map[string]float64{},
+	}
+}
+
+// DefaultLexicon 返回内置的默认词典。
+func DefaultLexicon() *Lexicon {
+	lex, err := LoadLexicon(strings.NewReader(defaultLexicon))
+	if err != nil {
+		panic("sentiment: invalid default lexicon: " + err.Error())
+	}
+	return lex
+}
+
+// LoadLexiconFile 从文件读取词典。
+func LoadLexiconFile(path string) (*Lexicon, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadLexicon(f)
+}
+
+// LoadLexicon 解析分段文本格式的词典。
+//
+// 段名为 [sentiment]、[negation]、[degree]、[emoji]，未声明段名的词条视为情感词。
+func LoadLexicon(r io.Reader) (*Lexicon, error) {
+	lex := NewLexicon()
+	section := "sentiment"
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			switch section {
+			case "sentiment", "negation", "degree", "emoji":
+			default:
+				return nil, fmt.Errorf("line %d: unknown section %q", lineNo, section)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := strings.ToLower(fields[0])
+		if section == "negation" {
+			lex.Negations[word] = struct{}{}
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: missing weight for %q", lineNo, word)
+		}
+		weight, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid weight %q", lineNo, fields[1])
+		}
+		switch section {
+		case "sentiment":
+			lex.Words[word] = weight
+		case "degree":
+			lex.Degrees[word] = weight
+		case "emoji":
+			lex.Emoji[word] = weight
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lex, nil
+}
+
+// Merge 将 other 中的词条合并进当前词典，同名词条以 other 为准。
+func (l *Lexicon) Merge(other *Lexicon) {
+	if other == nil {
+		return
+	}
+	for k, v := range other.Words {
+		l.Words[k] = v
+	}
+	for k := range other.Negations {
+		l.Negations[k] = struct{}{}
+	}
+	for k, v := range other.Degrees {
+		l.Degrees[k] = v
+	}
+	for k, v := range other.Emoji {
+		l.Emoji[k] = v
+	}
+	if l.terms != nil {
+		l.index()
+	}
+}
+
+// index 构建统一的词条索引，用于最长匹配。
+func (l *Lexicon) index() {
+	l.terms = make(map[string]term, len(l.Words)+len(l.Negations)+len(l.Degrees)+len(l.Emoji))
+	l.maxLen = 0
+	add := func(word string, t term) {
+		l.terms[word] = t
+		if n := utf8.RuneCountInString(word); n > l.maxLen {
+			l.maxLen = n
+		}
+	}
+	for k := range l.Negations {
+		add(k, term{kind: kindNegation})
+	}
+	for k, v := range l.Degrees {
+		add(k, term{kind: kindDegree, weight: v})
+	}
+	for k, v := range l.Words {
+		add(k, term{kind: kindWord, weight: v})
+	}
+	for k, v := range l.Emoji {
+		add(k, term{kind: kindEmoji, weight: v})
+	}
+}
+
+// match 在 runes[pos:] 处做正向最长匹配，返回命中词条及其长度。
+func (l *Lexicon) match(runes []rune, pos int) (term, int) {
+	maxLen := l.maxLen
+	if rest := len(runes) - pos; rest < maxLen {
+		maxLen = rest
+	}
+	for n := maxLen; n > 0; n-- {
+		t, ok := l.terms[string(runes[pos:pos+n])]
+		if !ok {
+			continue
+		}
+		if isASCIIWord(runes[pos:pos+n]) && !atWordBoundary(runes, pos, pos+n) {
+			continue
+		}
+		return t, n
+	}
+	return term{}, 0
+}
+
+func isASCIIWord(runes []rune) bool {
+	for _, r := range runes {
+		if !isASCIILetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func atWordBoundary(runes []rune, start, end int) bool {
+	if start > 0 && isASCIILetter(runes[start-1]) {
+		return false
+	}
+	if end < len(runes) && isASCIILetter(runes[end]) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package sentiment
+
+import (
+	"math"
+	"strings"
+)
+
+// 情绪标签。
+const (
+	Positive = "positive"
+	Neutral  = "neutral"
+	Negative = "negative"
+)
+
+const (
+	// modifierWindow 否定词、程度副词与情感词之间允许间隔的最大字符数。
+	modifierWindow = 3
+	// negationFactor 否定后的极性衰减，“不好”通常弱于“差”。
+	negationFactor = 0.8
+	// normalizeAlpha 归一化平滑系数，原始分越大越趋近 ±1。
+	normalizeAlpha = 4
+	// neutralThreshold 归一化得分绝对值低于该值时视为中性。
+	neutralThreshold = 0.1
+)
+
+// Score 单段文本的情绪评分。
+type Score struct {
+	// Value 归一化到 [-1, 1] 的得分。
+	Value float64
+	// Raw 未归一化的累计分值。
+	Raw float64
+	// Hits 命中的情感词与表情数量。
+	Hits int
+}
+
+// Label 返回得分对应的情绪标签。
+func (s Score) Label() string {
+	return LabelOf(s.Value)
+}
+
+// LabelOf 将归一化得分映射为情绪标签。
+func LabelOf(value float64) string {
+	switch {
+	case value >= neutralThreshold:
+		return Positive
+	case value <= -neutralThreshold:
+		return Negative
+	default:
+		return Neutral
+	}
+}
+
+// Scorer 基于词典为文本打分，支持否定、程度副词与表情。
+type Scorer struct {
+	lex *Lexicon
+}
+
+// NewScorer 使用给定词典创建评分器，lex 为空时使用默认词典。
+func NewScorer(lex *Lexicon) *Scorer {
+	if lex == nil {
+		lex = DefaultLexicon()
+	}
+	lex.index()
+	return &Scorer{lex: lex}
+}
+
+// Score 计算文本的情绪得分。
+func (s *Scorer) Score(text string) Score {
+	runes := []rune(strings.ToLower(text))
+	var (
+		raw      float64
+		hits     int
+		negated  int
+		degree   = 1.0
+		modEnd   = -1
+		resetMod = func() {
+			negated = 0
+			degree = 1
+			modEnd = -1
+		}
+	)
+
+	for i := 0; i < len(runes); {
+		if isClauseBreak(runes[i]) {
+			resetMod()
+			i++
+			continue
+		}
+		t, n := s.lex.match(runes, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		if modEnd >= 0 && i-modEnd > modifierWindow {
+			resetMod()
+		}
+
+		switch t.kind {
+		case kindNegation:
+			negated++
+			modEnd = i + n
+		case kindDegree:
+			degree *= t.weight
+			modEnd = i + n
+		case kindWord:
+			value := t.weight * degree
+			if negated%2 == 1 {
+				value = -value * negationFactor
+			}
+			raw += value
+			hits++
+			resetMod()
+		case kindEmoji:
+			raw += t.weight
+			hits++
+		}
+		i += n
+	}
+
+	return Score{Value: normalize(raw), Raw: raw, Hits: hits}
+}
+
+func normalize(raw float64) float64 {
+	if raw == 0 {
+		return 0
+	}
+	return raw / math.Sqrt(raw*raw+normalizeAlpha)
+}
+
+func isClauseBreak(r rune) bool {
+	switch r {
+	case '，', '。', '！', '？', '；', '：', '、', ',', '.', '!', '?', ';', ':', '\n':
+		return true
+	}
+	return false
+}
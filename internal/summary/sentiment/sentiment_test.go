@@ -0,0 +1,85 @@
+package sentiment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScorerPolarity(t *testing.T) {
+	s := NewScorer(nil)
+	cases := []struct {
+		text  string
+		label string
+	}{
+		{"品牌增长势头强劲", Positive},
+		{"业绩下滑，用户投诉增多", Negative},
+		{"分析师不看好下季度表现", Negative},
+		{"并非增长，而是回落", Negative},
+		{"今天发布了新版本", Neutral},
+		{"新品体验 👍👍", Positive},
+		{"this product is great", Positive},
+		{"goodness gracious", Neutral},
+	}
+	for _, tc := range cases {
+		got := s.Score(tc.text)
+		if got.Label() != tc.label {
+			t.Errorf("%q: expected %s, got %s (%.3f)", tc.text, tc.label, got.Label(), got.Value)
+		}
+	}
+}
+
+func TestScorerDegreeAndWindow(t *testing.T) {
+	s := NewScorer(nil)
+	plain := s.Score("满意")
+	strong := s.Score("非常满意")
+	weak := s.Score("略微满意")
+	if !(strong.Raw > plain.Raw && plain.Raw > weak.Raw) {
+		t.Fatalf("expected degree ordering, got strong=%.2f plain=%.2f weak=%.2f", strong.Raw, plain.Raw, weak.Raw)
+	}
+
+	far := s.Score("不知道大家怎么看待这次满意度调查")
+	if far.Raw <= 0 {
+		t.Fatalf("negation outside window should not flip polarity, got %.2f", far.Raw)
+	}
+}
+
+func TestLoadLexiconMerge(t *testing.T) {
+	custom, err := LoadLexicon(strings.NewReader("[sentiment]\n遥遥领先 2\n[negation]\n难以\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lex := DefaultLexicon()
+	lex.Merge(custom)
+	s := NewScorer(lex)
+	if got := s.Score("遥遥领先").Label(); got != Positive {
+		t.Fatalf("expected custom word to be positive, got %s", got)
+	}
+	if got := s.Score("难以满意").Label(); got != Negative {
+		t.Fatalf("expected custom negation to flip polarity, got %s", got)
+	}
+
+	if _, err := LoadLexicon(strings.NewReader("[sentiment]\n增长\n")); err == nil {
+		t.Fatalf("expected error for missing weight")
+	}
+}
+
+func TestScoreAspect(t *testing.T) {
+	s := NewScorer(nil)
+	aspects, err := ParseAspects("价格=价格,贵;质量=质量,做工")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := "做工非常好，但是价格太贵了"
+	price, mentions := s.ScoreAspect(text, aspects[0])
+	if mentions != 2 || price.Label() != Negative {
+		t.Fatalf("expected negative price with 2 mentions, got %s/%d", price.Label(), mentions)
+	}
+	quality, mentions := s.ScoreAspect(text, aspects[1])
+	if mentions != 1 || quality.Label() != Positive {
+		t.Fatalf("expected positive quality with 1 mention, got %s/%d", quality.Label(), mentions)
+	}
+
+	if _, err := ParseAspects("价格"); err == nil {
+		t.Fatalf("expected error for definition without keywords")
+	}
+}
@@ -3,45 +3,130 @@ package simple
 import (
 	"context"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
 	"agentgo/internal/model"
+	"agentgo/internal/summary"
+	"agentgo/internal/summary/cluster"
+	"agentgo/internal/summary/entity"
+	"agentgo/internal/summary/sentiment"
+	"agentgo/internal/summary/timeline"
 )
 
+// Config 简单摘要器的可选配置。
+type Config struct {
+	// Lexicon 情感词典，为空时使用内置默认词典。
+	Lexicon *sentiment.Lexicon
+	// Aspects 需要单独统计情绪的维度，为空时使用内置维度。
+	Aspects []sentiment.Aspect
+	// Cluster 话题聚类参数。
+	Cluster cluster.Config
+	// Gazetteer 实体词表，为空时使用内置词表。
+	Gazetteer *entity.Gazetteer
+	// Templates 按语言组织的 overview/highlight 模板，为空时使用内置模板。
+	Templates *Templates
+	// Locale 请求未指定语言时使用的默认语言。
+	Locale string
+	// Timeline 时间线分桶与突增检测参数。
+	Timeline timeline.Config
+	// Now 时钟函数，为空时使用 time.Now，便于测试注入。
+	Now func() time.Time
+}
+
 // Summarizer 使用朴素统计算法生成摘要与关键词。
-type Summarizer struct{}
+type Summarizer struct {
+	scorer    *sentiment.Scorer
+	aspects   []sentiment.Aspect
+	cluster   cluster.Config
+	extractor *entity.Extractor
+	templates *Templates
+	locale    string
+	timeline  timeline.Config
+	now       func() time.Time
+}
 
 // New 创建简单摘要器。
 func New() *Summarizer {
-	return &Summarizer{}
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig 按配置创建简单摘要器。
+func NewWithConfig(cfg Config) *Summarizer {
+	aspects := cfg.Aspects
+	if len(aspects) == 0 {
+		aspects = sentiment.DefaultAspects()
+	}
+	templates := cfg.Templates
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	locale := summary.NormalizeLocale(cfg.Locale)
+	if locale == "" {
+		locale = summary.DefaultLocale
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &Summarizer{
+		scorer:    sentiment.NewScorer(cfg.Lexicon),
+		aspects:   aspects,
+		cluster:   cfg.Cluster,
+		extractor: entity.NewExtractor(cfg.Gazetteer),
+		templates: templates,
+		locale:    locale,
+		timeline:  cfg.Timeline,
+		now:       now,
+	}
 }
 
-// Summarize 生成概要信息。
-func (s *Summarizer) Summarize(_ context.Context, query string, results []model.Result) (model.Summary, error) {
+// Summarize 生成概要信息，语言与时间窗口取自 context 中的 summary.Options。
+func (s *Summarizer) Summarize(ctx context.Context, query string, results []model.Result) (model.Summary, error) {
+	opts := summary.OptionsFrom(ctx)
+	locale := summary.NormalizeLocale(opts.Locale)
+	if locale == "" {
+		locale = s.locale
+	}
+	now := s.now()
 	if len(results) == 0 {
-		return model.Summary{Query: query, GeneratedAt: time.Now()}, nil
+		return model.Summary{Query: query, Locale: locale, GeneratedAt: now}, nil
 	}
 
 	keywords := s.extractKeywords(results)
-	highlights := s.buildHighlights(results)
-	sentiment := s.estimateSentiment(results)
+	clusters := s.groupTopics(results)
+	highlights, err := s.buildHighlights(locale, results, clusters)
+	if err != nil {
+		return model.Summary{}, err
+	}
+	stats := s.scoreSentiment(results)
+	aspects := s.scoreAspects(results)
+	entities := s.extractEntities(results)
 	sourceCount := map[string]int{}
 	for _, r := range results {
 		sourceCount[r.Source]++
 	}
+	tl := timeline.Build(results, opts.Start, opts.End, now, s.timeline)
 
-	overview := s.buildOverview(query, results, highlights)
+	overview, err := s.buildOverview(locale, query, results, highlights, keywords, sentiment.LabelOf(stats.MeanScore), tl)
+	if err != nil {
+		return model.Summary{}, err
+	}
 	return model.Summary{
 		Query:           query,
+		Locale:          locale,
 		Overview:        overview,
 		Highlights:      highlights,
 		Keywords:        keywords,
-		Sentiment:       sentiment,
+		Topics:          topicsOf(results, clusters),
+		Entities:        entities,
+		Sentiment:       sentiment.LabelOf(stats.MeanScore),
+		SentimentStats:  stats,
+		Aspects:         aspects,
 		SourceBreakdown: sourceCount,
-		GeneratedAt:     time.Now(),
+		Timeline:        tl,
+		GeneratedAt:     now,
 	}, nil
 }
 
@@ -88,60 +173,182 @@ func (s *Summarizer) extractKeywords(results []model.Result) []string {
 	return result
 }
 
-func (s *Summarizer) buildHighlights(results []model.Result) []string {
+func (s *Summarizer) groupTopics(results []model.Result) []cluster.Cluster {
+	docs := make([]cluster.Document, len(results))
+	for i, r := range results {
+		docs[i] = cluster.Document{Text: r.Title + " " + r.Summary, Tags: r.Tags}
+	}
+	return cluster.Group(docs, s.cluster)
+}
+
+// buildHighlights 依次取最大几个话题的代表结果，话题不足时按原顺序补齐。
+func (s *Summarizer) buildHighlights(locale string, results []model.Result, clusters []cluster.Cluster) ([]string, error) {
 	limit := 3
 	if len(results) < limit {
 		limit = len(results)
 	}
+	picked := make([]int, 0, limit)
+	used := map[int]bool{}
+	for _, c := range clusters {
+		if len(picked) == limit {
+			break
+		}
+		picked = append(picked, c.Representative)
+		used[c.Representative] = true
+	}
+	for i := 0; i < len(results) && len(picked) < limit; i++ {
+		if !used[i] {
+			picked = append(picked, i)
+		}
+	}
+
 	highlights := make([]string, 0, limit)
-	for i := 0; i < limit; i++ {
-		r := results[i]
-		highlight := r.Title
-		if r.Summary != "" {
-			highlight += " - " + truncate(r.Summary, 80)
+	for _, idx := range picked {
+		r := results[idx]
+		highlight, err := s.templates.render(locale, highlightTemplate, highlightData{
+			Title:   r.Title,
+			Summary: truncate(r.Summary, 80),
+			Source:  r.Source,
+			URL:     r.URL,
+			Author:  r.Author,
+		})
+		if err != nil {
+			return nil, err
 		}
 		highlights = append(highlights, highlight)
 	}
-	return highlights
+	return highlights, nil
 }
 
-func (s *Summarizer) buildOverview(query string, results []model.Result, highlights []string) string {
+func (s *Summarizer) buildOverview(locale, query string, results []model.Result, highlights, keywords []string, mood string, tl *model.Timeline) (string, error) {
 	if len(results) == 0 {
-		return ""
-	}
-	builder := strings.Builder{}
-	builder.WriteString("围绕“")
-	builder.WriteString(query)
-	builder.WriteString("”共找到")
-	builder.WriteString(fmtInt(len(results)))
-	builder.WriteString("条相关讨论，主要集中在")
+		return "", nil
+	}
+	lead := results[0].Title
 	if len(highlights) > 0 {
-		builder.WriteString(highlights[0])
-	} else {
-		builder.WriteString(results[0].Title)
+		lead = highlights[0]
 	}
-	builder.WriteString("等话题。")
-	return builder.String()
+	return s.templates.render(locale, overviewTemplate, overviewData{
+		Query:      query,
+		Total:      len(results),
+		Lead:       lead,
+		Highlights: highlights,
+		Keywords:   keywords,
+		Sentiment:  mood,
+		Bursts:     burstViews(locale, tl),
+	})
 }
 
-func (s *Summarizer) estimateSentiment(results []model.Result) string {
+// scoreSentiment 为每条结果打分并写回 results，同时统计整体分布。
+func (s *Summarizer) scoreSentiment(results []model.Result) model.SentimentStats {
+	var stats model.SentimentStats
 	if len(results) == 0 {
-		return "neutral"
+		return stats
 	}
-	var positive, negative int
-	for _, r := range results {
-		text := strings.ToLower(r.Summary + " " + r.Title)
-		positive += countContains(text, []string{"good", "great", "上升", "突破", "增长", "机遇"})
-		negative += countContains(text, []string{"bad", "risk", "下降", "危机", "挑战", "下滑"})
-	}
-	switch {
-	case positive > negative:
-		return "positive"
-	case negative > positive:
-		return "negative"
-	default:
-		return "neutral"
+	var total float64
+	for i := range results {
+		score := s.scorer.Score(results[i].Title + "。" + results[i].Summary)
+		label := score.Label()
+		results[i].Sentiment = &model.SentimentScore{Score: score.Value, Label: label}
+		total += score.Value
+		switch label {
+		case sentiment.Positive:
+			stats.Positive++
+		case sentiment.Negative:
+			stats.Negative++
+		default:
+			stats.Neutral++
+		}
+	}
+	stats.MeanScore = total / float64(len(results))
+	return stats
+}
+
+// scoreAspects 按维度统计提及次数与情绪，仅计入提及该维度的分句。
+func (s *Summarizer) scoreAspects(results []model.Result) []model.AspectSentiment {
+	out := make([]model.AspectSentiment, 0, len(s.aspects))
+	for _, aspect := range s.aspects {
+		stat := model.AspectSentiment{Aspect: aspect.Name}
+		var total float64
+		for _, r := range results {
+			score, mentions := s.scorer.ScoreAspect(r.Title+"。"+r.Summary, aspect)
+			if mentions == 0 {
+				continue
+			}
+			stat.Mentions += mentions
+			stat.Results++
+			total += score.Value
+			switch score.Label() {
+			case sentiment.Positive:
+				stat.Positive++
+			case sentiment.Negative:
+				stat.Negative++
+			default:
+				stat.Neutral++
+			}
+		}
+		if stat.Results > 0 {
+			stat.MeanScore = total / float64(stat.Results)
+		}
+		out = append(out, stat)
+	}
+	return out
+}
+
+// extractEntities 为每条结果标注实体并写回 results，返回提及最多的实体。
+func (s *Summarizer) extractEntities(results []model.Result) []model.EntityMention {
+	type key struct{ name, typ string }
+	stats := map[key]*model.EntityMention{}
+	for i := range results {
+		entities := s.extractor.Extract(results[i].Title + "\n" + results[i].Summary)
+		results[i].Entities = entities
+		for _, e := range entities {
+			k := key{e.Name, e.Type}
+			m, ok := stats[k]
+			if !ok {
+				m = &model.EntityMention{Name: e.Name, Type: e.Type}
+				stats[k] = m
+			}
+			m.Mentions += e.Count
+			m.Results++
+		}
+	}
+
+	mentions := make([]model.EntityMention, 0, len(stats))
+	for _, m := range stats {
+		mentions = append(mentions, *m)
+	}
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].Mentions != mentions[j].Mentions {
+			return mentions[i].Mentions > mentions[j].Mentions
+		}
+		if mentions[i].Results != mentions[j].Results {
+			return mentions[i].Results > mentions[j].Results
+		}
+		return mentions[i].Name < mentions[j].Name
+	})
+	if len(mentions) > 10 {
+		mentions = mentions[:10]
+	}
+	return mentions
+}
+
+func topicsOf(results []model.Result, clusters []cluster.Cluster) []model.TopicCluster {
+	topics := make([]model.TopicCluster, 0, len(clusters))
+	for _, c := range clusters {
+		breakdown := map[string]int{}
+		for _, m := range c.Members {
+			breakdown[results[m].Source]++
+		}
+		topics = append(topics, model.TopicCluster{
+			Label:           strings.Join(c.Keywords, " / "),
+			Keywords:        c.Keywords,
+			Members:         c.Members,
+			Size:            len(c.Members),
+			SourceBreakdown: breakdown,
+		})
 	}
+	return topics
 }
 
 func tokenize(text string) []string {
@@ -165,20 +372,3 @@ func truncate(text string, length int) string {
 	runes := []rune(text)
 	return string(runes[:length]) + "…"
 }
-
-func countContains(text string, dictionary []string) int {
-	count := 0
-	for _, word := range dictionary {
-		if strings.Contains(text, strings.ToLower(word)) {
-			count++
-		}
-	}
-	return count
-}
-
-func fmtInt(n int) string {
-	if n < 0 {
-		return "0"
-	}
-	return strconv.Itoa(n)
-}
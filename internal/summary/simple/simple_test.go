@@ -2,10 +2,15 @@ package simple
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"agentgo/internal/model"
+	"agentgo/internal/summary"
+	"agentgo/internal/summary/timeline"
 )
 
 func TestSummarizer(t *testing.T) {
@@ -38,4 +43,72 @@ func TestSummarizer(t *testing.T) {
 	if len(summary.SourceBreakdown) != 2 {
 		t.Fatalf("expected source breakdown for 2 sources")
 	}
+	stats := summary.SentimentStats
+	if stats.Positive != 1 || stats.Negative != 1 {
+		t.Fatalf("unexpected sentiment stats: %+v", stats)
+	}
+	for _, r := range results {
+		if r.Sentiment == nil {
+			t.Fatalf("expected per-result sentiment for %s", r.Title)
+		}
+	}
+	if results[1].Sentiment.Label != "negative" {
+		t.Fatalf("expected second result negative, got %s", results[1].Sentiment.Label)
+	}
+}
+
+func TestSummarizerLocaleAndTemplates(t *testing.T) {
+	results := []model.Result{{Title: "Brand growth", Summary: "Great quarter", Source: "zhihu", PublishedAt: time.Now()}}
+
+	en, err := New().Summarize(summary.WithOptions(context.Background(), summary.Options{Locale: "en-US"}), "brand", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if en.Locale != summary.LocaleEN || !strings.HasPrefix(en.Overview, "Found 1 related post about \"brand\"") {
+		t.Fatalf("unexpected english overview: %s (%s)", en.Overview, en.Locale)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "zh-CN"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "zh-CN", "highlight.tmpl"), []byte("[{{.Source}}] {{.Title}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zh, err := NewWithConfig(Config{Templates: templates}).Summarize(context.Background(), "brand", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zh.Highlights[0] != "[zhihu] Brand growth" {
+		t.Fatalf("expected overridden highlight, got %s", zh.Highlights[0])
+	}
+	if !strings.HasPrefix(zh.Overview, "围绕“brand”共找到1条") {
+		t.Fatalf("expected built-in overview to remain, got %s", zh.Overview)
+	}
+}
+
+func TestSummarizerBurstOverview(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	now := time.Date(2025, 3, 1, 18, 30, 0, 0, loc)
+	results := []model.Result{{Title: "早间讨论", Source: "zhihu", PublishedAt: now.Add(-11 * time.Hour)}}
+	for _, minutes := range []int{260, 250, 225, 205, 180, 160} {
+		results = append(results, model.Result{Title: "笔记", Source: "xiaohongshu", PublishedAt: now.Add(-time.Duration(minutes) * time.Minute)})
+	}
+
+	s := NewWithConfig(Config{Now: func() time.Time { return now }, Timeline: timeline.Config{Location: loc}})
+	ctx := summary.WithOptions(context.Background(), summary.Options{Start: now.Add(-12 * time.Hour)})
+	got, err := s.Summarize(ctx, "新品", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.GeneratedAt.Equal(now) {
+		t.Fatalf("expected injected clock, got %s", got.GeneratedAt)
+	}
+	if !strings.Contains(got.Overview, "小红书在14:00至16:00期间讨论量激增") {
+		t.Fatalf("expected burst in overview, got %s", got.Overview)
+	}
 }
@@ -0,0 +1,190 @@
+package simple
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"agentgo/internal/model"
+	"agentgo/internal/summary"
+	"agentgo/internal/summary/timeline"
+)
+
+//go:embed templates
+var defaultTemplateFS embed.FS
+
+const (
+	overviewTemplate  = "overview"
+	highlightTemplate = "highlight"
+)
+
+var templateNames = []string{overviewTemplate, highlightTemplate}
+
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"truncate": truncate,
+}
+
+// Templates 按语言组织的摘要模板，每种语言包含 overview 与 highlight 两个模板。
+type Templates struct {
+	sets map[string]map[string]*template.Template
+}
+
+// overviewData 渲染 overview 模板时可用的字段。
+type overviewData struct {
+	Query      string
+	Total      int
+	Lead       string
+	Highlights []string
+	Keywords   []string
+	Sentiment  string
+	// Bursts 按数量降序排列的突增，最多两条。
+	Bursts []burstView
+}
+
+// burstView 模板中使用的突增描述，时间已按粒度格式化。
+type burstView struct {
+	Source string
+	Start  string
+	End    string
+	Count  int
+}
+
+// highlightData 渲染 highlight 模板时可用的字段。
+type highlightData struct {
+	Title   string
+	Summary string
+	Source  string
+	URL     string
+	Author  string
+}
+
+var sourceNames = map[string]map[string]string{
+	summary.LocaleZhCN: {"zhihu": "知乎", "wechat": "微信公众号", "xiaohongshu": "小红书", "weibo": "微博", "douyin": "抖音"},
+	summary.LocaleZhTW: {"zhihu": "知乎", "wechat": "微信公眾號", "xiaohongshu": "小紅書", "weibo": "微博", "douyin": "抖音"},
+	summary.LocaleEN:   {"zhihu": "Zhihu", "wechat": "WeChat", "xiaohongshu": "Xiaohongshu", "weibo": "Weibo", "douyin": "Douyin"},
+}
+
+// sourceName 返回来源在指定语言下的展示名称，未知来源原样返回。
+func sourceName(locale, source string) string {
+	if name, ok := sourceNames[locale][source]; ok {
+		return name
+	}
+	return source
+}
+
+func burstViews(locale string, tl *model.Timeline) []burstView {
+	if tl == nil || len(tl.Bursts) == 0 {
+		return nil
+	}
+	bursts := append([]model.Burst(nil), tl.Bursts...)
+	sort.SliceStable(bursts, func(i, j int) bool {
+		return bursts[i].Count > bursts[j].Count
+	})
+	if len(bursts) > 2 {
+		bursts = bursts[:2]
+	}
+
+	layout := "15:04"
+	if tl.Granularity == timeline.Day {
+		layout = "01-02"
+	} else if len(tl.Buckets) > 0 && tl.Buckets[0].Start.YearDay() != tl.Buckets[len(tl.Buckets)-1].Start.YearDay() {
+		layout = "01-02 15:04"
+	}
+	views := make([]burstView, 0, len(bursts))
+	for _, b := range bursts {
+		end := b.End
+		if tl.Granularity == timeline.Day {
+			end = end.AddDate(0, 0, -1)
+		}
+		views = append(views, burstView{
+			Source: sourceName(locale, b.Source),
+			Start:  b.Start.Format(layout),
+			End:    end.Format(layout),
+			Count:  b.Count,
+		})
+	}
+	return views
+}
+
+// DefaultTemplates 返回内置的 zh-CN、zh-TW、en 模板。
+func DefaultTemplates() *Templates {
+	t := &Templates{sets: map[string]map[string]*template.Template{}}
+	for _, locale := range []string{summary.LocaleZhCN, summary.LocaleZhTW, summary.LocaleEN} {
+		for _, name := range templateNames {
+			raw, err := defaultTemplateFS.ReadFile("templates/" + locale + "/" + name + ".tmpl")
+			if err != nil {
+				panic("simple: missing default template: " + err.Error())
+			}
+			if err := t.add(locale, name, string(raw)); err != nil {
+				panic("simple: invalid default template: " + err.Error())
+			}
+		}
+	}
+	return t
+}
+
+// LoadTemplates 在内置模板基础上加载 dir/<locale>/<name>.tmpl 覆盖，缺失的文件沿用内置模板。
+func LoadTemplates(dir string) (*Templates, error) {
+	t := DefaultTemplates()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := summary.NormalizeLocale(entry.Name())
+		if locale == "" {
+			return nil, fmt.Errorf("unsupported template locale %q", entry.Name())
+		}
+		for _, name := range templateNames {
+			raw, err := os.ReadFile(filepath.Join(dir, entry.Name(), name+".tmpl"))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := t.add(locale, name, string(raw)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+func (t *Templates) add(locale, name, text string) error {
+	tmpl, err := template.New(locale + "/" + name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	if t.sets[locale] == nil {
+		t.sets[locale] = map[string]*template.Template{}
+	}
+	t.sets[locale][name] = tmpl
+	return nil
+}
+
+// render 渲染指定语言的模板，缺失时回退到默认语言。
+func (t *Templates) render(locale, name string, data any) (string, error) {
+	tmpl := t.sets[locale][name]
+	if tmpl == nil {
+		tmpl = t.sets[summary.DefaultLocale][name]
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("template %s/%s not found", locale, name)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
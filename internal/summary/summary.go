@@ -7,6 +7,8 @@ import (
 )
 
 // Summarizer 负责根据聚合结果生成概要。
+//
+// 实现可以就地为 results 补充逐条分析字段（如情绪得分），调用方会一并返回给客户端。
 type Summarizer interface {
 	Summarize(ctx context.Context, query string, results []model.Result) (model.Summary, error)
 }
@@ -0,0 +1,172 @@
+package timeline
+
+import (
+	"sort"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+// 时间桶粒度。
+const (
+	Hour = "hour"
+	Day  = "day"
+)
+
+const (
+	// hourlySpan 时间跨度不超过该值时按小时分桶，否则按天。
+	hourlySpan = 72 * time.Hour
+	// maxBuckets 单个时间线最多保留的桶数，超出时从最早一端截断。
+	maxBuckets = 1000
+)
+
+// Config 时间线与突增检测参数。
+type Config struct {
+	// Location 分桶使用的时区，为空时使用 time.Local。
+	Location *time.Location
+	// BurstFactor 桶内数量超过基线的倍数时视为突增。
+	BurstFactor float64
+	// MinBurstCount 判定突增所需的最小桶内数量。
+	MinBurstCount int
+}
+
+// Build 按来源统计每个时间桶的结果数量并检测突增。
+//
+// start、end 为查询的时间窗口，零值时分别取最早结果时间与 now。
+func Build(results []model.Result, start, end, now time.Time, cfg Config) *model.Timeline {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.BurstFactor <= 0 {
+		cfg.BurstFactor = 2.5
+	}
+	if cfg.MinBurstCount <= 0 {
+		cfg.MinBurstCount = 3
+	}
+
+	if start.IsZero() || end.IsZero() {
+		var first, last time.Time
+		for _, r := range results {
+			if r.PublishedAt.IsZero() {
+				continue
+			}
+			if first.IsZero() || r.PublishedAt.Before(first) {
+				first = r.PublishedAt
+			}
+			if r.PublishedAt.After(last) {
+				last = r.PublishedAt
+			}
+		}
+		if first.IsZero() {
+			return nil
+		}
+		if start.IsZero() {
+			start = first
+		}
+		if end.IsZero() {
+			end = now
+			if last.After(end) {
+				end = last
+			}
+		}
+	}
+	if !end.After(start) {
+		end = start.Add(time.Nanosecond)
+	}
+
+	granularity := Hour
+	if end.Sub(start) > hourlySpan {
+		granularity = Day
+	}
+	first := floor(start.In(cfg.Location), granularity)
+	bucketStarts := make([]time.Time, 0)
+	for t := first; t.Before(end); t = next(t, granularity) {
+		bucketStarts = append(bucketStarts, t)
+	}
+	if len(bucketStarts) > maxBuckets {
+		bucketStarts = bucketStarts[len(bucketStarts)-maxBuckets:]
+	}
+
+	buckets := make([]model.TimelineBucket, len(bucketStarts))
+	for i, t := range bucketStarts {
+		buckets[i] = model.TimelineBucket{Start: t, BySource: map[string]int{}}
+	}
+	sources := map[string]struct{}{}
+	for _, r := range results {
+		if r.PublishedAt.IsZero() || r.PublishedAt.Before(bucketStarts[0]) || !r.PublishedAt.Before(end) {
+			continue
+		}
+		idx := sort.Search(len(bucketStarts), func(i int) bool {
+			return bucketStarts[i].After(r.PublishedAt)
+		}) - 1
+		buckets[idx].Total++
+		buckets[idx].BySource[r.Source]++
+		sources[r.Source] = struct{}{}
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bursts := make([]model.Burst, 0)
+	for _, source := range names {
+		bursts = append(bursts, detectBursts(source, buckets, granularity, cfg)...)
+	}
+
+	return &model.Timeline{
+		Granularity: granularity,
+		Buckets:     buckets,
+		Bursts:      bursts,
+	}
+}
+
+// detectBursts 将数量显著高于其余桶均值的连续桶合并为一次突增。
+func detectBursts(source string, buckets []model.TimelineBucket, granularity string, cfg Config) []model.Burst {
+	if len(buckets) < 2 {
+		return nil
+	}
+	total := 0
+	for _, b := range buckets {
+		total += b.BySource[source]
+	}
+
+	bursts := make([]model.Burst, 0)
+	var current *model.Burst
+	var baselines float64
+	var spanned int
+	for _, b := range buckets {
+		count := b.BySource[source]
+		baseline := float64(total-count) / float64(len(buckets)-1)
+		if count >= cfg.MinBurstCount && float64(count) >= cfg.BurstFactor*baseline {
+			if current == nil {
+				bursts = append(bursts, model.Burst{Source: source, Start: b.Start})
+				current = &bursts[len(bursts)-1]
+				baselines, spanned = 0, 0
+			}
+			current.End = next(b.Start, granularity)
+			current.Count += count
+			baselines += baseline
+			spanned++
+			current.Baseline = baselines / float64(spanned)
+			continue
+		}
+		current = nil
+	}
+	return bursts
+}
+
+func floor(t time.Time, granularity string) time.Time {
+	if granularity == Day {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+func next(t time.Time, granularity string) time.Time {
+	if granularity == Day {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
@@ -0,0 +1,63 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"agentgo/internal/model"
+)
+
+func TestBuildDetectsBurst(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	now := time.Date(2025, 3, 1, 18, 30, 0, 0, loc)
+	start := now.Add(-12 * time.Hour)
+
+	results := []model.Result{
+		{Source: "zhihu", PublishedAt: now.Add(-11 * time.Hour)},
+		{Source: "zhihu", PublishedAt: now.Add(-6 * time.Hour)},
+	}
+	for _, offset := range []time.Duration{-260 * time.Minute, -250 * time.Minute, -225 * time.Minute, -205 * time.Minute, -180 * time.Minute, -160 * time.Minute} {
+		results = append(results, model.Result{Source: "xiaohongshu", PublishedAt: now.Add(offset)})
+	}
+
+	tl := Build(results, start, time.Time{}, now, Config{Location: loc})
+	if tl.Granularity != Hour {
+		t.Fatalf("expected hourly buckets, got %s", tl.Granularity)
+	}
+	if len(tl.Buckets) != 13 {
+		t.Fatalf("expected 13 buckets, got %d", len(tl.Buckets))
+	}
+	total := 0
+	for _, b := range tl.Buckets {
+		total += b.Total
+	}
+	if total != len(results) {
+		t.Fatalf("expected %d results bucketed, got %d", len(results), total)
+	}
+
+	if len(tl.Bursts) != 1 {
+		t.Fatalf("expected 1 burst, got %+v", tl.Bursts)
+	}
+	burst := tl.Bursts[0]
+	if burst.Source != "xiaohongshu" || burst.Count != 6 {
+		t.Fatalf("unexpected burst: %+v", burst)
+	}
+	if got := burst.Start.Format("15:04") + "-" + burst.End.Format("15:04"); got != "14:00-16:00" {
+		t.Fatalf("unexpected burst window %s", got)
+	}
+}
+
+func TestBuildDailyGranularity(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	results := []model.Result{
+		{Source: "wechat", PublishedAt: now.AddDate(0, 0, -6)},
+		{Source: "wechat", PublishedAt: now.AddDate(0, 0, -1)},
+	}
+	tl := Build(results, time.Time{}, time.Time{}, now, Config{Location: time.UTC})
+	if tl.Granularity != Day || len(tl.Buckets) != 7 {
+		t.Fatalf("expected 7 daily buckets, got %s/%d", tl.Granularity, len(tl.Buckets))
+	}
+	if Build(nil, time.Time{}, time.Time{}, now, Config{}) != nil {
+		t.Fatalf("expected nil timeline without results")
+	}
+}